@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// serverConfig is the JSON form of a server definition in the configuration file
+type serverConfig struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	POAs    []string `json:"poas"`
+}
+
+// config is the JSON configuration file of the daemon
+type config struct {
+	Servers []serverConfig `json:"servers"`
+}
+
+func main() {
+	// Parse command line flags
+	endpoint := flag.String("endpoint", "iiop://0.0.0.0:2809", "Endpoint the repository listens on")
+	host := flag.String("host", "", "Host name advertised to servers and clients, if different from the listen address")
+	configFile := flag.String("config", "", "JSON file listing the servers the repository can launch")
+	timeout := flag.Duration("startup-timeout", corba.DefaultServerStartupTimeout, "How long to wait for a launched server to register")
+	help := flag.Bool("help", false, "Show help message")
+
+	flag.Parse()
+
+	if *help {
+		showHelp()
+		return
+	}
+
+	ep, err := corba.ParseEndpoint(*endpoint)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	orb := corba.Init()
+	if *host != "" {
+		orb.SetPublishedHost(*host)
+	}
+
+	imr := corba.NewImplRepository(orb)
+	imr.SetStartupTimeout(*timeout)
+
+	// Load the server definitions
+	if *configFile != "" {
+		if err := loadConfig(imr, *configFile); err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if _, err := imr.Start(ep); err != nil {
+		fmt.Printf("Error starting implementation repository: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Implementation repository running; servers register with %s\n", orb.Endpoints()[0])
+
+	// Run until interrupted
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Println("Shutting down implementation repository")
+		orb.Shutdown(true)
+	}()
+
+	orb.Run()
+}
+
+// loadConfig adds the servers listed in a configuration file to the repository
+func loadConfig(imr *corba.ImplRepository, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	for _, server := range cfg.Servers {
+		def := corba.ServerDefinition{
+			Name:    server.Name,
+			Command: server.Command,
+			Args:    server.Args,
+			POAs:    server.POAs,
+		}
+		if err := imr.AddServer(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func showHelp() {
+	fmt.Println("CORBA Implementation Repository")
+	fmt.Println("Usage: imr [options]")
+	fmt.Println("")
+	fmt.Println("Options:")
+	flag.PrintDefaults()
+	fmt.Println("")
+	fmt.Println("Servers with persistent POAs register with the repository when their ORB")
+	fmt.Println("calls UseImplRepository, and the references those POAs create point at the")
+	fmt.Println("repository. Clients are forwarded to the running server. Servers listed in")
+	fmt.Println("the configuration file with a command are launched on demand, with")
+	fmt.Printf("%s and %s set in their environment.\n", corba.ImplRepositoryEndpointEnv, corba.ImplRepositoryServerEnv)
+	fmt.Println("")
+	fmt.Println("Configuration file:")
+	fmt.Println(`  {"servers": [{"name": "bank", "command": "/usr/local/bin/bank-server",`)
+	fmt.Println(`                "args": ["-v"], "poas": ["Bank"]}]}`)
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  imr -endpoint iiop://0.0.0.0:2809 -config servers.json")
+}
@@ -0,0 +1,218 @@
+package corba_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// shardActivator creates persistent shard POAs served by one default servant.
+// Activation waits for the gate to be closed. The "refused" shard is not
+// created, and the activator panics for the "broken" one.
+type shardActivator struct {
+	calls   atomic.Int32
+	gate    chan struct{}
+	servant *concurrencyServant
+}
+
+func (a *shardActivator) UnknownAdapter(parent *corba.POA, name string) bool {
+	a.calls.Add(1)
+	<-a.gate
+	switch name {
+	case "refused":
+		return false
+	case "broken":
+		panic("shard storage unavailable")
+	}
+
+	shard, err := parent.CreatePOA(name, nil, []corba.POAPolicy{
+		corba.NewLifespanPolicy(corba.PersistentLifespan),
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+		corba.NewIdUniquenessPolicy(corba.MultipleID),
+		corba.NewRequestProcessingPolicy(corba.UseDefaultServant),
+	})
+	if err != nil {
+		return false
+	}
+	shard.SetDefaultServant(a.servant)
+	return true
+}
+
+func TestFindPOAActivatesAdapter(t *testing.T) {
+	root := corba.Init().GetRootPOA()
+	activator := &shardActivator{gate: make(chan struct{}), servant: newConcurrencyServant()}
+	close(activator.gate)
+	root.SetAdapterActivator(activator)
+
+	if _, err := root.FindPOA("shard-1", false); err != corba.ErrAdapterNonExistent {
+		t.Fatalf("FindPOA without activate = %v, want ErrAdapterNonExistent", err)
+	}
+	if activator.calls.Load() != 0 {
+		t.Fatal("adapter activator called without activate")
+	}
+
+	shard, err := root.FindPOA("shard-1", true)
+	if err != nil || shard == nil {
+		t.Fatalf("FindPOA = %v, %v", shard, err)
+	}
+	if again, _ := root.FindPOA("shard-1", true); again != shard || activator.calls.Load() != 1 {
+		t.Errorf("existing POA activated again (%d calls)", activator.calls.Load())
+	}
+
+	if _, err := root.FindPOA("refused", true); err != corba.ErrAdapterNonExistent {
+		t.Errorf("refused activation = %v, want ErrAdapterNonExistent", err)
+	}
+	_, err = root.FindPOA("broken", true)
+	wantMinorCode(t, err, "OBJ_ADAPTER", corba.MinorAdapterActivatorFailed)
+}
+
+func TestRequestsHeldDuringAdapterActivation(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, "adapter-activation")
+	activator := &shardActivator{gate: make(chan struct{}), servant: newConcurrencyServant()}
+	orb.GetRootPOA().SetAdapterActivator(activator)
+
+	conn, err := corba.NewMemTransport().Connect(ep)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Both requests name a shard that does not exist yet
+	key := corba.ObjectKey{POAPath: []string{"shard-42"}, Persistent: true, ObjectID: corba.ObjectID("acct")}
+	sendRawRequest(t, conn, 1, key.Encode(), "ping")
+	sendRawRequest(t, conn, 2, key.Encode(), "ping")
+
+	select {
+	case <-activator.servant.started:
+		t.Fatal("request dispatched before the adapter activator finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(activator.gate)
+	for i := 0; i < 2; i++ {
+		if reply := readRawReply(t, conn); reply.ReplyStatus != giop.ReplyStatusNoException {
+			t.Fatalf("reply %d has status %d", reply.RequestID, reply.ReplyStatus)
+		}
+	}
+	if n := activator.calls.Load(); n != 1 {
+		t.Errorf("adapter activator called %d times, want 1", n)
+	}
+
+	// A POA the activator refuses to create does not exist
+	refused := corba.ObjectKey{POAPath: []string{"refused"}, Persistent: true, ObjectID: corba.ObjectID("acct")}
+	sendRawRequest(t, conn, 3, refused.Encode(), "ping")
+	if reply := readRawReply(t, conn); reply.ReplyStatus != giop.ReplyStatusSystemException {
+		t.Errorf("request for refused POA has status %d", reply.ReplyStatus)
+	}
+	if n := activator.calls.Load(); n != 2 {
+		t.Errorf("adapter activator called %d times for two POAs, want 2", n)
+	}
+}
+
+func TestShutdownWaitsForAdapterActivation(t *testing.T) {
+	orb := corba.Init()
+	server, conn, _, _ := shutdownFixture(t, orb, t.Name())
+	activator := &shardActivator{gate: make(chan struct{}), servant: newConcurrencyServant()}
+	orb.GetRootPOA().SetAdapterActivator(activator)
+
+	key := corba.ObjectKey{POAPath: []string{"shard-7"}, Persistent: true, ObjectID: corba.ObjectID("acct")}
+	sendRawRequest(t, conn, 1, key.Encode(), "ping")
+	for activator.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The request waiting for its POA is in flight
+	done := shutdownAsync(server, context.Background())
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown completed during adapter activation: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(activator.gate)
+	if reply := readRawReply(t, conn); reply.ReplyStatus != giop.ReplyStatusNoException {
+		t.Errorf("reply has status %d", reply.ReplyStatus)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("ShutdownContext: %v", err)
+	}
+}
+
+func TestAdapterActivationRunsOnWorkers(t *testing.T) {
+	orb := corba.Init()
+	if err := orb.SetMaxConcurrentRequests(1); err != nil {
+		t.Fatalf("SetMaxConcurrentRequests: %v", err)
+	}
+	_, conn, key, servant := shutdownFixture(t, orb, t.Name())
+
+	// The only worker is busy
+	sendRawRequest(t, conn, 1, key, "block")
+	<-servant.started
+
+	// A POA nobody can create is reported missing without a worker
+	missing := corba.ObjectKey{POAPath: []string{"missing"}, Persistent: true, ObjectID: corba.ObjectID("acct")}
+	sendRawRequest(t, conn, 2, missing.Encode(), "ping")
+	if reply := readRawReply(t, conn); reply.RequestID != 2 || reply.ReplyStatus != giop.ReplyStatusSystemException {
+		t.Fatalf("got reply %d with status %d, want a system exception for request 2", reply.RequestID, reply.ReplyStatus)
+	}
+
+	// Adapter activation waits for a worker
+	activator := &shardActivator{gate: make(chan struct{}), servant: newConcurrencyServant()}
+	close(activator.gate)
+	orb.GetRootPOA().SetAdapterActivator(activator)
+	shard := corba.ObjectKey{POAPath: []string{"shard-3"}, Persistent: true, ObjectID: corba.ObjectID("acct")}
+	sendRawRequest(t, conn, 3, shard.Encode(), "ping")
+	time.Sleep(50 * time.Millisecond)
+	if n := activator.calls.Load(); n != 0 {
+		t.Fatalf("adapter activator called %d times while no worker was free", n)
+	}
+
+	close(servant.gate)
+	for _, id := range []uint32{1, 3} {
+		if reply := readRawReply(t, conn); reply.RequestID != id || reply.ReplyStatus != giop.ReplyStatusNoException {
+			t.Fatalf("got reply %d with status %d, want request %d to succeed", reply.RequestID, reply.ReplyStatus, id)
+		}
+	}
+}
+
+func TestLocateActivatesAdapter(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, t.Name())
+	activator := &shardActivator{gate: make(chan struct{}), servant: newConcurrencyServant()}
+	close(activator.gate)
+	orb.GetRootPOA().SetAdapterActivator(activator)
+
+	conn, err := corba.NewMemTransport().Connect(ep)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	for i, tt := range []struct {
+		shard string
+		want  uint32
+	}{
+		{"shard-5", giop.LocateStatusObjectHere},
+		{"shard-5", giop.LocateStatusObjectHere},
+		{"refused", giop.LocateStatusUnknownObject},
+	} {
+		key := corba.ObjectKey{POAPath: []string{tt.shard}, Persistent: true, ObjectID: corba.ObjectID("acct")}
+		data, _ := giop.MarshalGIOPMessage(&giop.Message{
+			Header: giop.NewMessageHeader(giop.MsgLocateRequest, 0),
+			Body:   &giop.LocateRequestHeader{RequestID: uint32(i), ObjectKey: key.Encode()},
+		})
+		conn.Write(data)
+		reply, ok := readRawMessage(t, conn).Body.(*giop.LocateReplyHeader)
+		if !ok || reply.Status != tt.want {
+			t.Fatalf("locate reply for %s = %+v, want status %d", tt.shard, reply, tt.want)
+		}
+	}
+	if n := activator.calls.Load(); n != 2 {
+		t.Errorf("adapter activator called %d times for two POAs, want 2", n)
+	}
+}
@@ -0,0 +1,744 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ifabos/go-corba/giop"
+)
+
+// marshalValue writes a value in the CDR representation of the type described
+// by tc. Go values are converted to the IDL type where possible, and a nil
+// value is written as the zero value of the type. Structs and exceptions take
+// their members from a map, from a GetMember method or from struct fields.
+func marshalValue(m *giop.CDRMarshaller, tc TypeCode, value interface{}) error {
+	kind, err := tcKindOf(tc)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+
+	switch kind {
+	case TC_NULL, TC_VOID:
+		return nil
+	case TC_BOOLEAN:
+		b := false
+		if rv.IsValid() {
+			if rv.Kind() != reflect.Bool {
+				return fmt.Errorf("%w: %T is not a boolean", ErrTypeMismatch, value)
+			}
+			b = rv.Bool()
+		}
+		m.WriteBool(b)
+	case TC_CHAR, TC_OCTET:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteOctet(byte(n))
+	case TC_WCHAR:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteWChar(rune(n))
+	case TC_SHORT:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteShort(int16(n))
+	case TC_USHORT:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteUShort(uint16(n))
+	case TC_LONG:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteLong(int32(n))
+	case TC_ULONG, TC_ENUM:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteULong(uint32(n))
+	case TC_LONGLONG:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteLongLong(n)
+	case TC_ULONGLONG:
+		n, err := intValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteULongLong(uint64(n))
+	case TC_FLOAT:
+		f, err := floatValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteFloat(float32(f))
+	case TC_DOUBLE:
+		f, err := floatValue(rv)
+		if err != nil {
+			return err
+		}
+		m.WriteDouble(f)
+	case TC_STRING, TC_WSTRING:
+		s := ""
+		if rv.IsValid() {
+			if rv.Kind() != reflect.String {
+				return fmt.Errorf("%w: %T is not a string", ErrTypeMismatch, value)
+			}
+			s = rv.String()
+		}
+		if bound := tc.(TypeCodeImpl).Length(); bound > 0 && len([]rune(s)) > bound {
+			return fmt.Errorf("string of %d characters exceeds its bound of %d", len([]rune(s)), bound)
+		}
+		m.WriteString(s)
+	case TC_SEQUENCE:
+		return marshalSequence(m, tc, rv)
+	case TC_ARRAY:
+		return marshalArray(m, tc, rv)
+	case TC_STRUCT, TC_EXCEPT:
+		return marshalMembers(m, tc, value)
+	case TC_UNION:
+		return marshalUnion(m, tc, rv)
+	case TC_ALIAS:
+		original, err := tc.(TypeCodeImpl).ContentType()
+		if err != nil {
+			return err
+		}
+		return marshalValue(m, original, value)
+	case TC_TYPECODE:
+		typeCode, ok := value.(TypeCode)
+		if !ok && value != nil {
+			return fmt.Errorf("%w: %T is not a TypeCode", ErrTypeMismatch, value)
+		}
+		if typeCode == nil {
+			typeCode, _ = GetBasicTypeCode(TC_NULL)
+		}
+		return marshalTypeCode(m, typeCode)
+	case TC_ANY:
+		return marshalAny(m, value)
+	case TC_FIXED:
+		return marshalFixed(m, tc, rv)
+	default:
+		return fmt.Errorf("%w: cannot marshal %s values", ErrUnsupportedType, kind)
+	}
+	return nil
+}
+
+// marshalSequence writes the length and elements of a slice or array
+func marshalSequence(m *giop.CDRMarshaller, tc TypeCode, rv reflect.Value) error {
+	elementType, err := tc.(TypeCodeImpl).ContentType()
+	if err != nil {
+		return err
+	}
+
+	if !rv.IsValid() {
+		m.WriteULong(0)
+		return nil
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("%w: %s is not a sequence", ErrTypeMismatch, rv.Type())
+	}
+	if bound := tc.(TypeCodeImpl).Length(); bound > 0 && rv.Len() > bound {
+		return fmt.Errorf("sequence of %d elements exceeds its bound of %d", rv.Len(), bound)
+	}
+
+	if kind, _ := tcKindOf(elementType); kind == TC_OCTET && rv.Type().Elem().Kind() == reflect.Uint8 {
+		m.WriteOctetSequence(rv.Bytes())
+		return nil
+	}
+
+	m.WriteULong(uint32(rv.Len()))
+	for i := 0; i < rv.Len(); i++ {
+		if err := marshalValue(m, elementType, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalMembers writes the members of a struct or exception value in the
+// order of its TypeCode
+func marshalMembers(m *giop.CDRMarshaller, tc TypeCode, value interface{}) error {
+	impl, ok := tc.(TypeCodeImpl)
+	if !ok {
+		return fmt.Errorf("%w: %s has no member information", ErrInvalidTypeCode, tc.Id())
+	}
+
+	for i := 0; i < impl.MemberCount(); i++ {
+		name, err := impl.MemberName(i)
+		if err != nil {
+			return err
+		}
+		memberType, err := impl.MemberType(i)
+		if err != nil {
+			return err
+		}
+		if err := marshalValue(m, memberType, memberValue(value, name)); err != nil {
+			return fmt.Errorf("member %s of %s: %w", name, tc.Name(), err)
+		}
+	}
+	return nil
+}
+
+// memberValue returns the named member of a struct or exception value, or nil
+// if the value does not have it
+func memberValue(value interface{}, name string) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		return v[name]
+	case interface {
+		GetMember(string) (interface{}, bool)
+	}:
+		member, _ := v.GetMember(name)
+		return member
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	if field := structField(rv, name); field.IsValid() {
+		return field.Interface()
+	}
+	return nil
+}
+
+// structField returns the exported field of a struct value that holds an IDL
+// member: the field tagged `corba:"name"`, or the one named like the member
+func structField(rv reflect.Value, name string) reflect.Value {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() && f.Tag.Get("corba") == name {
+			return rv.Field(i)
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() && !f.Anonymous && strings.EqualFold(f.Name, name) {
+			return rv.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// marshalArray writes the elements of an array, which has as many elements
+// as its TypeCode says
+func marshalArray(m *giop.CDRMarshaller, tc TypeCode, rv reflect.Value) error {
+	impl := tc.(TypeCodeImpl)
+	elementType, err := impl.ContentType()
+	if err != nil {
+		return err
+	}
+
+	if !rv.IsValid() {
+		// Write an array of zero values
+		for i := 0; i < impl.Length(); i++ {
+			if err := marshalValue(m, elementType, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("%w: %s is not an array", ErrTypeMismatch, rv.Type())
+	}
+	if rv.Len() != impl.Length() {
+		return fmt.Errorf("%w: array of %d elements needs %d", ErrTypeMismatch, rv.Len(), impl.Length())
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		if err := marshalValue(m, elementType, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalUnion writes the discriminator and active member of a union value: a
+// UnionValue, or a struct with Discriminant and Value fields
+func marshalUnion(m *giop.CDRMarshaller, tc TypeCode, rv reflect.Value) error {
+	impl := tc.(TypeCodeImpl)
+	discriminatorType, err := impl.DiscriminatorType()
+	if err != nil {
+		return err
+	}
+
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %v is not a union", ErrTypeMismatch, rv.Type())
+	}
+	discriminant, member := rv.FieldByName("Discriminant"), rv.FieldByName("Value")
+	if !discriminant.IsValid() || !member.IsValid() {
+		return fmt.Errorf("%w: %s has no Discriminant and Value fields", ErrTypeMismatch, rv.Type())
+	}
+
+	if err := marshalLabel(m, discriminatorType, discriminant.Interface()); err != nil {
+		return err
+	}
+	index := unionMemberIndex(impl, discriminant.Interface())
+	if index < 0 {
+		return nil
+	}
+	memberType, err := impl.MemberType(index)
+	if err != nil {
+		return err
+	}
+	return marshalValue(m, memberType, member.Interface())
+}
+
+// unionMemberIndex returns the index of the union member selected by a
+// discriminator value: the member with a matching label, or the default
+// member. It returns -1 if the value selects no member.
+func unionMemberIndex(tc TypeCodeImpl, discriminator interface{}) int {
+	discriminatorType, _ := tc.DiscriminatorType()
+	key := labelKey(discriminator)
+	if name, ok := discriminator.(string); ok {
+		if ordinal, ok := enumOrdinal(discriminatorType, name); ok {
+			key = int64(ordinal)
+		}
+	}
+
+	for i := 0; i < tc.MemberCount(); i++ {
+		if i == tc.DefaultIndex() {
+			continue
+		}
+		label, _ := tc.MemberLabel(i)
+		if name, ok := label.(string); ok {
+			if ordinal, ok := enumOrdinal(discriminatorType, name); ok {
+				label = ordinal
+			}
+		}
+		if labelKey(label) == key {
+			return i
+		}
+	}
+	return tc.DefaultIndex()
+}
+
+// marshalAny writes the TypeCode and value of an Any. A nil Any is written
+// as holding no value.
+func marshalAny(m *giop.CDRMarshaller, value interface{}) error {
+	any, ok := value.(*Any)
+	if !ok && value != nil {
+		return fmt.Errorf("%w: %T is not an Any", ErrTypeMismatch, value)
+	}
+	if any == nil || any.TypeCode() == nil {
+		any = nullAny()
+	}
+
+	if err := marshalTypeCode(m, any.TypeCode()); err != nil {
+		return err
+	}
+	return marshalValue(m, any.TypeCode(), any.Value())
+}
+
+// marshalFixed writes a fixed point decimal as packed decimal digits followed
+// by a sign, truncating digits beyond the scale of its type
+func marshalFixed(m *giop.CDRMarshaller, tc TypeCode, rv reflect.Value) error {
+	info, ok := tc.(fixedTypeInfo)
+	if !ok {
+		return fmt.Errorf("%w: fixed type without digits", ErrInvalidTypeCode)
+	}
+
+	r := new(big.Rat)
+	if rv.IsValid() {
+		if r, ok = decimalValue(rv); !ok {
+			return fmt.Errorf("%w: %v is not a decimal", ErrTypeMismatch, rv)
+		}
+	}
+	units, _, ok := fixedUnits(r, info.FixedDigits(), info.FixedScale())
+	if !ok {
+		return fmt.Errorf("%s has too many digits for %s", r.FloatString(int(info.FixedScale())), tc.Name())
+	}
+
+	// An odd number of nibbles precedes the sign, so an even number of
+	// digits is preceded by a zero nibble
+	octets := int(info.FixedDigits())/2 + 1
+	digits := fmt.Sprintf("%0*s", 2*octets-1, new(big.Int).Abs(units).String())
+	sign := byte(0xc)
+	if units.Sign() < 0 {
+		sign = 0xd
+	}
+	for i := 0; i < octets; i++ {
+		high := digits[2*i] - '0'
+		low := sign
+		if 2*i+1 < len(digits) {
+			low = digits[2*i+1] - '0'
+		}
+		m.WriteOctet(high<<4 | low)
+	}
+	return nil
+}
+
+// decimalValue converts a decimal string, with an optional d or D suffix, an
+// integer, a float or a big.Rat to a rational number
+func decimalValue(rv reflect.Value) (*big.Rat, bool) {
+	for rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	if r, ok := rv.Interface().(*big.Rat); ok {
+		return new(big.Rat).Set(r), r != nil
+	}
+	if r, ok := rv.Interface().(big.Rat); ok {
+		return new(big.Rat).Set(&r), true
+	}
+
+	r := new(big.Rat)
+	switch {
+	case rv.Kind() == reflect.String:
+		_, ok := r.SetString(strings.TrimRight(rv.String(), "dD"))
+		return r, ok
+	case rv.CanInt():
+		return r.SetInt64(rv.Int()), true
+	case rv.CanUint():
+		return r.SetUint64(rv.Uint()), true
+	case rv.CanFloat():
+		return r, r.SetFloat64(rv.Float()) != nil
+	}
+	return nil, false
+}
+
+// fixedUnit returns the value of the last digit of a fixed point type
+func fixedUnit(scale uint16) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+}
+
+// fixedUnits scales a decimal to an integer number of units of the last digit
+// of a fixed point type, truncating toward zero. It reports whether digits
+// were truncated, and fails if the value has too many integer digits.
+func fixedUnits(r *big.Rat, digits, scale uint16) (*big.Int, bool, bool) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(fixedUnit(scale)))
+	units := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	if new(big.Int).Abs(units).Cmp(limit) >= 0 {
+		return nil, false, false
+	}
+	return units, !scaled.IsInt(), true
+}
+
+// unmarshalFixed reads a fixed point decimal, returned as a string with the
+// scale of its type
+func unmarshalFixed(u *giop.CDRUnmarshaller, tc TypeCode) (interface{}, error) {
+	info, ok := tc.(fixedTypeInfo)
+	if !ok {
+		return nil, fmt.Errorf("%w: fixed type without digits", ErrInvalidTypeCode)
+	}
+
+	// Each octet holds two nibbles, the last of which is the sign
+	octets := int(info.FixedDigits())/2 + 1
+	nibbles := make([]byte, 0, 2*octets)
+	for i := 0; i < octets; i++ {
+		octet, err := u.ReadOctet()
+		if err != nil {
+			return nil, err
+		}
+		nibbles = append(nibbles, octet>>4, octet&0xf)
+	}
+
+	units, ten := new(big.Int), big.NewInt(10)
+	for _, digit := range nibbles[:len(nibbles)-1] {
+		if digit > 9 {
+			return nil, fmt.Errorf("invalid digit %#x of a fixed point decimal", digit)
+		}
+		units.Mul(units, ten).Add(units, big.NewInt(int64(digit)))
+	}
+	switch sign := nibbles[len(nibbles)-1]; sign {
+	case 0xc:
+	case 0xd:
+		units.Neg(units)
+	default:
+		return nil, fmt.Errorf("invalid sign %#x of a fixed point decimal", sign)
+	}
+	return new(big.Rat).SetFrac(units, fixedUnit(info.FixedScale())).FloatString(int(info.FixedScale())), nil
+}
+
+// unmarshalValue reads a value of the type described by tc. Structs and
+// exceptions are returned as a map of their members, sequences and arrays as
+// a slice, with octet sequences as []byte, enums as their ordinal, unions as
+// a UnionValue and fixed point decimals as a string.
+func unmarshalValue(u *giop.CDRUnmarshaller, tc TypeCode) (interface{}, error) {
+	kind, err := tcKindOf(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case TC_NULL, TC_VOID:
+		return nil, nil
+	case TC_BOOLEAN:
+		return u.ReadBool()
+	case TC_CHAR:
+		return u.ReadChar()
+	case TC_OCTET:
+		return u.ReadOctet()
+	case TC_WCHAR:
+		return u.ReadWChar()
+	case TC_SHORT:
+		return u.ReadShort()
+	case TC_USHORT:
+		return u.ReadUShort()
+	case TC_LONG:
+		return u.ReadLong()
+	case TC_ULONG, TC_ENUM:
+		return u.ReadULong()
+	case TC_LONGLONG:
+		return u.ReadLongLong()
+	case TC_ULONGLONG:
+		return u.ReadULongLong()
+	case TC_FLOAT:
+		return u.ReadFloat()
+	case TC_DOUBLE:
+		return u.ReadDouble()
+	case TC_STRING, TC_WSTRING:
+		return u.ReadString()
+	case TC_SEQUENCE:
+		return unmarshalSequence(u, tc)
+	case TC_ARRAY:
+		return unmarshalArray(u, tc)
+	case TC_STRUCT, TC_EXCEPT:
+		return unmarshalMembers(u, tc)
+	case TC_UNION:
+		return unmarshalUnion(u, tc)
+	case TC_ALIAS:
+		original, err := tc.(TypeCodeImpl).ContentType()
+		if err != nil {
+			return nil, err
+		}
+		return unmarshalValue(u, original)
+	case TC_TYPECODE:
+		return unmarshalTypeCode(u)
+	case TC_ANY:
+		return unmarshalAny(u)
+	case TC_FIXED:
+		return unmarshalFixed(u, tc)
+	}
+	return nil, fmt.Errorf("%w: cannot unmarshal %s values", ErrUnsupportedType, kind)
+}
+
+// unmarshalSequence reads the length and elements of a sequence
+func unmarshalSequence(u *giop.CDRUnmarshaller, tc TypeCode) (interface{}, error) {
+	elementType, err := tc.(TypeCodeImpl).ContentType()
+	if err != nil {
+		return nil, err
+	}
+	if kind, _ := tcKindOf(elementType); kind == TC_OCTET {
+		return u.ReadOctetSequence()
+	}
+
+	length, err := u.ReadULong()
+	if err != nil {
+		return nil, err
+	}
+	if int(length) > u.Len() {
+		// Every element takes at least one byte
+		return nil, fmt.Errorf("sequence length %d exceeds the remaining data", length)
+	}
+
+	elements := make([]interface{}, length)
+	for i := range elements {
+		if elements[i], err = unmarshalValue(u, elementType); err != nil {
+			return nil, err
+		}
+	}
+	return elements, nil
+}
+
+// unmarshalArray reads the elements of an array
+func unmarshalArray(u *giop.CDRUnmarshaller, tc TypeCode) (interface{}, error) {
+	impl := tc.(TypeCodeImpl)
+	elementType, err := impl.ContentType()
+	if err != nil {
+		return nil, err
+	}
+	length := impl.Length()
+	if length > u.Len() {
+		return nil, fmt.Errorf("array length %d exceeds the remaining data", length)
+	}
+
+	if kind, _ := tcKindOf(elementType); kind == TC_OCTET {
+		octets := make([]byte, length)
+		for i := range octets {
+			if octets[i], err = u.ReadOctet(); err != nil {
+				return nil, err
+			}
+		}
+		return octets, nil
+	}
+
+	elements := make([]interface{}, length)
+	for i := range elements {
+		if elements[i], err = unmarshalValue(u, elementType); err != nil {
+			return nil, err
+		}
+	}
+	return elements, nil
+}
+
+// unmarshalUnion reads the discriminator and active member of a union
+func unmarshalUnion(u *giop.CDRUnmarshaller, tc TypeCode) (interface{}, error) {
+	impl := tc.(TypeCodeImpl)
+	discriminatorType, err := impl.DiscriminatorType()
+	if err != nil {
+		return nil, err
+	}
+
+	var value UnionValue
+	if value.Discriminant, err = unmarshalValue(u, discriminatorType); err != nil {
+		return nil, err
+	}
+	index := unionMemberIndex(impl, value.Discriminant)
+	if index < 0 {
+		return value, nil
+	}
+	memberType, err := impl.MemberType(index)
+	if err != nil {
+		return nil, err
+	}
+	if value.Value, err = unmarshalValue(u, memberType); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// unmarshalAny reads the TypeCode and value of an Any
+func unmarshalAny(u *giop.CDRUnmarshaller) (*Any, error) {
+	tc, err := unmarshalTypeCode(u)
+	if err != nil {
+		return nil, err
+	}
+	value, err := unmarshalValue(u, tc)
+	if err != nil {
+		return nil, err
+	}
+	return &Any{typeCode: tc, value: value}, nil
+}
+
+// unmarshalMembers reads the members of a struct or exception into a map
+func unmarshalMembers(u *giop.CDRUnmarshaller, tc TypeCode) (map[string]interface{}, error) {
+	impl, ok := tc.(TypeCodeImpl)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no member information", ErrInvalidTypeCode, tc.Id())
+	}
+
+	members := make(map[string]interface{}, impl.MemberCount())
+	for i := 0; i < impl.MemberCount(); i++ {
+		name, err := impl.MemberName(i)
+		if err != nil {
+			return nil, err
+		}
+		memberType, err := impl.MemberType(i)
+		if err != nil {
+			return nil, err
+		}
+		if members[name], err = unmarshalValue(u, memberType); err != nil {
+			return nil, fmt.Errorf("member %s of %s: %w", name, tc.Name(), err)
+		}
+	}
+	return members, nil
+}
+
+// assignValue stores an unmarshalled value in a Go variable, converting
+// numbers, slices and struct members to the variable's type
+func assignValue(dst reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+
+	switch {
+	case rv.Type().AssignableTo(dst.Type()):
+		dst.Set(rv)
+	case dst.Kind() == reflect.Slice && rv.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(dst.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := assignValue(slice.Index(i), rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case dst.Kind() == reflect.Struct && rv.Kind() == reflect.Map:
+		for _, key := range rv.MapKeys() {
+			if field := structField(dst, key.String()); field.IsValid() {
+				if err := assignValue(field, rv.MapIndex(key).Interface()); err != nil {
+					return err
+				}
+			}
+		}
+	case dst.Kind() == reflect.Pointer && rv.Kind() == reflect.Map:
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		dst.Set(elem)
+	case rv.Type().ConvertibleTo(dst.Type()) && rv.Kind() != reflect.String && dst.Kind() != reflect.String:
+		dst.Set(rv.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("%w: cannot assign %T to %s", ErrTypeMismatch, value, dst.Type())
+	}
+	return nil
+}
+
+// tcKindOf returns the TCKind of a TypeCode
+func tcKindOf(tc TypeCode) (TCKind, error) {
+	if impl, ok := tc.(TypeCodeImpl); ok {
+		return impl.TCKind(), nil
+	}
+	if tc == nil {
+		return TC_NULL, ErrInvalidTypeCode
+	}
+	return TC_NULL, fmt.Errorf("%w: %s", ErrInvalidTypeCode, tc.Id())
+}
+
+// intValue returns an integer, or zero for a nil value
+func intValue(rv reflect.Value) (int64, error) {
+	switch {
+	case !rv.IsValid():
+		return 0, nil
+	case rv.CanInt():
+		return rv.Int(), nil
+	case rv.CanUint():
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("%w: %s is not an integer", ErrTypeMismatch, rv.Type())
+}
+
+// floatValue returns a floating point number, or zero for a nil value
+func floatValue(rv reflect.Value) (float64, error) {
+	switch {
+	case !rv.IsValid():
+		return 0, nil
+	case rv.CanFloat():
+		return rv.Float(), nil
+	case rv.CanInt():
+		return float64(rv.Int()), nil
+	}
+	return 0, fmt.Errorf("%w: %s is not a number", ErrTypeMismatch, rv.Type())
+}
@@ -2,11 +2,11 @@ package corba
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"io"
-	"net"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ifabos/go-corba/giop"
 )
@@ -14,32 +14,85 @@ import (
 // Client represents a CORBA client
 type Client struct {
 	orb              *ORB
-	connections      map[string]net.Conn
+	transport        Transport // Transport for host/port connections; nil means plain TCP
+	connections      map[string]*clientConn
 	requestIDCounter uint32
+	requestTimeout   time.Duration // How long to wait for a reply; zero waits forever
 	mu               sync.RWMutex
 }
 
+// SetRequestTimeout sets how long the client waits for the reply to a
+// request. Requests that time out fail with TIMEOUT. Zero, the default,
+// waits forever.
+func (c *Client) SetRequestTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requestTimeout = d
+}
+
+// endpointFor returns the endpoint of a host and port for the client's default transport
+func (c *Client) endpointFor(host string, port int) Endpoint {
+	if c.transport != nil {
+		return Endpoint{Protocol: c.transport.Protocol(), Host: host, Port: port}
+	}
+	return NewTCPEndpoint(host, port)
+}
+
+// transportFor returns the transport used to connect to an endpoint
+func (c *Client) transportFor(ep Endpoint) (Transport, error) {
+	if c.transport != nil && c.transport.Protocol() == ep.Protocol {
+		return c.transport, nil
+	}
+	if c.orb != nil {
+		return c.orb.GetTransport(ep.Protocol)
+	}
+	return profileTransport(ep.Protocol), nil
+}
+
 // Connect establishes a connection to a CORBA server
 func (c *Client) Connect(host string, port int) error {
-	address := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-	conn, err := net.Dial("tcp", address)
+	return c.ConnectEndpoint(c.endpointFor(host, port))
+}
+
+// ConnectEndpoint establishes a connection to a CORBA server at an endpoint of
+// any transport. An open connection to the endpoint is kept; all requests to
+// the endpoint share it.
+func (c *Client) ConnectEndpoint(ep Endpoint) error {
+	_, err := c.getConnection(ep)
+	return err
+}
+
+// dial opens a new connection to an endpoint and starts reading its replies
+func (c *Client) dial(ep Endpoint) (*clientConn, error) {
+	transport, err := c.transportFor(ep)
 	if err != nil {
-		return fmt.Errorf("failed to connect to CORBA server at %s: %w", address, err)
+		return nil, err
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.connections == nil {
-		c.connections = make(map[string]net.Conn)
+	netConn, err := transport.Connect(ep)
+	if err != nil {
+		// Nothing was sent, so the request may safely be retried elsewhere
+		return nil, TRANSIENT(MinorConnectFailed, CompletionStatusNo)
 	}
-	c.connections[address] = conn
-	return nil
+
+	conn := newClientConn(netConn)
+	go func() {
+		conn.readReplies()
+		c.forgetConnection(ep, conn)
+	}()
+	return conn, nil
 }
 
 // Disconnect closes a connection to a CORBA server
 func (c *Client) Disconnect(host string, port int) error {
-	address := fmt.Sprintf("%s:%d", host, port)
+	return c.DisconnectEndpoint(c.endpointFor(host, port))
+}
+
+// DisconnectEndpoint closes a connection to a CORBA server at an endpoint.
+// Requests still waiting for a reply on the connection fail with COMM_FAILURE.
+func (c *Client) DisconnectEndpoint(ep Endpoint) error {
+	address := ep.String()
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -57,7 +110,7 @@ func (c *Client) Disconnect(host string, port int) error {
 
 	data, err := giop.MarshalGIOPMessage(closeMsg)
 	if err == nil {
-		conn.Write(data) // Best effort, ignore errors
+		conn.writeMessage(data, closeConnectionTimeout) // Best effort, ignore errors
 	}
 
 	if err := conn.Close(); err != nil {
@@ -68,6 +121,113 @@ func (c *Client) Disconnect(host string, port int) error {
 	return nil
 }
 
+// getConnection returns the connection to an endpoint, connecting if needed
+func (c *Client) getConnection(ep Endpoint) (*clientConn, error) {
+	address := ep.String()
+
+	c.mu.RLock()
+	conn, exists := c.connections[address]
+	c.mu.RUnlock()
+	if exists {
+		return conn, nil
+	}
+
+	conn, err := c.dial(ep)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another request may have connected meanwhile
+	if existing, exists := c.connections[address]; exists {
+		conn.Close()
+		return existing, nil
+	}
+	if c.connections == nil {
+		c.connections = make(map[string]*clientConn)
+	}
+	c.connections[address] = conn
+	return conn, nil
+}
+
+// exchange sends a marshalled request to an endpoint and waits for the message
+// that answers it, matched by request ID. A connection that fails is closed
+// and forgotten, so that the next request reconnects.
+func (c *Client) exchange(ep Endpoint, requestID uint32, data []byte) (*giop.Message, error) {
+	// Get the connection or create one if it doesn't exist
+	conn, err := c.getConnection(ep)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, ex := conn.await(requestID)
+	if ex != nil {
+		return nil, ex
+	}
+
+	c.mu.RLock()
+	timeout := c.requestTimeout
+	c.mu.RUnlock()
+
+	// Send the request
+	if err := conn.writeMessage(data, timeout); err != nil {
+		conn.forget(requestID)
+		c.dropConnection(ep, conn)
+		return nil, ioException(err, COMM_FAILURE(MinorSendFailed, CompletionStatusNo))
+	}
+
+	// Wait for the reply
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case result := <-reply:
+		return result.msg, result.err
+	case <-expired:
+		conn.forget(requestID)
+		return nil, TIMEOUT(MinorRequestTimeout, CompletionStatusMaybe)
+	}
+}
+
+// send sends a marshalled message that expects no reply to an endpoint
+func (c *Client) send(ep Endpoint, data []byte) error {
+	conn, err := c.getConnection(ep)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	timeout := c.requestTimeout
+	c.mu.RUnlock()
+
+	if err := conn.writeMessage(data, timeout); err != nil {
+		c.dropConnection(ep, conn)
+		return ioException(err, COMM_FAILURE(MinorSendFailed, CompletionStatusNo))
+	}
+	return nil
+}
+
+// dropConnection closes a failed connection and removes it from the client
+func (c *Client) dropConnection(ep Endpoint, conn *clientConn) {
+	conn.Close()
+	c.forgetConnection(ep, conn)
+}
+
+// forgetConnection removes a closed connection from the client
+func (c *Client) forgetConnection(ep Endpoint, conn *clientConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connections[ep.String()] == conn {
+		delete(c.connections, ep.String())
+	}
+}
+
 // NextRequestID generates a new unique request ID
 func (c *Client) NextRequestID() uint32 {
 	return atomic.AddUint32(&c.requestIDCounter, 1)
@@ -75,46 +235,107 @@ func (c *Client) NextRequestID() uint32 {
 
 // InvokeMethod invokes a method on a remote object using GIOP/IIOP
 func (c *Client) InvokeMethod(objectName string, methodName string, serverHost string, serverPort int, args ...interface{}) (interface{}, error) {
-	// Get the connection or create one if it doesn't exist
-	address := fmt.Sprintf("%s:%d", serverHost, serverPort)
+	return c.invoke(c.endpointFor(serverHost, serverPort), ObjectKeyFromString(objectName), methodName, args...)
+}
 
-	c.mu.RLock()
-	conn, exists := c.connections[address]
-	c.mu.RUnlock()
+// invocation is an operation to invoke on an object
+type invocation struct {
+	operation string
+	args      []interface{} // Arguments, as seen by interceptors
+
+	// body is the CDR-encoded request body, and decode, if set, decodes the
+	// result from the reply body in the byte order of the reply. Operations
+	// invoked with plain Go arguments have neither.
+	body   []byte
+	decode func(body []byte, order binary.ByteOrder) (interface{}, error)
+
+	// raises, if set, lists the user exceptions the operation may raise.
+	// They are decoded with their TypeCodes, and any other user exception is
+	// reported as UNKNOWN.
+	raises ExceptionList
+
+	// oneway operations are sent without waiting for a reply
+	oneway bool
+
+	// context, if set, holds the alternating names and values of the
+	// Context properties matched by the operation's context clause. They are
+	// sent after the arguments.
+	context []string
+
+	// target is the reference invoked, if any, and current the PICurrent
+	// whose slots the client interceptors see
+	target  *ObjectRef
+	current *PICurrent
+}
 
-	if !exists {
-		if err := c.Connect(serverHost, serverPort); err != nil {
-			return nil, err
+// invoke sends a request for an object key to an endpoint and waits for the reply
+func (c *Client) invoke(ep Endpoint, objectKey []byte, methodName string, args ...interface{}) (interface{}, error) {
+	inv := invocation{operation: methodName, args: args}
+	result, _, err := c.invokeAt(objectLocation{endpoint: ep, objectKey: objectKey}, inv)
+	return result, err
+}
+
+// invokeAt sends a request to an object location, following LOCATION_FORWARD
+// replies and the ForwardRequests raised by client interceptors. It returns
+// the location that finally answered the request.
+func (c *Client) invokeAt(loc objectLocation, inv invocation) (interface{}, objectLocation, error) {
+	for hops := 0; ; hops++ {
+		result, forward, err := c.invokeOnce(loc, inv)
+		var redirect *ForwardRequest
+		if errors.As(err, &redirect) && !redirect.Forward.IsNil() {
+			forward = &objectLocation{endpoint: redirect.Forward.Endpoint(), objectKey: redirect.Forward.key()}
+			err = nil
 		}
-		c.mu.RLock()
-		conn = c.connections[address]
-		c.mu.RUnlock()
+		if forward == nil {
+			return result, loc, err
+		}
+		if hops == maxLocationForwards {
+			return nil, loc, TRANSIENT(MinorForwardLoop, CompletionStatusNo)
+		}
+		loc = *forward
 	}
+}
+
+// invokeOnce sends a request to an object location and waits for the reply. A
+// LOCATION_FORWARD reply is returned as the location to retry at. Oneway
+// requests return as soon as they are sent.
+func (c *Client) invokeOnce(loc objectLocation, inv invocation) (interface{}, *objectLocation, error) {
+	objectKey := loc.objectKey
+	methodName, args := inv.operation, inv.args
 
 	// Generate a unique request ID
 	requestID := c.NextRequestID()
 
-	// Create object key from the object name
-	objectKey := []byte(objectName)
-
 	// Create a GIOP request message
-	requestMsg := giop.NewRequestMessage(requestID, objectKey, methodName, true)
+	requestMsg := giop.NewRequestMessage(requestID, objectKey, methodName, !inv.oneway)
 
 	// Create request info for interceptors
 	reqInfo := &RequestInfo{
 		Operation:        methodName,
-		ObjectKey:        objectName,
+		ObjectKey:        ObjectKeyToString(objectKey),
 		Arguments:        args,
 		RequestID:        requestID,
-		ResponseExpected: true,
+		ResponseExpected: !inv.oneway,
 		ServiceContexts:  []ServiceContext{},
+		Target:           inv.target,
+		EffectiveTarget:  c.referenceAt(loc),
+		EffectiveProfile: profileTransport(loc.endpoint.Protocol).CreateProfile(loc.endpoint, objectKey),
+		SyncScope:        SyncWithTarget,
+		slots:            inv.current.copySlots(c.orb.slotCount),
+	}
+	if inv.oneway {
+		// Oneway invocations return once the request is written
+		reqInfo.SyncScope = SyncWithTransport
+	}
+	if reqInfo.Target == nil {
+		reqInfo.Target = reqInfo.EffectiveTarget
 	}
 
 	// Call client request interceptors - SendRequest
 	interceptors := c.orb.GetInterceptorRegistry().GetClientRequestInterceptors()
 	for _, interceptor := range interceptors {
 		if err := interceptor.SendRequest(reqInfo); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -122,7 +343,7 @@ func (c *Client) InvokeMethod(objectName string, methodName string, serverHost s
 	for _, ctx := range reqInfo.ServiceContexts {
 		requestHeader, ok := requestMsg.Body.(*giop.RequestHeader)
 		if !ok {
-			return nil, fmt.Errorf("invalid request message format")
+			return nil, nil, fmt.Errorf("invalid request message format")
 		}
 		requestHeader.ServiceContexts = append(
 			requestHeader.ServiceContexts,
@@ -134,7 +355,15 @@ func (c *Client) InvokeMethod(objectName string, methodName string, serverHost s
 	}
 
 	// Marshal the arguments using CDR
-	if len(args) > 0 {
+	if inv.context != nil {
+		// The IDL context follows the arguments
+		m := giop.NewCDRMarshaller(binary.BigEndian)
+		m.WriteRaw(inv.body)
+		marshalContextValues(m, inv.context)
+		requestMsg.Body.(*giop.RequestHeader).Body = m.Bytes()
+	} else if inv.body != nil {
+		requestMsg.Body.(*giop.RequestHeader).Body = inv.body
+	} else if len(args) > 0 {
 		// For now, simply store the arguments as a placeholder
 		// In a real implementation, we'd use CDR to marshal them properly based on IDL definitions
 		// This would be done after the request header
@@ -143,53 +372,42 @@ func (c *Client) InvokeMethod(objectName string, methodName string, serverHost s
 	// Marshal the complete message
 	data, err := giop.MarshalGIOPMessage(requestMsg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Send the request
-	if _, err := conn.Write(data); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	// Receive the reply
-	headerBuf := make([]byte, 12) // Size of the GIOP header
-	if _, err := io.ReadFull(conn, headerBuf); err != nil {
-		return nil, fmt.Errorf("failed to read response header: %w", err)
-	}
-
-	// Unmarshal the header
-	unmarshaller := giop.NewCDRUnmarshaller(headerBuf, binary.BigEndian)
-	header, err := unmarshaller.ReadMessageHeader()
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response header: %w", err)
-	}
+	if inv.oneway {
+		if err := c.send(loc.endpoint, data); err != nil {
+			return nil, nil, err
+		}
 
-	// Read the message body
-	bodyBuf := make([]byte, header.MsgSize)
-	if _, err := io.ReadFull(conn, bodyBuf); err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		// Call client request interceptors - ReceiveOther, as no reply will come
+		for _, interceptor := range interceptors {
+			if err := interceptor.ReceiveOther(reqInfo); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, nil
 	}
 
-	// Unmarshal the entire message
-	data = append(headerBuf, bodyBuf...)
-	msg, err := giop.UnmarshalGIOPMessage(data)
+	// Send the request and receive the reply
+	msg, err := c.exchange(loc.endpoint, requestID, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, nil, err
 	}
 
 	// Process the response
 	if msg.Header.MsgType != giop.MsgReply {
-		return nil, fmt.Errorf("expected reply message, got message type %d", msg.Header.MsgType)
+		return nil, nil, fmt.Errorf("expected reply message, got message type %d", msg.Header.MsgType)
 	}
 
 	replyHeader, ok := msg.Body.(*giop.ReplyHeader)
 	if !ok {
-		return nil, fmt.Errorf("invalid reply message format")
+		return nil, nil, fmt.Errorf("invalid reply message format")
 	}
 
 	// Verify the request ID matches
 	if replyHeader.RequestID != requestID {
-		return nil, fmt.Errorf("mismatched request ID: expected %d, got %d", requestID, replyHeader.RequestID)
+		return nil, nil, fmt.Errorf("mismatched request ID: expected %d, got %d", requestID, replyHeader.RequestID)
 	}
 
 	// Convert service contexts to our format for interceptors
@@ -207,78 +425,96 @@ func (c *Client) InvokeMethod(objectName string, methodName string, serverHost s
 	if replyHeader.ReplyStatus != giop.ReplyStatusNoException {
 		switch replyHeader.ReplyStatus {
 		case giop.ReplyStatusUserException, giop.ReplyStatusSystemException:
-			exception, err = c.handleExceptionReply(replyHeader)
+			exception, err = c.handleExceptionReply(replyHeader, inv.raises)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
+			reqInfo.ReplyStatus = replyStatusOf(exception)
+			reqInfo.Exception = exception
 
 			// Call client request interceptors - ReceiveException
 			for _, interceptor := range interceptors {
 				if err := interceptor.ReceiveException(reqInfo, exception); err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
 
-			return nil, exception
+			return nil, nil, exception
+
+		case giop.ReplyStatusLocationForward, giop.ReplyStatusLocationForwardPerm:
+			forward, err := c.forwardLocation(replyHeader.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+			reqInfo.ReplyStatus = ReplyLocationForward
+			reqInfo.ForwardReference = c.referenceAt(*forward)
 
-		case giop.ReplyStatusLocationForward:
 			// Call client request interceptors - ReceiveOther
 			for _, interceptor := range interceptors {
 				if err := interceptor.ReceiveOther(reqInfo); err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
-
-			return nil, fmt.Errorf("location forward")
+			return nil, forward, nil
 
 		default:
 			// Call client request interceptors - ReceiveOther for unknown status
 			for _, interceptor := range interceptors {
 				if err := interceptor.ReceiveOther(reqInfo); err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
 
-			return nil, fmt.Errorf("unknown reply status: %d", replyHeader.ReplyStatus)
+			return nil, nil, fmt.Errorf("unknown reply status: %d", replyHeader.ReplyStatus)
 		}
 	}
 
-	// In a real implementation, we'd unmarshal the return value from the reply body
-	// For the placeholder, we'll set the result in the request info
+	// Operations with an encoded body decode their result from the reply body.
+	// For the others we'll set the placeholder in the request info
+	if inv.decode != nil {
+		if result, err = inv.decode(replyHeader.Body, bodyByteOrder(replyHeader.ByteOrder)); err != nil {
+			return nil, nil, MARSHAL(MinorMalformedReply, CompletionStatusMaybe)
+		}
+	}
 	reqInfo.Result = result
 
 	// Call client request interceptors - ReceiveReply
 	for _, interceptor := range interceptors {
 		if err := interceptor.ReceiveReply(reqInfo); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	// Return potentially modified result from interceptors
-	return reqInfo.Result, nil
+	return reqInfo.Result, nil, nil
 }
 
-// handleExceptionReply processes a GIOP exception reply
-func (c *Client) handleExceptionReply(reply *giop.ReplyHeader) (Exception, error) {
-	// Look for the exception service context
-	var exceptionData []byte
-	for _, ctx := range reply.ServiceContexts {
-		if ctx.ID == 0x45584350 { // "EXCP"
-			exceptionData = ctx.Data
-			break
-		}
+// handleExceptionReply decodes the exception carried in the body of a GIOP
+// exception reply. User exceptions are decoded with their TypeCode in raises,
+// or with the TypeCode registered for their repository ID if raises is nil.
+func (c *Client) handleExceptionReply(reply *giop.ReplyHeader, raises ExceptionList) (Exception, error) {
+	if len(reply.Body) == 0 {
+		// No exception data found, create a generic system exception
+		return UNKNOWN(MinorEmptyExceptionReply, CompletionStatusMaybe), nil
 	}
 
-	if len(exceptionData) == 0 {
-		// No exception data found, create a generic system exception
-		return UNKNOWN(0, CompletionStatusNo), nil
+	order := bodyByteOrder(reply.ByteOrder)
+	var tc TypeCode
+	if raises != nil && reply.ReplyStatus == giop.ReplyStatusUserException {
+		id, err := giop.NewCDRUnmarshaller(reply.Body, order).ReadString()
+		if err != nil {
+			return MARSHAL(MinorMalformedReply, CompletionStatusMaybe), fmt.Errorf("failed to unmarshal exception: %w", err)
+		}
+		listed, ok := raises.lookup(id)
+		if !ok {
+			return UNKNOWN(MinorUnlistedUserException, CompletionStatusYes), nil
+		}
+		tc = listed
 	}
 
-	// In a real implementation, we would also have the TypeCode information
-	// For now, we'll try to parse the simple string format used in MarshalException
-	ex, err := UnmarshalException(exceptionData, nil)
+	ex, err := unmarshalException(reply.Body, order, tc)
 	if err != nil {
-		return MARSHAL(1, CompletionStatusNo), fmt.Errorf("failed to unmarshal exception: %w", err)
+		return MARSHAL(MinorMalformedReply, CompletionStatusMaybe), fmt.Errorf("failed to unmarshal exception: %w", err)
 	}
 
 	return ex, nil
@@ -286,25 +522,36 @@ func (c *Client) handleExceptionReply(reply *giop.ReplyHeader) (Exception, error
 
 // GetObject retrieves a reference to a remote object
 func (c *Client) GetObject(name string, serverHost string, serverPort int) (*ObjectRef, error) {
-	// Connect to server if not already connected
-	address := fmt.Sprintf("%s:%d", serverHost, serverPort)
+	return c.GetObjectAt(name, c.endpointFor(serverHost, serverPort))
+}
 
-	c.mu.RLock()
-	_, exists := c.connections[address]
-	c.mu.RUnlock()
+// referenceAt returns a reference to the object at a location, without
+// connecting to it
+func (c *Client) referenceAt(loc objectLocation) *ObjectRef {
+	return &ObjectRef{
+		Name:       ObjectKeyToString(loc.objectKey),
+		ServerHost: loc.endpoint.Host,
+		ServerPort: loc.endpoint.Port,
+		client:     c,
+		objectKey:  loc.objectKey,
+		endpoint:   loc.endpoint,
+	}
+}
 
-	if !exists {
-		if err := c.Connect(serverHost, serverPort); err != nil {
-			return nil, err
-		}
+// GetObjectAt retrieves a reference to a remote object reachable at an endpoint
+func (c *Client) GetObjectAt(name string, ep Endpoint) (*ObjectRef, error) {
+	// Connect to server if not already connected
+	if _, err := c.getConnection(ep); err != nil {
+		return nil, err
 	}
 
 	// Create an object reference
 	ref := &ObjectRef{
 		Name:       name,
-		ServerHost: serverHost,
-		ServerPort: serverPort,
+		ServerHost: ep.Host,
+		ServerPort: ep.Port,
 		client:     c,
+		endpoint:   ep,
 	}
 
 	return ref, nil
@@ -0,0 +1,173 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ifabos/go-corba/giop"
+)
+
+// clientConn is a connection to a server shared by all requests a client sends
+// to its endpoint. Messages are written whole, and a reader goroutine hands
+// each reply to the request waiting for it by request ID, so that any number
+// of requests can be outstanding on the connection at once.
+type clientConn struct {
+	net.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint32]chan exchangeResult // Requests waiting for their reply
+	failure Exception                      // Why the connection failed, once it has
+}
+
+// exchangeResult is the reply to a request, or why none will arrive
+type exchangeResult struct {
+	msg *giop.Message
+	err error
+}
+
+// newClientConn wraps a connection to a server
+func newClientConn(conn net.Conn) *clientConn {
+	return &clientConn{
+		Conn:    conn,
+		pending: make(map[uint32]chan exchangeResult),
+	}
+}
+
+// await registers a request whose reply is delivered on the returned channel.
+// It fails with the exception that ended the connection if it has failed.
+func (c *clientConn) await(requestID uint32) (<-chan exchangeResult, Exception) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failure != nil {
+		return nil, c.failure
+	}
+	reply := make(chan exchangeResult, 1)
+	c.pending[requestID] = reply
+	return reply, nil
+}
+
+// forget stops waiting for the reply to a request. A reply that arrives later
+// is discarded.
+func (c *clientConn) forget(requestID uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, requestID)
+}
+
+// deliver hands a reply to the request waiting for it
+func (c *clientConn) deliver(requestID uint32, msg *giop.Message) {
+	c.mu.Lock()
+	reply, ok := c.pending[requestID]
+	delete(c.pending, requestID)
+	c.mu.Unlock()
+
+	if ok {
+		reply <- exchangeResult{msg: msg}
+	}
+}
+
+// fail closes the connection and fails every request waiting on it with ex
+func (c *clientConn) fail(ex Exception) {
+	c.mu.Lock()
+	if c.failure == nil {
+		c.failure = ex
+	}
+	pending := c.pending
+	c.pending = make(map[uint32]chan exchangeResult)
+	c.mu.Unlock()
+
+	c.Close()
+	for _, reply := range pending {
+		reply <- exchangeResult{err: ex}
+	}
+}
+
+// writeMessage writes a complete GIOP message to the connection, giving up
+// once the timeout expires if it is not zero
+func (c *clientConn) writeMessage(data []byte, timeout time.Duration) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if timeout > 0 {
+		c.SetWriteDeadline(time.Now().Add(timeout))
+		defer c.SetWriteDeadline(time.Time{})
+	}
+	_, err := c.Write(data)
+	return err
+}
+
+// readReplies delivers the replies read from the connection until it fails
+// or the server closes it
+func (c *clientConn) readReplies() {
+	for {
+		headerBuf := make([]byte, 12) // Size of the GIOP header
+		if _, err := io.ReadFull(c, headerBuf); err != nil {
+			c.fail(COMM_FAILURE(MinorReceiveFailed, CompletionStatusMaybe))
+			return
+		}
+
+		// Unmarshal the header
+		unmarshaller := giop.NewCDRUnmarshaller(headerBuf, binary.BigEndian)
+		header, err := unmarshaller.ReadMessageHeader()
+		if err != nil {
+			c.fail(MARSHAL(MinorMalformedReply, CompletionStatusMaybe))
+			return
+		}
+
+		// Read the message body
+		bodyBuf := make([]byte, header.MsgSize)
+		if _, err := io.ReadFull(c, bodyBuf); err != nil {
+			c.fail(COMM_FAILURE(MinorReceiveFailed, CompletionStatusMaybe))
+			return
+		}
+
+		switch {
+		case header.MsgType == giop.MsgMessageError:
+			// The server could not interpret a message sent on the connection
+			c.fail(COMM_FAILURE(MinorMessageError, CompletionStatusMaybe))
+			return
+		case header.MsgType == giop.MsgFragment || header.HasMoreFragments():
+			// Fragmented replies cannot be reassembled, so the stream is lost
+			c.fail(MARSHAL(MinorFragmentedMessage, CompletionStatusMaybe))
+			return
+		}
+
+		// Unmarshal the entire message. A reply that cannot be decoded
+		// cannot be matched to its request either.
+		msg, err := giop.UnmarshalGIOPMessage(append(headerBuf, bodyBuf...))
+		if err != nil {
+			c.fail(MARSHAL(MinorMalformedReply, CompletionStatusMaybe))
+			return
+		}
+
+		switch body := msg.Body.(type) {
+		case *giop.ReplyHeader:
+			c.deliver(body.RequestID, msg)
+		case *giop.LocateReplyHeader:
+			c.deliver(body.RequestID, msg)
+		default:
+			if msg.Header.MsgType == giop.MsgCloseConn {
+				// The server is going away; the outstanding requests were not processed
+				c.fail(TRANSIENT(MinorConnectionClosed, CompletionStatusNo))
+				return
+			}
+		}
+	}
+}
+
+// ioException returns the exception for a failure to exchange messages over a
+// connection: TIMEOUT if the request timeout expired, and ex otherwise
+func ioException(err error, ex Exception) Exception {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return TIMEOUT(MinorRequestTimeout, CompletionStatusMaybe)
+	}
+	return ex
+}
@@ -1,8 +1,14 @@
 package corba
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+
+	"github.com/ifabos/go-corba/giop"
 )
 
 // Context represents a CORBA context that contains a collection of properties
@@ -80,25 +86,230 @@ func (c *Context) GetAll() map[string]interface{} {
 	return result
 }
 
+// GetValues returns the properties of the context and its parents whose names
+// match a pattern. A pattern ending in '*' matches every property whose name
+// starts with the rest of the pattern; any other pattern matches the property
+// of that name.
+func (c *Context) GetValues(pattern string) map[string]interface{} {
+	values := make(map[string]interface{})
+	prefix, wildcard := strings.CutSuffix(pattern, "*")
+	for name, value := range c.GetAll() {
+		if name == pattern || (wildcard && strings.HasPrefix(name, prefix)) {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// clauseValues returns the properties matched by the patterns of an IDL
+// context clause, as the alternating names and values sent with a request.
+// Values that are not strings are sent in their default format.
+func (c *Context) clauseValues(clause []string) []string {
+	matched := make(map[string]interface{})
+	for _, pattern := range clause {
+		for name, value := range c.GetValues(pattern) {
+			matched[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(matched))
+	for name := range matched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		values = append(values, name, fmt.Sprint(matched[name]))
+	}
+	return values
+}
+
+// marshalContextValues writes the properties sent for an IDL context clause
+// as a sequence of strings alternating names and values
+func marshalContextValues(m *giop.CDRMarshaller, values []string) {
+	m.WriteULong(uint32(len(values)))
+	for _, value := range values {
+		m.WriteString(value)
+	}
+}
+
+// unmarshalContextValues reads the properties sent for an IDL context clause
+// into a new Context
+func unmarshalContextValues(u *giop.CDRUnmarshaller) (*Context, error) {
+	count, err := u.ReadULong()
+	if err != nil {
+		return nil, err
+	}
+	if count%2 != 0 || int(count) > u.Len() {
+		return nil, fmt.Errorf("invalid context of %d strings", count)
+	}
+
+	ctx := NewContext()
+	for i := uint32(0); i < count; i += 2 {
+		name, err := u.ReadString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := u.ReadString()
+		if err != nil {
+			return nil, err
+		}
+		ctx.Set(name, value)
+	}
+	return ctx, nil
+}
+
+// ContextClauseProvider is implemented by servants whose operations declare
+// IDL context clauses. Requests for such operations carry the matching
+// properties of the caller's Context after their arguments; the server makes
+// them available to the servant through IDLContextFromContext.
+type ContextClauseProvider interface {
+	ContextClause(operation string) []string
+}
+
+// ParameterTypesProvider is implemented by static servants that describe the
+// in and inout parameters of their operations. The server decodes the
+// arguments of their requests with these TypeCodes; the requests of other
+// static servants carry no arguments in their body.
+type ParameterTypesProvider interface {
+	ParameterTypes(operation string) []TypeCode
+}
+
+// unmarshalRequestBody decodes the arguments of a request for a static
+// servant, and the IDL context that follows them if the operation has a
+// context clause
+func unmarshalRequestBody(servant interface{}, operation string, u *giop.CDRUnmarshaller) ([]interface{}, *Context, error) {
+	var args []interface{}
+	if provider, ok := servant.(ParameterTypesProvider); ok {
+		for _, tc := range provider.ParameterTypes(operation) {
+			value, err := unmarshalValue(u, tc)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, value)
+		}
+	}
+
+	provider, ok := servant.(ContextClauseProvider)
+	if !ok || len(provider.ContextClause(operation)) == 0 {
+		return args, nil, nil
+	}
+	idlCtx, err := unmarshalContextValues(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return args, idlCtx, nil
+}
+
+// idlContextKey is the context key of the IDL Context sent with a request
+type idlContextKey struct{}
+
+// withIDLContext returns a context carrying the IDL Context sent with a request
+func withIDLContext(ctx context.Context, idlCtx *Context) context.Context {
+	return context.WithValue(ctx, idlContextKey{}, idlCtx)
+}
+
+// IDLContextFromContext returns the IDL Context properties sent with the
+// request a context was created for, if its operation has a context clause
+func IDLContextFromContext(ctx context.Context) (*Context, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	idlCtx, ok := ctx.Value(idlContextKey{}).(*Context)
+	return idlCtx, ok
+}
+
 // ObjectRef represents a reference to a CORBA object
 type ObjectRef struct {
 	Name       string
 	ServerHost string
 	ServerPort int
 	client     *Client
-	ior        *IOR   // Added IOR reference
-	objectKey  []byte // Added object key for proper identification
-	typeID     string // Added type ID (repository ID)
+	ior        *IOR       // Added IOR reference
+	objectKey  []byte     // Added object key for proper identification
+	typeID     string     // Added type ID (repository ID)
+	endpoint   Endpoint   // Transport endpoint selected from the IOR, if any
+	bindMu     sync.Mutex // Serializes binding the reference to published endpoints
+
+	forward atomic.Pointer[objectLocation] // Location requests were last forwarded to
 }
 
 // Invoke calls a method on the referenced object using GIOP/IIOP
 func (ref *ObjectRef) Invoke(methodName string, args ...interface{}) (interface{}, error) {
 	if ref == nil || ref.client == nil {
-		return nil, NewCORBASystemException("OBJECT_NOT_EXIST", 0, CompletionStatusNo)
+		return nil, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	return ref.invoke(invocation{operation: methodName, args: args})
+}
+
+// InvokeContext calls a method declared with an IDL context clause on the
+// referenced object. The properties of ctx matched by the clause are sent
+// with the request.
+func (ref *ObjectRef) InvokeContext(ctx *Context, clause []string, methodName string, args ...interface{}) (interface{}, error) {
+	if ref == nil || ref.client == nil {
+		return nil, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	if ctx == nil {
+		ctx = NewContext()
+	}
+	return ref.invoke(invocation{operation: methodName, args: args, context: ctx.clauseValues(clause)})
+}
+
+// InvokeWithContext calls a method on the referenced object. The slots of the
+// PICurrent carried by ctx, if any, are passed to the client interceptors.
+func (ref *ObjectRef) InvokeWithContext(ctx context.Context, methodName string, args ...interface{}) (interface{}, error) {
+	if ref == nil || ref.client == nil {
+		return nil, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	current, _ := PICurrentFromContext(ctx)
+	return ref.invoke(invocation{operation: methodName, args: args, current: current})
+}
+
+// invoke sends an invocation to the referenced object
+func (ref *ObjectRef) invoke(inv invocation) (interface{}, error) {
+	inv.target = ref
+
+	// References created before any server was running are bound to the
+	// endpoints published since
+	if !ref.bindProfiles() {
+		return nil, TRANSIENT(MinorNoUsableProfile, CompletionStatusNo)
+	}
+
+	// References resolved from an IOR carry an endpoint for their transport
+	if !ref.endpoint.IsZero() {
+		return ref.invokeForwarded(inv)
 	}
 
 	// Use the client to invoke the method with GIOP/IIOP
-	return ref.client.InvokeMethod(ref.Name, methodName, ref.ServerHost, ref.ServerPort, args...)
+	loc := objectLocation{endpoint: ref.client.endpointFor(ref.ServerHost, ref.ServerPort), objectKey: ref.key()}
+	result, _, err := ref.client.invokeAt(loc, inv)
+	return result, err
+}
+
+// key returns the object key used on the wire
+func (ref *ObjectRef) key() []byte {
+	if len(ref.objectKey) > 0 {
+		return ref.objectKey
+	}
+	return ObjectKeyFromString(ref.Name)
+}
+
+// GetObjectKey returns the object key the reference addresses on the wire
+func (ref *ObjectRef) GetObjectKey() []byte {
+	return ref.key()
+}
+
+// Endpoint returns the transport endpoint of the reference. References created
+// from a host and port report a TCP endpoint.
+func (ref *ObjectRef) Endpoint() Endpoint {
+	if !ref.endpoint.IsZero() {
+		return ref.endpoint
+	}
+	return NewTCPEndpoint(ref.ServerHost, ref.ServerPort)
 }
 
 // IsNil checks if this is a nil object reference
@@ -153,19 +364,26 @@ func (ref *ObjectRef) SetIOR(ior *IOR) error {
 		return fmt.Errorf("cannot set nil IOR")
 	}
 
-	ref.ior = ior
-	ref.typeID = ior.TypeID
-
-	// Extract information from the primary IIOP profile
-	profile, err := ior.GetPrimaryIIOPProfile()
+	// Extract information from the first profile we can reach
+	var endpoint Endpoint
+	var objectKey []byte
+	var err error
+	if ref.client != nil && ref.client.orb != nil {
+		endpoint, objectKey, err = ref.client.orb.EndpointFromIOR(ior)
+	} else {
+		endpoint, objectKey, err = selectProfile(ior, defaultProfileTransports())
+	}
 	if err != nil {
 		return err
 	}
 
-	ref.ServerHost = profile.Host
-	ref.ServerPort = int(profile.Port)
-	ref.objectKey = profile.ObjectKey
-	ref.Name = ObjectKeyToString(profile.ObjectKey)
+	ref.ior = ior
+	ref.typeID = ior.TypeID
+	ref.endpoint = endpoint
+	ref.ServerHost = endpoint.Host
+	ref.ServerPort = endpoint.Port
+	ref.objectKey = objectKey
+	ref.Name = ObjectKeyToString(objectKey)
 
 	return nil
 }
@@ -186,8 +404,18 @@ func (ref *ObjectRef) SetTypeID(typeID string) {
 	}
 }
 
-// ToString returns the stringified IOR representation
+// ToString returns the stringified IOR representation. References of objects
+// that no server publishes an endpoint for cannot be stringified.
 func (ref *ObjectRef) ToString() (string, error) {
+	if !ref.bindProfiles() {
+		return "", fmt.Errorf("object reference %s has no profile: no server publishes an endpoint", ref.Name)
+	}
+	return ref.toIOR().ToString(), nil
+}
+
+// toIOR returns the IOR of the reference, creating it from the reference's
+// endpoint and object key if it has none
+func (ref *ObjectRef) toIOR() *IOR {
 	if ref.ior == nil {
 		// Create an IOR if none exists
 		ior := NewIOR(ref.GetTypeID())
@@ -199,11 +427,16 @@ func (ref *ObjectRef) ToString() (string, error) {
 			objKey = ObjectKeyFromString(ref.Name)
 		}
 
-		ior.AddIIOPProfile(version, ref.ServerHost, uint16(ref.ServerPort), objKey)
+		if ref.endpoint.IsZero() {
+			ior.AddIIOPProfile(version, ref.ServerHost, uint16(ref.ServerPort), objKey)
+		} else {
+			transport := profileTransport(ref.endpoint.Protocol)
+			ior.Profiles = append(ior.Profiles, transport.CreateProfile(ref.endpoint, objKey))
+		}
 		ref.ior = ior
 	}
 
-	return ref.ior.ToString(), nil
+	return ref.ior
 }
 
 // CORBASystemException represents a standard CORBA system exception
@@ -0,0 +1,191 @@
+package corba_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// callerContext returns a Context whose properties are split between it and
+// its parent
+func callerContext() *corba.Context {
+	parent := corba.NewContext()
+	parent.Set("USER", "alice")
+	parent.Set("LOCALE_LANG", "en")
+	parent.Set("HOST", "client.example.com")
+
+	ctx := corba.NewContext()
+	ctx.SetParent(parent)
+	ctx.Set("LOCALE_REGION", "GB")
+	ctx.Set("LOCALE_LANG", "fr")
+	return ctx
+}
+
+// wantContextClause is what callerContext sends for context("USER", "LOCALE*")
+var wantContextClause = map[string]interface{}{
+	"USER":          "alice",
+	"LOCALE_LANG":   "fr",
+	"LOCALE_REGION": "GB",
+}
+
+// greeterServant records the IDL Context of its greet and welcome requests.
+// welcome takes the name of the person welcomed.
+type greeterServant struct {
+	received chan map[string]interface{}
+}
+
+func (s *greeterServant) ContextClause(operation string) []string {
+	if operation == "greet" || operation == "welcome" {
+		return []string{"USER", "LOCALE*"}
+	}
+	return nil
+}
+
+func (s *greeterServant) ParameterTypes(operation string) []corba.TypeCode {
+	if operation == "welcome" {
+		tc, _ := corba.GetBasicTypeCode(corba.TC_STRING)
+		return []corba.TypeCode{tc}
+	}
+	return nil
+}
+
+func (s *greeterServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.DispatchContext(context.Background(), methodName, args)
+}
+
+func (s *greeterServant) DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error) {
+	if methodName == "welcome" && (len(args) != 1 || args[0] != "bob") {
+		return nil, corba.BAD_PARAM(corba.MinorInvalidArgument, corba.CompletionStatusNo)
+	}
+	idlCtx, ok := corba.IDLContextFromContext(ctx)
+	if !ok {
+		s.received <- nil
+		return nil, nil
+	}
+	s.received <- idlCtx.GetAll()
+	return nil, nil
+}
+
+func TestContextGetValues(t *testing.T) {
+	ctx := callerContext()
+
+	if got := ctx.GetValues("LOCALE*"); !reflect.DeepEqual(got, map[string]interface{}{"LOCALE_LANG": "fr", "LOCALE_REGION": "GB"}) {
+		t.Errorf("GetValues(LOCALE*) = %v", got)
+	}
+	if got := ctx.GetValues("USER"); !reflect.DeepEqual(got, map[string]interface{}{"USER": "alice"}) {
+		t.Errorf("GetValues(USER) = %v", got)
+	}
+	if got := ctx.GetValues("LOCALE"); len(got) != 0 {
+		t.Errorf("GetValues(LOCALE) = %v, want no properties", got)
+	}
+}
+
+func TestIDLContextClause(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	servant := &greeterServant{received: make(chan map[string]interface{}, 1)}
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// Stubs send the properties matched by the clause
+	if _, err := target.InvokeContext(callerContext(), []string{"USER", "LOCALE*"}, "greet"); err != nil {
+		t.Fatalf("InvokeContext: %v", err)
+	}
+	if got := <-servant.received; !reflect.DeepEqual(got, wantContextClause) {
+		t.Errorf("servant received context %v, want %v", got, wantContextClause)
+	}
+
+	// So do DII requests
+	req := corba.NewRequest(target, "greet")
+	req.Context = callerContext()
+	req.ContextClause = []string{"USER", "LOCALE*"}
+	if _, err := req.Invoke(); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if got := <-servant.received; !reflect.DeepEqual(got, wantContextClause) {
+		t.Errorf("servant received context %v, want %v", got, wantContextClause)
+	}
+
+	// Typed DII requests send the properties after their arguments
+	req = corba.NewRequest(target, "welcome")
+	req.SetResultType(basicTC(t, corba.TC_VOID))
+	req.AddTypedParameter("name", basicTC(t, corba.TC_STRING), "bob", corba.FlagIn)
+	req.Context = callerContext()
+	req.ContextClause = []string{"USER", "LOCALE*"}
+	if _, err := req.Invoke(); err != nil {
+		t.Fatalf("Invoke with arguments: %v", err)
+	}
+	if got := <-servant.received; !reflect.DeepEqual(got, wantContextClause) {
+		t.Errorf("servant received context %v after arguments, want %v", got, wantContextClause)
+	}
+
+	// Operations without a clause receive no Context
+	if _, err := target.Invoke("reset"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if got := <-servant.received; got != nil {
+		t.Errorf("servant received context %v for an operation without a clause", got)
+	}
+}
+
+// contextEcho is a DSI servant returning the number of context properties
+// sent after its single long argument
+type contextEcho struct {
+	t *testing.T
+}
+
+func (e *contextEcho) Invoke(request *corba.ServerRequest) error {
+	if _, err := request.Ctx(); err == nil {
+		e.t.Error("Ctx before Arguments succeeded")
+	}
+
+	var params corba.NVList
+	params.AddValue("a", basicTC(e.t, corba.TC_LONG), nil, corba.FlagIn)
+	if err := request.Arguments(params); err != nil {
+		return err
+	}
+	ctx, err := request.Ctx()
+	if err != nil {
+		return err
+	}
+	if lang, _ := ctx.Get("LOCALE_LANG"); lang != "fr" {
+		e.t.Errorf("LOCALE_LANG = %v, want fr", lang)
+	}
+
+	result, err := corba.NewAnyWithTypeCode(basicTC(e.t, corba.TC_LONG), int32(len(ctx.GetAll())))
+	if err != nil {
+		return err
+	}
+	request.SetResult(result)
+	return nil
+}
+
+func TestDSIContext(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	ref, err := orb.GetRootPOA().ServantToReference(&corba.DynamicServantAdapter{Servant: &contextEcho{t: t}})
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	ref, err = client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	req := corba.NewRequest(ref, "count")
+	req.SetResultType(basicTC(t, corba.TC_LONG))
+	req.AddTypedParameter("a", basicTC(t, corba.TC_LONG), int32(1), corba.FlagIn)
+	req.Context = callerContext()
+	req.ContextClause = []string{"USER", "LOCALE*"}
+	if result, err := req.Invoke(); err != nil || result != int32(len(wantContextClause)) {
+		t.Errorf("Invoke = %v, %v, want %d", result, err, len(wantContextClause))
+	}
+}
@@ -2,7 +2,12 @@
 package corba
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ifabos/go-corba/giop"
 )
 
 // Common DII errors
@@ -17,7 +22,55 @@ var (
 type NamedValue struct {
 	Name  string
 	Value interface{}
-	Flags int // For parameter direction (in, out, inout)
+	Type  TypeCode // IDL type of the value; nil for untyped requests
+	Flags int      // For parameter direction (in, out, inout)
+}
+
+// NVList is an ordered list of named values, such as the parameters of a
+// DII request
+type NVList []*NamedValue
+
+// AddValue appends a typed value to the list and returns it
+func (l *NVList) AddValue(name string, tc TypeCode, value interface{}, flags int) (*NamedValue, error) {
+	if flags != FlagIn && flags != FlagOut && flags != FlagInOut {
+		return nil, ErrInvalidArgument
+	}
+
+	nv := &NamedValue{Name: name, Value: value, Type: tc, Flags: flags}
+	*l = append(*l, nv)
+	return nv, nil
+}
+
+// Count returns the number of values in the list
+func (l NVList) Count() int {
+	return len(l)
+}
+
+// Item returns the value at an index of the list
+func (l NVList) Item(index int) (*NamedValue, error) {
+	if index < 0 || index >= len(l) {
+		return nil, fmt.Errorf("NVList index %d out of range [0, %d)", index, len(l))
+	}
+	return l[index], nil
+}
+
+// ExceptionList lists the TypeCodes of the user exceptions an operation may
+// raise, as declared by its raises clause
+type ExceptionList []TypeCode
+
+// Add appends the TypeCode of a user exception to the list
+func (l *ExceptionList) Add(tc TypeCode) {
+	*l = append(*l, tc)
+}
+
+// lookup returns the TypeCode in the list with a repository ID
+func (l ExceptionList) lookup(id string) (TypeCode, bool) {
+	for _, tc := range l {
+		if tc != nil && tc.Id() == id {
+			return tc, true
+		}
+	}
+	return nil, false
 }
 
 // Parameter flags
@@ -32,15 +85,19 @@ const (
 type Request struct {
 	Target           *ObjectRef     // The target object reference
 	Operation        string         // The operation name to invoke
-	Parameters       []*NamedValue  // The parameters for the operation
+	Parameters       NVList         // The parameters for the operation
 	Result           *NamedValue    // To store the result
+	Exceptions       ExceptionList  // User exceptions the operation may raise
 	Exception        error          // To store any exceptions
 	Context          *Context       // Context for the request
+	ContextClause    []string       // Properties of Context sent with the request, as in the IDL context clause
 	Status           int            // Status of the request
 	ResponseReceived bool           // Whether a response has been received
 	Flags            int            // Request flags
 	Environment      interface{}    // Environment for the request
 	ServerRequest    *ServerRequest // For DSI integration
+
+	done chan struct{} // Closed when a deferred request has completed
 }
 
 // Request status
@@ -56,7 +113,7 @@ func NewRequest(target *ObjectRef, operation string) *Request {
 	return &Request{
 		Target:     target,
 		Operation:  operation,
-		Parameters: make([]*NamedValue, 0),
+		Parameters: make(NVList, 0),
 		Result: &NamedValue{
 			Name:  "result",
 			Value: nil,
@@ -86,6 +143,21 @@ func (r *Request) AddParameter(name string, value interface{}, flag int) error {
 	return nil
 }
 
+// AddTypedParameter adds a parameter with its IDL type to the request
+func (r *Request) AddTypedParameter(name string, tc TypeCode, value interface{}, flag int) error {
+	_, err := r.Parameters.AddValue(name, tc, value, flag)
+	return err
+}
+
+// SetResultType sets the IDL type of the result of the operation. Requests
+// with a result type are sent as CDR: their in and inout parameters are
+// marshalled with their TypeCodes, and the result and the out and inout
+// parameters are decoded from the reply. Operations without a result take
+// the TypeCode of void.
+func (r *Request) SetResultType(tc TypeCode) {
+	r.Result.Type = tc
+}
+
 // SetResult sets the result value of the request
 func (r *Request) SetResult(value interface{}) {
 	r.Result.Value = value
@@ -99,21 +171,22 @@ func (r *Request) GetResult() interface{} {
 // Invoke sends the request and waits for a response
 func (r *Request) Invoke() (interface{}, error) {
 	// Check if the target is valid
-	if r.Target == nil || r.Target.IsNil() {
-		return nil, NewCORBASystemException("OBJECT_NOT_EXIST", 0, CompletionStatusNo)
+	if r.Target == nil || r.Target.IsNil() || r.Target.client == nil {
+		return nil, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
 	}
 
 	// Set the request status to in progress
 	r.Status = StatusInProgress
 
-	// Extract parameter values for the invocation
-	args := make([]interface{}, len(r.Parameters))
-	for i, param := range r.Parameters {
-		args[i] = param.Value
+	inv, err := r.invocation()
+	if err != nil {
+		r.Status = StatusError
+		r.Exception = err
+		return nil, err
 	}
 
-	// Call the target object reference's Invoke method
-	result, err := r.Target.Invoke(r.Operation, args...)
+	// Call the target object reference
+	result, err := r.Target.invoke(inv)
 	if err != nil {
 		r.Status = StatusError
 		r.Exception = err
@@ -127,26 +200,185 @@ func (r *Request) Invoke() (interface{}, error) {
 	return result, nil
 }
 
-// SendDeferred sends the request asynchronously
+// invocation returns the invocation that sends the request, with the Context
+// properties matched by its context clause
+func (r *Request) invocation() (invocation, error) {
+	inv, err := r.argumentInvocation()
+	if err != nil {
+		return invocation{}, err
+	}
+
+	// Operations with a context clause send the matching Context properties
+	if len(r.ContextClause) > 0 {
+		ctx := r.Context
+		if ctx == nil {
+			ctx = NewContext()
+		}
+		inv.context = ctx.clauseValues(r.ContextClause)
+	}
+	return inv, nil
+}
+
+// argumentInvocation returns the invocation carrying the arguments of the
+// request. Requests with a result type are sent as CDR; the others pass their
+// parameter values as they are.
+func (r *Request) argumentInvocation() (invocation, error) {
+	if r.Result.Type == nil {
+		// Extract parameter values for the invocation
+		args := make([]interface{}, len(r.Parameters))
+		for i, param := range r.Parameters {
+			args[i] = param.Value
+		}
+		return invocation{operation: r.Operation, args: args}, nil
+	}
+
+	body, err := r.marshalArguments()
+	if err != nil {
+		return invocation{}, MARSHAL(MinorUnmarshallableArgument, CompletionStatusNo)
+	}
+
+	args := make([]interface{}, 0, len(r.Parameters))
+	for _, param := range r.Parameters {
+		if param.Flags != FlagOut {
+			args = append(args, param.Value)
+		}
+	}
+
+	// A request without a raises clause still accepts no user exceptions
+	raises := ExceptionList{}
+	raises = append(raises, r.Exceptions...)
+
+	return invocation{
+		operation: r.Operation,
+		args:      args,
+		body:      body,
+		decode:    r.unmarshalReply,
+		raises:    raises,
+	}, nil
+}
+
+// marshalArguments encodes the in and inout parameters of the request as the
+// body of a GIOP request
+func (r *Request) marshalArguments() ([]byte, error) {
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	for _, param := range r.Parameters {
+		if param.Flags == FlagOut {
+			continue
+		}
+		if param.Type == nil {
+			return nil, fmt.Errorf("parameter %s has no TypeCode", param.Name)
+		}
+		if err := marshalValue(m, param.Type, param.Value); err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+	}
+	return m.Bytes(), nil
+}
+
+// unmarshalReply decodes the result of the request from the body of a reply,
+// followed by its out and inout parameters, which are stored in the NVList
+func (r *Request) unmarshalReply(body []byte, order binary.ByteOrder) (interface{}, error) {
+	u := giop.NewCDRUnmarshaller(body, order)
+
+	result, err := unmarshalValue(u, r.Result.Type)
+	if err != nil {
+		return nil, fmt.Errorf("result: %w", err)
+	}
+
+	for _, param := range r.Parameters {
+		if param.Flags == FlagIn {
+			continue
+		}
+		if param.Type == nil {
+			return nil, fmt.Errorf("parameter %s has no TypeCode", param.Name)
+		}
+		value, err := unmarshalValue(u, param.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		param.Value = value
+	}
+	return result, nil
+}
+
+// SendOneway sends the request without waiting for, or expecting, a reply.
+// It returns once the request has been written to the connection.
+func (r *Request) SendOneway() error {
+	if r.Target == nil || r.Target.IsNil() || r.Target.client == nil {
+		return OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+	if r.Status != StatusInit {
+		return BAD_INV_ORDER(MinorRequestAlreadySent, CompletionStatusNo)
+	}
+
+	inv, err := r.invocation()
+	if err == nil {
+		inv.oneway = true
+		_, err = r.Target.invoke(inv)
+	}
+	if err != nil {
+		r.Status = StatusError
+		r.Exception = err
+		return err
+	}
+
+	r.Status = StatusCompleted
+	return nil
+}
+
+// SendDeferred sends the request and returns without waiting for the reply,
+// which is collected with PollResponse and GetResponse
 func (r *Request) SendDeferred() error {
+	return r.sendDeferred(nil)
+}
+
+// sendDeferred sends the request on its own goroutine and calls completed, if
+// set, once the reply has arrived
+func (r *Request) sendDeferred(completed func(*Request)) error {
+	if r.done != nil || r.Status != StatusInit {
+		return BAD_INV_ORDER(MinorRequestAlreadySent, CompletionStatusNo)
+	}
+
 	// Set deferred flag
 	r.Flags |= FlagDeferred
+	r.Status = StatusInProgress
+	r.done = make(chan struct{})
 
-	// Future implementation will use goroutines to handle this properly
-	// For now, we'll do a synchronous call as a placeholder
-	_, err := r.Invoke()
-	return err
+	go func() {
+		r.Invoke()
+		close(r.done)
+		if completed != nil {
+			completed(r)
+		}
+	}()
+	return nil
 }
 
 // PollResponse checks if a deferred response has been received
 func (r *Request) PollResponse() bool {
-	// For the initial implementation, we'll assume the response is ready
-	// if the status is completed
-	return r.Status == StatusCompleted
+	if r.done == nil {
+		return r.Status == StatusCompleted
+	}
+
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
 }
 
-// GetResponse gets the response for a deferred request
+// GetResponse gets the response for a deferred request, waiting for it to
+// arrive. The exception raised by the operation, if any, is returned.
 func (r *Request) GetResponse() (interface{}, error) {
+	if r.done != nil {
+		<-r.done
+	}
+
+	if r.Status == StatusError && r.Exception != nil {
+		return nil, r.Exception
+	}
+
 	if !r.ResponseReceived {
 		return nil, ErrNoResponse
 	}
@@ -161,20 +393,108 @@ func (r *Request) GetResponse() (interface{}, error) {
 // RequestProcessor handles DII requests
 type RequestProcessor struct {
 	orb *ORB
+
+	mu          sync.Mutex
+	responded   *sync.Cond // Signalled when a deferred request completes
+	outstanding int        // Deferred requests sent and not yet completed
+	responses   []*Request // Completed deferred requests not yet returned
 }
 
 // NewRequestProcessor creates a new DII request processor
 func NewRequestProcessor(orb *ORB) *RequestProcessor {
-	return &RequestProcessor{orb: orb}
+	rp := &RequestProcessor{orb: orb}
+	rp.responded = sync.NewCond(&rp.mu)
+	return rp
+}
+
+// SendMultipleRequestsOneway sends oneway requests concurrently. It returns
+// once every request has been sent, with the first error encountered.
+func (rp *RequestProcessor) SendMultipleRequestsOneway(requests []*Request) error {
+	errs := make([]error, len(requests))
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = req.SendOneway()
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendMultipleRequestsDeferred sends requests concurrently without waiting for
+// their replies. Requests to the same server share its connection. The
+// requests are collected with GetNextResponse as their replies arrive.
+func (rp *RequestProcessor) SendMultipleRequestsDeferred(requests []*Request) error {
+	for _, req := range requests {
+		rp.mu.Lock()
+		rp.outstanding++
+		rp.mu.Unlock()
+
+		if err := req.sendDeferred(rp.complete); err != nil {
+			rp.mu.Lock()
+			rp.outstanding--
+			rp.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// complete queues a deferred request whose reply has arrived
+func (rp *RequestProcessor) complete(req *Request) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	rp.outstanding--
+	rp.responses = append(rp.responses, req)
+	rp.responded.Broadcast()
+}
+
+// PollNextResponse reports whether a request sent with
+// SendMultipleRequestsDeferred has completed and awaits GetNextResponse
+func (rp *RequestProcessor) PollNextResponse() bool {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return len(rp.responses) > 0
+}
+
+// GetNextResponse returns the next request sent with
+// SendMultipleRequestsDeferred to complete, waiting for one if needed.
+// Requests are returned in the order their replies arrive; their result or
+// exception is read with GetResponse. It raises BAD_INV_ORDER when no
+// deferred request is outstanding.
+func (rp *RequestProcessor) GetNextResponse() (*Request, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	for len(rp.responses) == 0 {
+		if rp.outstanding == 0 {
+			return nil, BAD_INV_ORDER(MinorRequestNotSent, CompletionStatusNo)
+		}
+		rp.responded.Wait()
+	}
+
+	req := rp.responses[0]
+	rp.responses = rp.responses[1:]
+	return req, nil
 }
 
 // CreateRequest creates a new request on the specified object reference
 func (rp *RequestProcessor) CreateRequest(
 	target *ObjectRef,
 	operation string,
-	params []*NamedValue,
+	params NVList,
 	result *NamedValue,
-	exceptions []string,
+	exceptions ExceptionList,
 	ctx *Context) *Request {
 
 	req := NewRequest(target, operation)
@@ -189,6 +509,9 @@ func (rp *RequestProcessor) CreateRequest(
 		req.Result = result
 	}
 
+	// Set the exceptions the operation may raise
+	req.Exceptions = exceptions
+
 	// Set context if provided
 	if ctx != nil {
 		req.Context = ctx
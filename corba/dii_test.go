@@ -0,0 +1,384 @@
+package corba_test
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// giopResponder answers the requests of a connection with the reply status
+// and body built by reply from the request body
+func giopResponder(t *testing.T, name string, reply func(operation string, body []byte) (uint32, []byte)) corba.Endpoint {
+	t.Helper()
+
+	ep := corba.NewMemEndpoint(name)
+	l, err := corba.NewMemTransport().Listen(ep)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			header := make([]byte, 12)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			size := binary.BigEndian.Uint32(header[8:])
+			body := make([]byte, size)
+			if _, err := io.ReadFull(conn, body); err != nil {
+				return
+			}
+			msg, err := giop.UnmarshalGIOPMessage(append(header, body...))
+			if err != nil {
+				return
+			}
+			request := msg.Body.(*giop.RequestHeader)
+
+			status, replyBody := reply(request.Operation, request.Body)
+			data, err := giop.MarshalGIOPMessage(&giop.Message{
+				Header: giop.NewMessageHeader(giop.MsgReply, 0),
+				Body: &giop.ReplyHeader{
+					ServiceContexts: make(giop.ServiceContextList, 0),
+					RequestID:       request.RequestID,
+					ReplyStatus:     status,
+					Body:            replyBody,
+				},
+			})
+			if err != nil {
+				return
+			}
+			conn.Write(data)
+		}
+	}()
+	return ep
+}
+
+// calculatorReply implements
+//
+//	long divide(in long a, in long b, out long remainder, inout string log)
+//	    raises (DivideByZero);
+func calculatorReply(operation string, body []byte) (uint32, []byte) {
+	u := giop.NewCDRUnmarshaller(body, binary.BigEndian)
+	a, _ := u.ReadLong()
+	b, _ := u.ReadLong()
+	log, _ := u.ReadString()
+
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	if b == 0 {
+		m.WriteString("IDL:Test/DivideByZero:1.0")
+		m.WriteLong(a)
+		return giop.ReplyStatusUserException, m.Bytes()
+	}
+	m.WriteLong(a / b)
+	m.WriteLong(a % b)
+	m.WriteString(log + " divided")
+	return giop.ReplyStatusNoException, m.Bytes()
+}
+
+// divideRequest creates a DII request for divide
+func divideRequest(t *testing.T, ref *corba.ObjectRef, a, b int32) *corba.Request {
+	t.Helper()
+
+	long := basicTC(t, corba.TC_LONG)
+	str := basicTC(t, corba.TC_STRING)
+
+	req := corba.NewRequest(ref, "divide")
+	req.SetResultType(long)
+	for _, p := range []struct {
+		name  string
+		tc    corba.TypeCode
+		value interface{}
+		flag  int
+	}{
+		{"a", long, a, corba.FlagIn},
+		{"b", long, b, corba.FlagIn},
+		{"remainder", long, nil, corba.FlagOut},
+		{"log", str, "log:", corba.FlagInOut},
+	} {
+		if err := req.AddTypedParameter(p.name, p.tc, p.value, p.flag); err != nil {
+			t.Fatalf("AddTypedParameter(%s): %v", p.name, err)
+		}
+	}
+	return req
+}
+
+func TestTypedDIIRequest(t *testing.T) {
+	orb := corba.Init()
+	ep := giopResponder(t, t.Name(), calculatorReply)
+	ref, err := orb.CreateClient().GetObjectAt("Calculator", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	req := divideRequest(t, ref, 17, 5)
+	result, err := req.Invoke()
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if result != int32(3) || req.GetResult() != int32(3) {
+		t.Errorf("result = %v, %v, want 3", result, req.GetResult())
+	}
+	if got := req.Parameters[2].Value; got != int32(2) {
+		t.Errorf("out remainder = %v, want 2", got)
+	}
+	if got := req.Parameters[3].Value; got != "log: divided" {
+		t.Errorf("inout log = %v, want %q", got, "log: divided")
+	}
+	if got := req.Parameters[0].Value; got != int32(17) {
+		t.Errorf("in parameter changed to %v", got)
+	}
+}
+
+func TestTypedDIIRequestExceptions(t *testing.T) {
+	orb := corba.Init()
+	ep := giopResponder(t, t.Name(), calculatorReply)
+	ref, err := orb.CreateClient().GetObjectAt("Calculator", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	divideByZero, err := corba.CreateExceptionTypeCode("IDL:Test/DivideByZero:1.0", "DivideByZero")
+	if err != nil {
+		t.Fatalf("CreateExceptionTypeCode: %v", err)
+	}
+	if divideByZero.MemberCount() == 0 {
+		divideByZero.AddMember("dividend", basicTC(t, corba.TC_LONG))
+	}
+
+	// Listed exceptions are decoded with their TypeCode
+	req := divideRequest(t, ref, 17, 0)
+	req.Exceptions.Add(divideByZero)
+	_, err = req.Invoke()
+	ex, ok := err.(*corba.UserException)
+	if !ok || ex.ID() != "IDL:Test/DivideByZero:1.0" {
+		t.Fatalf("Invoke error = %v, want DivideByZero", err)
+	}
+	if dividend, _ := ex.GetMember("dividend"); dividend != int32(17) {
+		t.Errorf("dividend = %v, want 17", dividend)
+	}
+	if req.Status != corba.StatusError || req.Exception != err {
+		t.Errorf("request status %d with exception %v after failure", req.Status, req.Exception)
+	}
+
+	// Exceptions missing from the raises clause are reported as UNKNOWN
+	_, err = divideRequest(t, ref, 17, 0).Invoke()
+	wantMinorCode(t, err, "UNKNOWN", corba.MinorUnlistedUserException)
+}
+
+func TestSendMultipleRequestsDeferred(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	servant := newConcurrencyServant()
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	const count = 8
+	processor := orb.GetRequestProcessor()
+	requests := make([]*corba.Request, count)
+	for i := range requests {
+		requests[i] = corba.NewRequest(target, "block")
+	}
+	if err := processor.SendMultipleRequestsDeferred(requests); err != nil {
+		t.Fatalf("SendMultipleRequestsDeferred: %v", err)
+	}
+
+	// Every request is dispatched while the others are still outstanding
+	for range count {
+		<-servant.started
+	}
+	if processor.PollNextResponse() || requests[0].PollResponse() {
+		t.Error("response available before the servant replied")
+	}
+	if err := requests[0].SendDeferred(); err == nil {
+		t.Error("SendDeferred of a request already sent succeeded")
+	}
+	close(servant.gate)
+
+	seen := make(map[*corba.Request]bool)
+	for range count {
+		req, err := processor.GetNextResponse()
+		if err != nil {
+			t.Fatalf("GetNextResponse: %v", err)
+		}
+		if _, err := req.GetResponse(); err != nil {
+			t.Errorf("GetResponse: %v", err)
+		}
+		seen[req] = true
+	}
+	if len(seen) != count {
+		t.Errorf("GetNextResponse returned %d distinct requests, want %d", len(seen), count)
+	}
+	if got := servant.maxConcurrent(); got != count {
+		t.Errorf("max concurrent requests = %d, want %d", got, count)
+	}
+
+	_, err = processor.GetNextResponse()
+	wantMinorCode(t, err, "BAD_INV_ORDER", corba.MinorRequestNotSent)
+}
+
+func TestSendOneway(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	servant := newConcurrencyServant()
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// Oneway requests return while the servant is still blocked
+	requests := []*corba.Request{corba.NewRequest(target, "block"), corba.NewRequest(target, "block")}
+	if err := orb.GetRequestProcessor().SendMultipleRequestsOneway(requests); err != nil {
+		t.Fatalf("SendMultipleRequestsOneway: %v", err)
+	}
+	<-servant.started
+	<-servant.started
+	close(servant.gate)
+
+	// The connection carries no stray replies for the next request
+	if err := corba.NewRequest(target, "ping").SendOneway(); err != nil {
+		t.Fatalf("SendOneway: %v", err)
+	}
+	<-servant.started
+	if _, err := corba.NewRequest(target, "ping").Invoke(); err != nil {
+		t.Errorf("Invoke after oneway requests: %v", err)
+	}
+}
+
+// rawResponder answers the first request of a connection with a fixed message
+func rawResponder(t *testing.T, name string, message func(requestID uint32) []byte) corba.Endpoint {
+	t.Helper()
+
+	ep := corba.NewMemEndpoint(name)
+	l, err := corba.NewMemTransport().Listen(ep)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		header := make([]byte, 12)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		body := make([]byte, binary.BigEndian.Uint32(header[8:]))
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		msg, err := giop.UnmarshalGIOPMessage(append(header, body...))
+		if err != nil {
+			return
+		}
+		conn.Write(message(msg.Body.(*giop.RequestHeader).RequestID))
+		io.Copy(io.Discard, conn)
+	}()
+	return ep
+}
+
+func TestMessageErrorFailsConnection(t *testing.T) {
+	ep := rawResponder(t, t.Name(), func(uint32) []byte {
+		return []byte{'G', 'I', 'O', 'P', 1, 2, 0, giop.MsgMessageError, 0, 0, 0, 0}
+	})
+	ref, err := corba.Init().CreateClient().GetObjectAt("Calculator", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	_, err = ref.Invoke("ping")
+	wantMinorCode(t, err, "COMM_FAILURE", corba.MinorMessageError)
+}
+
+func TestFragmentedReplyRejected(t *testing.T) {
+	ep := rawResponder(t, t.Name(), func(requestID uint32) []byte {
+		msg := &giop.Message{
+			Header: giop.NewMessageHeader(giop.MsgReply, 0),
+			Body: &giop.ReplyHeader{
+				ServiceContexts: make(giop.ServiceContextList, 0),
+				RequestID:       requestID,
+				ReplyStatus:     giop.ReplyStatusNoException,
+			},
+		}
+		msg.Header.Flags |= 0x02 // More fragments follow
+		data, _ := giop.MarshalGIOPMessage(msg)
+		return data
+	})
+	ref, err := corba.Init().CreateClient().GetObjectAt("Calculator", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	_, err = ref.Invoke("ping")
+	wantMinorCode(t, err, "MARSHAL", corba.MinorFragmentedMessage)
+}
+
+func TestLittleEndianExceptionReply(t *testing.T) {
+	ep := rawResponder(t, t.Name(), func(requestID uint32) []byte {
+		m := giop.NewCDRMarshaller(binary.LittleEndian)
+		m.WriteString("IDL:omg.org/CORBA/TRANSIENT:1.0")
+		m.WriteULong(corba.MinorServerShuttingDown)
+		m.WriteULong(uint32(corba.CompletionStatusNo))
+		msg := &giop.Message{
+			Header: giop.NewMessageHeader(giop.MsgReply, 0),
+			Body: &giop.ReplyHeader{
+				ServiceContexts: make(giop.ServiceContextList, 0),
+				RequestID:       requestID,
+				ReplyStatus:     giop.ReplyStatusSystemException,
+				Body:            m.Bytes(),
+			},
+		}
+		msg.Header.Flags |= 0x01 // Little endian
+		data, _ := giop.MarshalGIOPMessage(msg)
+		return data
+	})
+	ref, err := corba.Init().CreateClient().GetObjectAt("Calculator", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	_, err = ref.Invoke("ping")
+	wantMinorCode(t, err, "TRANSIENT", corba.MinorServerShuttingDown)
+}
+
+func TestGIOP12BodyAlignment(t *testing.T) {
+	msg := giop.NewRequestMessage(1, []byte("key"), "op", true)
+	msg.Body.(*giop.RequestHeader).Body = []byte{1, 2, 3}
+	data, err := giop.MarshalGIOPMessage(msg)
+	if err != nil {
+		t.Fatalf("MarshalGIOPMessage: %v", err)
+	}
+
+	// The body starts on an 8-octet boundary from the start of the message
+	if offset := len(data) - 3; offset%8 != 0 {
+		t.Errorf("body starts at offset %d", offset)
+	}
+	decoded, err := giop.UnmarshalGIOPMessage(data)
+	if err != nil {
+		t.Fatalf("UnmarshalGIOPMessage: %v", err)
+	}
+	if body := decoded.Body.(*giop.RequestHeader).Body; string(body) != "\x01\x02\x03" {
+		t.Errorf("body = %v, want [1 2 3]", body)
+	}
+}
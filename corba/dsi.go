@@ -2,9 +2,13 @@
 package corba
 
 import (
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"reflect"
+
+	"github.com/ifabos/go-corba/giop"
 )
 
 // Common DSI errors
@@ -15,7 +19,9 @@ var (
 	ErrOperationNotFound       = errors.New("operation not found in the interface repository")
 )
 
-// ServerRequest represents a request being processed by a dynamic skeleton
+// ServerRequest represents a request being processed by a dynamic skeleton.
+// Requests received from the wire carry the encoded request body, which is
+// only decoded when the servant describes the parameters with Arguments.
 type ServerRequest struct {
 	// The name of the operation being invoked
 	Operation string
@@ -23,8 +29,9 @@ type ServerRequest struct {
 	// The object key for the target object
 	ObjectKey string
 
-	// Arguments for the operation
-	Arguments []interface{}
+	// Args holds the in and inout argument values of the operation. For
+	// requests received from the wire it is filled in by Arguments.
+	Args []interface{}
 
 	// Result storage for the operation
 	Result interface{}
@@ -37,6 +44,14 @@ type ServerRequest struct {
 
 	// The context for this request
 	Context *Context
+
+	body          []byte                // Encoded request body; nil for requests built in process
+	order         binary.ByteOrder      // Byte order of body
+	params        NVList                // Parameters described by the servant through Arguments
+	argumentsRead bool                  // Whether Arguments has been called
+	contextRead   bool                  // Whether Ctx has been called
+	rest          *giop.CDRUnmarshaller // Body after the arguments: the IDL context, if any
+	ctx           context.Context       // Go context of the dispatch, carrying the POA Current
 }
 
 // NewServerRequest creates a new server request
@@ -45,26 +60,152 @@ func NewServerRequest(operation string, objectKey string, requestID uint32) *Ser
 		Operation: operation,
 		ObjectKey: objectKey,
 		RequestID: requestID,
-		Arguments: make([]interface{}, 0),
+		Args:      make([]interface{}, 0),
 		Context:   NewContext(),
 	}
 }
 
+// newWireServerRequest creates a server request for a GIOP request, whose
+// arguments are decoded from the request body on demand
+func newWireServerRequest(ctx context.Context, request *giop.RequestHeader) *ServerRequest {
+	sr := NewServerRequest(request.Operation, string(request.ObjectKey), request.RequestID)
+	sr.body = request.Body
+	if sr.body == nil {
+		sr.body = []byte{}
+	}
+	sr.order = bodyByteOrder(request.ByteOrder)
+	sr.ctx = ctx
+	return sr
+}
+
 // AddArgument adds an argument to the server request
 func (sr *ServerRequest) AddArgument(value interface{}) {
-	sr.Arguments = append(sr.Arguments, value)
+	sr.Args = append(sr.Args, value)
+}
+
+// Arguments fills in the values of the in and inout parameters described by
+// nvlist, in the order of the operation's signature. Requests received from
+// the wire are decoded with the TypeCodes of the parameters; a list that does
+// not describe the body raises MARSHAL. The list is kept for the reply, which
+// carries the values the servant leaves in its out and inout parameters.
+// Arguments can only be called once. For operations with a context clause,
+// the body continues with the IDL context read by Ctx.
+func (sr *ServerRequest) Arguments(nvlist NVList) error {
+	if sr.argumentsRead {
+		return BAD_INV_ORDER(MinorArgumentsCalledTwice, CompletionStatusNo)
+	}
+	sr.argumentsRead = true
+
+	if sr.body == nil {
+		// Requests built in process carry their arguments as Go values
+		in := 0
+		for _, param := range nvlist {
+			if param.Flags == FlagOut {
+				continue
+			}
+			if in == len(sr.Args) {
+				return MARSHAL(MinorArgumentsMismatch, CompletionStatusNo)
+			}
+			param.Value = sr.Args[in]
+			in++
+		}
+		sr.params = nvlist
+		return nil
+	}
+
+	u := giop.NewCDRUnmarshaller(sr.body, sr.order)
+	args := make([]interface{}, 0, len(nvlist))
+	for _, param := range nvlist {
+		if param.Flags == FlagOut {
+			continue
+		}
+		if param.Type == nil {
+			return MARSHAL(MinorArgumentsMismatch, CompletionStatusNo)
+		}
+		value, err := unmarshalValue(u, param.Type)
+		if err != nil {
+			return MARSHAL(MinorArgumentsMismatch, CompletionStatusNo)
+		}
+		param.Value = value
+		args = append(args, value)
+	}
+
+	sr.Args = args
+	sr.params = nvlist
+	sr.rest = u
+	return nil
+}
+
+// Ctx returns the IDL Context properties sent with the request, for
+// operations declared with a context clause. It must be called once, after
+// Arguments. Requests without context properties yield an empty Context.
+func (sr *ServerRequest) Ctx() (*Context, error) {
+	if !sr.argumentsRead || sr.contextRead {
+		return nil, BAD_INV_ORDER(MinorContextOrder, CompletionStatusNo)
+	}
+	sr.contextRead = true
+
+	if sr.rest == nil || sr.rest.Len() == 0 {
+		// Requests built in process carry their Context as it is
+		return sr.Context, nil
+	}
+
+	ctx, err := unmarshalContextValues(sr.rest)
+	if err != nil {
+		return nil, MARSHAL(MinorMalformedRequest, CompletionStatusNo)
+	}
+	sr.Context = ctx
+	return ctx, nil
+}
+
+// RequestContext returns the Go context of the dispatch, which carries the
+// POA Current of requests dispatched through a POA
+func (sr *ServerRequest) RequestContext() context.Context {
+	if sr.ctx == nil {
+		return context.Background()
+	}
+	return sr.ctx
 }
 
-// SetResult sets the result of the operation
+// SetResult sets the result of the operation. Requests received from the
+// wire are answered with the result marshalled with its TypeCode, so the
+// result of such requests must be an *Any, or nil for void operations.
 func (sr *ServerRequest) SetResult(result interface{}) {
 	sr.Result = result
 }
 
-// SetException sets an exception for the operation
+// SetException sets an exception for the operation. It is marshalled into
+// the exception reply in place of the result.
 func (sr *ServerRequest) SetException(err error) {
 	sr.Exception = err
 }
 
+// marshalReply encodes the body of the reply to a request received from the
+// wire: the result, followed by the out and inout parameters
+func (sr *ServerRequest) marshalReply() (encodedResult, error) {
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+
+	switch result := sr.Result.(type) {
+	case nil:
+	case *Any:
+		if err := marshalValue(m, result.TypeCode(), result.Value()); err != nil {
+			return nil, fmt.Errorf("result: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("result %T is not an Any", result)
+	}
+
+	for _, param := range sr.params {
+		if param.Flags == FlagIn {
+			continue
+		}
+		if err := marshalValue(m, param.Type, param.Value); err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+	}
+	return encodedResult(m.Bytes()), nil
+}
+
 // DynamicImplementation defines the interface that dynamic servants must implement
 // to handle requests through the Dynamic Skeleton Interface (DSI)
 type DynamicImplementation interface {
@@ -104,6 +245,11 @@ func NewDynamicServant(repoID string) *DynamicServant {
 	}
 }
 
+// PrimaryInterface returns the repository ID of the interface the servant implements
+func (ds *DynamicServant) PrimaryInterface() string {
+	return ds.RepositoryID
+}
+
 // AddOperation adds a new operation to the dynamic servant
 func (ds *DynamicServant) AddOperation(name string, returnType reflect.Type) {
 	ds.Operations[name] = OperationInfo{
@@ -158,6 +304,15 @@ type DynamicServantAdapter struct {
 	Servant DynamicImplementation
 }
 
+// PrimaryInterface returns the repository ID of the dynamic implementation,
+// if it reports one
+func (adapter *DynamicServantAdapter) PrimaryInterface() string {
+	if p, ok := adapter.Servant.(PrimaryInterfaceProvider); ok {
+		return p.PrimaryInterface()
+	}
+	return ""
+}
+
 // Dispatch implements the Dispatch method expected by the server
 // It creates a ServerRequest and passes it to the dynamic implementation
 func (adapter *DynamicServantAdapter) Dispatch(methodName string, args []interface{}) (interface{}, error) {
@@ -183,3 +338,26 @@ func (adapter *DynamicServantAdapter) Dispatch(methodName string, args []interfa
 	// Return the result
 	return request.Result, nil
 }
+
+// Invoke passes a request to the dynamic implementation, so that the adapter
+// is dispatched like the servant it wraps
+func (adapter *DynamicServantAdapter) Invoke(request *ServerRequest) error {
+	return adapter.Servant.Invoke(request)
+}
+
+// dispatchServerRequest invokes a dynamic servant for a request received from
+// the wire and returns the encoded reply body
+func dispatchServerRequest(servant DynamicImplementation, request *ServerRequest) (interface{}, error) {
+	if err := servant.Invoke(request); err != nil {
+		return nil, err
+	}
+	if request.Exception != nil {
+		return nil, request.Exception
+	}
+
+	body, err := request.marshalReply()
+	if err != nil {
+		return nil, MARSHAL(MinorUnmarshallableResult, CompletionStatusYes)
+	}
+	return body, nil
+}
@@ -0,0 +1,119 @@
+package corba_test
+
+import (
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// dynamicCalculator implements divide, as declared for calculatorReply,
+// without static knowledge of its signature
+type dynamicCalculator struct {
+	t *testing.T
+}
+
+func (c *dynamicCalculator) Invoke(request *corba.ServerRequest) error {
+	long := basicTC(c.t, corba.TC_LONG)
+	str := basicTC(c.t, corba.TC_STRING)
+
+	var params corba.NVList
+	params.AddValue("a", long, nil, corba.FlagIn)
+	params.AddValue("b", long, nil, corba.FlagIn)
+	remainder, _ := params.AddValue("remainder", long, nil, corba.FlagOut)
+	log, _ := params.AddValue("log", str, nil, corba.FlagInOut)
+	if err := request.Arguments(params); err != nil {
+		return err
+	}
+
+	a, b := request.Args[0].(int32), request.Args[1].(int32)
+	if b == 0 {
+		ex := corba.NewCORBAUserException("DivideByZero", "IDL:Test/DivideByZero:1.0")
+		ex.SetMember("dividend", a)
+		request.SetException(ex)
+		return nil
+	}
+	remainder.Value = a % b
+	log.Value = log.Value.(string) + " divided"
+	result, err := corba.NewAnyWithTypeCode(long, a/b)
+	if err != nil {
+		return err
+	}
+	request.SetResult(result)
+	return nil
+}
+
+func TestDSIServerRequest(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	ref, err := orb.GetRootPOA().ServantToReference(&corba.DynamicServantAdapter{Servant: &dynamicCalculator{t: t}})
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	ref, err = client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	req := divideRequest(t, ref, 23, 4)
+	if result, err := req.Invoke(); err != nil || result != int32(5) {
+		t.Fatalf("Invoke = %v, %v, want 5", result, err)
+	}
+	if got := req.Parameters[2].Value; got != int32(3) {
+		t.Errorf("out remainder = %v, want 3", got)
+	}
+	if got := req.Parameters[3].Value; got != "log: divided" {
+		t.Errorf("inout log = %v, want %q", got, "log: divided")
+	}
+
+	divideByZero, err := corba.CreateExceptionTypeCode("IDL:Test/DivideByZero:1.0", "DivideByZero")
+	if err != nil {
+		t.Fatalf("CreateExceptionTypeCode: %v", err)
+	}
+	if divideByZero.MemberCount() == 0 {
+		divideByZero.AddMember("dividend", basicTC(t, corba.TC_LONG))
+	}
+	req = divideRequest(t, ref, 23, 0)
+	req.Exceptions.Add(divideByZero)
+	_, err = req.Invoke()
+	if ex, ok := err.(*corba.UserException); !ok || ex.ID() != "IDL:Test/DivideByZero:1.0" {
+		t.Errorf("Invoke error = %v, want DivideByZero", err)
+	}
+}
+
+func TestDSIServantWithoutAdapter(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	ref, err := orb.GetRootPOA().ServantToReference(&dynamicCalculator{t: t})
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	ref, err = client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// Dynamic servants are dispatched through DSI without being wrapped
+	if result, err := divideRequest(t, ref, 9, 2).Invoke(); err != nil || result != int32(4) {
+		t.Fatalf("Invoke = %v, %v, want 4", result, err)
+	}
+}
+
+func TestDSIArgumentsMismatch(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	ref, err := orb.GetRootPOA().ServantToReference(&corba.DynamicServantAdapter{Servant: &dynamicCalculator{t: t}})
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	ref, err = client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// A request carrying a single long does not match the servant's list
+	req := corba.NewRequest(ref, "divide")
+	req.SetResultType(basicTC(t, corba.TC_LONG))
+	req.AddTypedParameter("a", basicTC(t, corba.TC_LONG), int32(1), corba.FlagIn)
+	_, err = req.Invoke()
+	wantMinorCode(t, err, "MARSHAL", corba.MinorArgumentsMismatch)
+}
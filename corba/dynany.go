@@ -0,0 +1,732 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"math/big"
+	"reflect"
+)
+
+// DynamicAny module exceptions. They are CORBA user exceptions and can be
+// compared with ==.
+var (
+	ErrDynAnyTypeMismatch   = NewCORBAUserException("TypeMismatch", "IDL:omg.org/DynamicAny/DynAny/TypeMismatch:1.0")
+	ErrDynAnyInvalidValue   = NewCORBAUserException("InvalidValue", "IDL:omg.org/DynamicAny/DynAny/InvalidValue:1.0")
+	ErrInconsistentTypeCode = NewCORBAUserException("InconsistentTypeCode", "IDL:omg.org/DynamicAny/DynAnyFactory/InconsistentTypeCode:1.0")
+)
+
+// DynAny is a value of any IDL type that is built and inspected through its
+// TypeCode rather than through a static Go type. Values of constructed types
+// are made of components, which are DynAny values themselves. One of them is
+// the current component: the Insert and Get operations of a constructed value
+// act on it, and Seek, Rewind and Next move between components.
+//
+// Values of constructed types are converted to and from Any as follows:
+// structs and exceptions as a map of their members, sequences and arrays as
+// a []interface{} (a []byte for octets), unions as a UnionValue, enums as
+// their uint32 ordinal and fixed point decimals as a string.
+type DynAny interface {
+	// Type returns the TypeCode of the value
+	Type() TypeCode
+
+	// Assign copies the value of a DynAny of the same type
+	Assign(dyn DynAny) error
+
+	// FromAny sets the value from an Any of the same type
+	FromAny(value *Any) error
+
+	// ToAny returns the value as an Any
+	ToAny() *Any
+
+	// Equal reports whether a DynAny has the same type and value
+	Equal(dyn DynAny) bool
+
+	// Copy returns a deep copy of the value
+	Copy() DynAny
+
+	// Insert operations set a value of a basic type, or the current
+	// component of a constructed value
+	InsertBoolean(value bool) error
+	InsertOctet(value byte) error
+	InsertChar(value byte) error
+	InsertWChar(value rune) error
+	InsertShort(value int16) error
+	InsertUShort(value uint16) error
+	InsertLong(value int32) error
+	InsertULong(value uint32) error
+	InsertLongLong(value int64) error
+	InsertULongLong(value uint64) error
+	InsertFloat(value float32) error
+	InsertDouble(value float64) error
+	InsertString(value string) error
+	InsertWString(value string) error
+	InsertAny(value *Any) error
+	InsertDynAny(value DynAny) error
+
+	// Get operations return a value of a basic type, or the current
+	// component of a constructed value
+	GetBoolean() (bool, error)
+	GetOctet() (byte, error)
+	GetChar() (byte, error)
+	GetWChar() (rune, error)
+	GetShort() (int16, error)
+	GetUShort() (uint16, error)
+	GetLong() (int32, error)
+	GetULong() (uint32, error)
+	GetLongLong() (int64, error)
+	GetULongLong() (uint64, error)
+	GetFloat() (float32, error)
+	GetDouble() (float64, error)
+	GetString() (string, error)
+	GetWString() (string, error)
+	GetAny() (*Any, error)
+	GetDynAny() (DynAny, error)
+
+	// Seek makes the component at index current. It reports false, leaving
+	// no current component, if there is no such component.
+	Seek(index int) bool
+
+	// Rewind makes the first component current
+	Rewind()
+
+	// Next makes the next component current. It reports false, leaving no
+	// current component, after the last one.
+	Next() bool
+
+	// ComponentCount returns the number of components
+	ComponentCount() int
+
+	// CurrentComponent returns the current component, or nil if there is
+	// none. Values of types without components raise TypeMismatch.
+	CurrentComponent() (DynAny, error)
+}
+
+// DynAnyFactory creates DynAny values
+type DynAnyFactory struct{}
+
+// GetDynAnyFactory returns the ORB's DynAny factory
+func (orb *ORB) GetDynAnyFactory() *DynAnyFactory {
+	return &DynAnyFactory{}
+}
+
+// CreateDynAny creates a DynAny holding the value of an Any. It raises
+// InconsistentTypeCode if the Any's type is not supported, and InvalidValue if
+// its value does not match its type.
+func (f *DynAnyFactory) CreateDynAny(value *Any) (DynAny, error) {
+	if value == nil {
+		return nil, ErrDynAnyInvalidValue
+	}
+
+	d, err := newDynAny(value.TypeCode())
+	if err != nil {
+		return nil, err
+	}
+	if err := d.setFrom(value.Value()); err != nil {
+		return nil, err
+	}
+	d.current = d.firstComponent()
+	return d.wrap(), nil
+}
+
+// CreateDynAnyFromTypeCode creates a DynAny holding the default value of a
+// type: zero for numbers, empty strings and sequences, the first enumerator
+// and, for unions, the first member.
+func (f *DynAnyFactory) CreateDynAnyFromTypeCode(tc TypeCode) (DynAny, error) {
+	d, err := newDynAny(tc)
+	if err != nil {
+		return nil, err
+	}
+	return d.wrap(), nil
+}
+
+// dynAny holds the value of a DynAny. Basic types, enums and fixed point
+// decimals keep their value in value; constructed types keep theirs in
+// components.
+type dynAny struct {
+	tc         TypeCode
+	impl       TypeCodeImpl
+	kind       TCKind
+	value      interface{}
+	components []*dynAny
+	current    int // Index of the current component, or -1
+	member     int // Index of the active member of a union, or -1
+}
+
+// dynAnyNode is implemented by every DynAny created by a DynAnyFactory
+type dynAnyNode interface {
+	node() *dynAny
+}
+
+// newDynAny creates a DynAny holding the default value of a type
+func newDynAny(tc TypeCode) (*dynAny, error) {
+	// Values of an alias are values of the original type
+	original := unaliasTypeCode(tc)
+	kind, err := tcKindOf(original)
+	if err != nil {
+		return nil, ErrInconsistentTypeCode
+	}
+	d := &dynAny{tc: tc, impl: original.(TypeCodeImpl), kind: kind, current: -1, member: -1}
+
+	switch {
+	case isBasicKind(kind):
+		d.value = zeroValue(kind)
+	case kind == TC_ANY:
+		d.value = nullAny()
+	default:
+		if err := d.initConstructed(); err != nil {
+			return nil, err
+		}
+	}
+
+	d.current = d.firstComponent()
+	return d, nil
+}
+
+// nullAny returns an Any holding no value
+func nullAny() *Any {
+	tc, _ := GetBasicTypeCode(TC_NULL)
+	return &Any{typeCode: tc}
+}
+
+// node returns the value of the DynAny
+func (d *dynAny) node() *dynAny {
+	return d
+}
+
+// wrap returns the DynAny for a value, with the interface of its type
+func (d *dynAny) wrap() DynAny {
+	switch d.kind {
+	case TC_STRUCT, TC_EXCEPT:
+		return &dynStruct{d}
+	case TC_UNION:
+		return &dynUnion{d}
+	case TC_SEQUENCE:
+		return &dynSequence{dynElements{d}}
+	case TC_ARRAY:
+		return &dynArray{dynElements{d}}
+	case TC_ENUM:
+		return &dynEnum{d}
+	case TC_FIXED:
+		return &dynFixed{d}
+	}
+	return d
+}
+
+// nodeOf returns the value of a DynAny created by a DynAnyFactory
+func nodeOf(dyn DynAny) (*dynAny, bool) {
+	n, ok := dyn.(dynAnyNode)
+	if !ok || n.node() == nil {
+		return nil, false
+	}
+	return n.node(), true
+}
+
+// sameType reports whether two TypeCodes describe the same type
+func sameType(a, b TypeCode) bool {
+	return a != nil && b != nil && equivalentTypeCodes(a, b)
+}
+
+// Type returns the TypeCode of the value
+func (d *dynAny) Type() TypeCode {
+	return d.tc
+}
+
+// Assign copies the value of a DynAny of the same type
+func (d *dynAny) Assign(dyn DynAny) error {
+	other, ok := nodeOf(dyn)
+	if !ok || !sameType(d.tc, other.tc) {
+		return ErrDynAnyTypeMismatch
+	}
+	d.setContent(other.copyNode())
+	return nil
+}
+
+// FromAny sets the value from an Any of the same type
+func (d *dynAny) FromAny(value *Any) error {
+	if value == nil {
+		return ErrDynAnyInvalidValue
+	}
+	if !sameType(d.tc, value.TypeCode()) {
+		return ErrDynAnyTypeMismatch
+	}
+
+	fresh, err := newDynAny(d.tc)
+	if err != nil {
+		return err
+	}
+	if err := fresh.setFrom(value.Value()); err != nil {
+		return err
+	}
+	d.setContent(fresh)
+	return nil
+}
+
+// ToAny returns the value as an Any
+func (d *dynAny) ToAny() *Any {
+	return &Any{typeCode: d.tc, value: d.toValue()}
+}
+
+// Equal reports whether a DynAny has the same type and value
+func (d *dynAny) Equal(dyn DynAny) bool {
+	other, ok := nodeOf(dyn)
+	if !ok || !sameType(d.tc, other.tc) {
+		return false
+	}
+	return reflect.DeepEqual(d.toValue(), other.toValue())
+}
+
+// Copy returns a deep copy of the value
+func (d *dynAny) Copy() DynAny {
+	return d.copyNode().wrap()
+}
+
+// copyNode returns a deep copy of the value
+func (d *dynAny) copyNode() *dynAny {
+	c := *d
+	if r, ok := d.value.(*big.Rat); ok {
+		c.value = new(big.Rat).Set(r)
+	}
+	if d.components != nil {
+		c.components = make([]*dynAny, len(d.components))
+		for i, component := range d.components {
+			c.components[i] = component.copyNode()
+		}
+	}
+	return &c
+}
+
+// setContent replaces the value with that of another DynAny of the same type
+// and makes the first component current
+func (d *dynAny) setContent(other *dynAny) {
+	d.value = other.value
+	d.components = other.components
+	d.member = other.member
+	d.current = d.firstComponent()
+}
+
+// firstComponent returns the index of the first component, or -1 if there are none
+func (d *dynAny) firstComponent() int {
+	if len(d.components) == 0 {
+		return -1
+	}
+	return 0
+}
+
+// constructed reports whether the value is made of components
+func (d *dynAny) constructed() bool {
+	switch d.kind {
+	case TC_STRUCT, TC_EXCEPT, TC_UNION, TC_SEQUENCE, TC_ARRAY:
+		return true
+	}
+	return false
+}
+
+// toValue returns the value in the representation held by an Any
+func (d *dynAny) toValue() interface{} {
+	switch {
+	case isBasicKind(d.kind), d.kind == TC_ANY, d.kind == TC_ENUM:
+		return d.value
+	}
+	return d.constructedValue()
+}
+
+// setFrom sets a default-initialized value from a Go value. A nil value
+// leaves the default.
+func (d *dynAny) setFrom(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch {
+	case isBasicKind(d.kind):
+		v, ok := convertBasic(d.kind, value)
+		if !ok {
+			return ErrDynAnyInvalidValue
+		}
+		d.value = v
+		return nil
+	case d.kind == TC_ANY:
+		a, ok := value.(*Any)
+		if !ok || a == nil {
+			return ErrDynAnyInvalidValue
+		}
+		d.value = a
+		return nil
+	}
+	return d.setConstructedFrom(value)
+}
+
+// Seek makes the component at index current
+func (d *dynAny) Seek(index int) bool {
+	d.refresh()
+	if index < 0 || index >= len(d.components) {
+		d.current = -1
+		return false
+	}
+	d.current = index
+	return true
+}
+
+// Rewind makes the first component current
+func (d *dynAny) Rewind() {
+	d.Seek(0)
+}
+
+// Next makes the next component current
+func (d *dynAny) Next() bool {
+	d.refresh()
+	if d.current+1 >= len(d.components) {
+		d.current = -1
+		return false
+	}
+	d.current++
+	return true
+}
+
+// ComponentCount returns the number of components
+func (d *dynAny) ComponentCount() int {
+	d.refresh()
+	return len(d.components)
+}
+
+// CurrentComponent returns the current component, or nil if there is none
+func (d *dynAny) CurrentComponent() (DynAny, error) {
+	if !d.constructed() {
+		return nil, ErrDynAnyTypeMismatch
+	}
+	d.refresh()
+	if d.current < 0 {
+		return nil, nil
+	}
+	return d.components[d.current].wrap(), nil
+}
+
+// target returns the value that Insert and Get operations act on: the value
+// itself, or its current component
+func (d *dynAny) target() (*dynAny, error) {
+	if !d.constructed() {
+		return d, nil
+	}
+	d.refresh()
+	if d.current < 0 {
+		return nil, ErrDynAnyInvalidValue
+	}
+	return d.components[d.current], nil
+}
+
+// insert sets a value of a basic type
+func (d *dynAny) insert(kind TCKind, value interface{}) error {
+	t, err := d.target()
+	if err != nil {
+		return err
+	}
+	if t.kind != kind {
+		return ErrDynAnyTypeMismatch
+	}
+	t.value = value
+
+	// Setting the discriminator of a union may change its member
+	d.refresh()
+	return nil
+}
+
+// get returns a value of a basic type
+func (d *dynAny) get(kind TCKind) (interface{}, error) {
+	t, err := d.target()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != kind {
+		return nil, ErrDynAnyTypeMismatch
+	}
+	return t.value, nil
+}
+
+// InsertBoolean sets a boolean value
+func (d *dynAny) InsertBoolean(value bool) error { return d.insert(TC_BOOLEAN, value) }
+
+// InsertOctet sets an octet value
+func (d *dynAny) InsertOctet(value byte) error { return d.insert(TC_OCTET, value) }
+
+// InsertChar sets a char value
+func (d *dynAny) InsertChar(value byte) error { return d.insert(TC_CHAR, value) }
+
+// InsertWChar sets a wchar value
+func (d *dynAny) InsertWChar(value rune) error { return d.insert(TC_WCHAR, value) }
+
+// InsertShort sets a short value
+func (d *dynAny) InsertShort(value int16) error { return d.insert(TC_SHORT, value) }
+
+// InsertUShort sets an unsigned short value
+func (d *dynAny) InsertUShort(value uint16) error { return d.insert(TC_USHORT, value) }
+
+// InsertLong sets a long value
+func (d *dynAny) InsertLong(value int32) error { return d.insert(TC_LONG, value) }
+
+// InsertULong sets an unsigned long value
+func (d *dynAny) InsertULong(value uint32) error { return d.insert(TC_ULONG, value) }
+
+// InsertLongLong sets a long long value
+func (d *dynAny) InsertLongLong(value int64) error { return d.insert(TC_LONGLONG, value) }
+
+// InsertULongLong sets an unsigned long long value
+func (d *dynAny) InsertULongLong(value uint64) error { return d.insert(TC_ULONGLONG, value) }
+
+// InsertFloat sets a float value
+func (d *dynAny) InsertFloat(value float32) error { return d.insert(TC_FLOAT, value) }
+
+// InsertDouble sets a double value
+func (d *dynAny) InsertDouble(value float64) error { return d.insert(TC_DOUBLE, value) }
+
+// InsertString sets a string value
+func (d *dynAny) InsertString(value string) error { return d.insert(TC_STRING, value) }
+
+// InsertWString sets a wstring value
+func (d *dynAny) InsertWString(value string) error { return d.insert(TC_WSTRING, value) }
+
+// InsertAny sets an any value
+func (d *dynAny) InsertAny(value *Any) error {
+	if value == nil {
+		return ErrDynAnyInvalidValue
+	}
+	return d.insert(TC_ANY, value)
+}
+
+// InsertDynAny sets an any value from a DynAny
+func (d *dynAny) InsertDynAny(value DynAny) error {
+	if value == nil {
+		return ErrDynAnyInvalidValue
+	}
+	return d.InsertAny(value.ToAny())
+}
+
+// GetBoolean returns a boolean value
+func (d *dynAny) GetBoolean() (bool, error) {
+	v, err := d.get(TC_BOOLEAN)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// GetOctet returns an octet value
+func (d *dynAny) GetOctet() (byte, error) {
+	v, err := d.get(TC_OCTET)
+	if err != nil {
+		return 0, err
+	}
+	return v.(byte), nil
+}
+
+// GetChar returns a char value
+func (d *dynAny) GetChar() (byte, error) {
+	v, err := d.get(TC_CHAR)
+	if err != nil {
+		return 0, err
+	}
+	return v.(byte), nil
+}
+
+// GetWChar returns a wchar value
+func (d *dynAny) GetWChar() (rune, error) {
+	v, err := d.get(TC_WCHAR)
+	if err != nil {
+		return 0, err
+	}
+	return v.(rune), nil
+}
+
+// GetShort returns a short value
+func (d *dynAny) GetShort() (int16, error) {
+	v, err := d.get(TC_SHORT)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int16), nil
+}
+
+// GetUShort returns an unsigned short value
+func (d *dynAny) GetUShort() (uint16, error) {
+	v, err := d.get(TC_USHORT)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint16), nil
+}
+
+// GetLong returns a long value
+func (d *dynAny) GetLong() (int32, error) {
+	v, err := d.get(TC_LONG)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int32), nil
+}
+
+// GetULong returns an unsigned long value
+func (d *dynAny) GetULong() (uint32, error) {
+	v, err := d.get(TC_ULONG)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint32), nil
+}
+
+// GetLongLong returns a long long value
+func (d *dynAny) GetLongLong() (int64, error) {
+	v, err := d.get(TC_LONGLONG)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// GetULongLong returns an unsigned long long value
+func (d *dynAny) GetULongLong() (uint64, error) {
+	v, err := d.get(TC_ULONGLONG)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint64), nil
+}
+
+// GetFloat returns a float value
+func (d *dynAny) GetFloat() (float32, error) {
+	v, err := d.get(TC_FLOAT)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float32), nil
+}
+
+// GetDouble returns a double value
+func (d *dynAny) GetDouble() (float64, error) {
+	v, err := d.get(TC_DOUBLE)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+// GetString returns a string value
+func (d *dynAny) GetString() (string, error) {
+	v, err := d.get(TC_STRING)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// GetWString returns a wstring value
+func (d *dynAny) GetWString() (string, error) {
+	v, err := d.get(TC_WSTRING)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// GetAny returns an any value
+func (d *dynAny) GetAny() (*Any, error) {
+	v, err := d.get(TC_ANY)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Any), nil
+}
+
+// GetDynAny returns an any value as a DynAny
+func (d *dynAny) GetDynAny() (DynAny, error) {
+	value, err := d.GetAny()
+	if err != nil {
+		return nil, err
+	}
+	return (&DynAnyFactory{}).CreateDynAny(value)
+}
+
+// isBasicKind reports whether values of a kind are held by a DynAny without
+// components, as a Go value of the type returned by zeroValue
+func isBasicKind(kind TCKind) bool {
+	switch kind {
+	case TC_BOOLEAN, TC_OCTET, TC_CHAR, TC_WCHAR, TC_SHORT, TC_USHORT, TC_LONG, TC_ULONG,
+		TC_LONGLONG, TC_ULONGLONG, TC_FLOAT, TC_DOUBLE, TC_STRING, TC_WSTRING:
+		return true
+	}
+	return false
+}
+
+// zeroValue returns the zero value of a basic kind
+func zeroValue(kind TCKind) interface{} {
+	switch kind {
+	case TC_BOOLEAN:
+		return false
+	case TC_STRING, TC_WSTRING:
+		return ""
+	}
+	v, _ := convertBasic(kind, 0)
+	return v
+}
+
+// convertBasic converts a Go value to the Go type of a basic kind. Numbers
+// must fit the type.
+func convertBasic(kind TCKind, value interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(value)
+
+	switch kind {
+	case TC_BOOLEAN:
+		if rv.Kind() != reflect.Bool {
+			return nil, false
+		}
+		return rv.Bool(), true
+	case TC_STRING, TC_WSTRING:
+		if rv.Kind() != reflect.String {
+			return nil, false
+		}
+		return rv.String(), true
+	case TC_FLOAT, TC_DOUBLE:
+		f, err := floatValue(rv)
+		if err != nil {
+			return nil, false
+		}
+		if kind == TC_FLOAT {
+			return float32(f), true
+		}
+		return f, true
+	case TC_ULONGLONG:
+		if rv.CanUint() {
+			return rv.Uint(), true
+		}
+	}
+
+	n, err := intValue(rv)
+	if err != nil {
+		return nil, false
+	}
+	var v interface{}
+	switch kind {
+	case TC_OCTET, TC_CHAR:
+		v = byte(n)
+	case TC_WCHAR:
+		v = rune(n)
+	case TC_SHORT:
+		v = int16(n)
+	case TC_USHORT:
+		v = uint16(n)
+	case TC_LONG:
+		v = int32(n)
+	case TC_ULONG:
+		v = uint32(n)
+	case TC_LONGLONG:
+		v = n
+	case TC_ULONGLONG:
+		if n < 0 {
+			return nil, false
+		}
+		return uint64(n), true
+	default:
+		return nil, false
+	}
+
+	// The value must survive the conversion
+	back, _ := intValue(reflect.ValueOf(v))
+	return v, back == n
+}
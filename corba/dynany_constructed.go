@@ -0,0 +1,873 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// NameValuePair is a member of a struct or exception, as an Any
+type NameValuePair struct {
+	Name  string
+	Value *Any
+}
+
+// NameDynAnyPair is a member of a struct or exception, as a DynAny
+type NameDynAnyPair struct {
+	Name  string
+	Value DynAny
+}
+
+// UnionValue is the value of a union held by an Any. Value is nil when the
+// discriminator selects no member. Generated union types, which have the same
+// fields, are accepted wherever a UnionValue is.
+type UnionValue struct {
+	Discriminant interface{}
+	Value        interface{}
+}
+
+// DynStruct is a DynAny holding a struct or exception. Its components are
+// its members.
+type DynStruct interface {
+	DynAny
+
+	// CurrentMemberName returns the name of the current member
+	CurrentMemberName() (string, error)
+
+	// CurrentMemberKind returns the kind of the current member
+	CurrentMemberKind() (TCKind, error)
+
+	// GetMembers returns the members as Any values
+	GetMembers() []NameValuePair
+
+	// SetMembers sets all members. Names, if given, must match the member
+	// names in order.
+	SetMembers(members []NameValuePair) error
+
+	// GetMembersAsDynAny returns the members as DynAny values
+	GetMembersAsDynAny() []NameDynAnyPair
+
+	// SetMembersAsDynAny sets all members from DynAny values
+	SetMembersAsDynAny(members []NameDynAnyPair) error
+}
+
+// DynUnion is a DynAny holding a union. Its components are the discriminator
+// and, if the discriminator selects one, the active member.
+type DynUnion interface {
+	DynAny
+
+	// GetDiscriminator returns the discriminator. Changing its value selects
+	// the member it denotes.
+	GetDiscriminator() DynAny
+
+	// SetDiscriminator sets the discriminator and selects the member it denotes
+	SetDiscriminator(d DynAny) error
+
+	// SetToDefaultMember selects the default member
+	SetToDefaultMember() error
+
+	// SetToNoActiveMember sets a discriminator that selects no member
+	SetToNoActiveMember() error
+
+	// HasNoActiveMember reports whether the discriminator selects no member
+	HasNoActiveMember() bool
+
+	// DiscriminatorKind returns the kind of the discriminator
+	DiscriminatorKind() TCKind
+
+	// Member returns the active member
+	Member() (DynAny, error)
+
+	// MemberName returns the name of the active member
+	MemberName() (string, error)
+
+	// MemberKind returns the kind of the active member
+	MemberKind() (TCKind, error)
+}
+
+// DynSequence is a DynAny holding a sequence. Its components are its elements.
+type DynSequence interface {
+	DynAny
+
+	// GetLength returns the number of elements
+	GetLength() int
+
+	// SetLength adds default elements to the end of the sequence or removes
+	// elements from it
+	SetLength(length int) error
+
+	// GetElements returns the elements as Any values
+	GetElements() []*Any
+
+	// SetElements replaces the elements
+	SetElements(elements []*Any) error
+
+	// GetElementsAsDynAny returns the elements as DynAny values
+	GetElementsAsDynAny() []DynAny
+
+	// SetElementsAsDynAny replaces the elements with DynAny values
+	SetElementsAsDynAny(elements []DynAny) error
+}
+
+// DynArray is a DynAny holding an array. Its components are its elements.
+type DynArray interface {
+	DynAny
+
+	// GetElements returns the elements as Any values
+	GetElements() []*Any
+
+	// SetElements replaces the elements. There must be as many as the
+	// length of the array.
+	SetElements(elements []*Any) error
+
+	// GetElementsAsDynAny returns the elements as DynAny values
+	GetElementsAsDynAny() []DynAny
+
+	// SetElementsAsDynAny replaces the elements with DynAny values
+	SetElementsAsDynAny(elements []DynAny) error
+}
+
+// DynEnum is a DynAny holding an enum. It has no components.
+type DynEnum interface {
+	DynAny
+
+	// GetAsString returns the name of the enumerator
+	GetAsString() string
+
+	// SetAsString sets the enumerator by name
+	SetAsString(name string) error
+
+	// GetAsULong returns the ordinal of the enumerator
+	GetAsULong() uint32
+
+	// SetAsULong sets the enumerator by ordinal
+	SetAsULong(ordinal uint32) error
+}
+
+// DynFixed is a DynAny holding a fixed point decimal. It has no components.
+type DynFixed interface {
+	DynAny
+
+	// GetValue returns the value as a decimal string
+	GetValue() string
+
+	// SetValue sets the value from a decimal string. Digits beyond the
+	// scale of the type are truncated, which SetValue reports.
+	SetValue(value string) (bool, error)
+}
+
+// fixedTypeInfo is implemented by the TypeCodes of fixed point decimals
+type fixedTypeInfo interface {
+	FixedDigits() uint16
+	FixedScale() uint16
+}
+
+// initConstructed sets the default value of a constructed type, an enum or a
+// fixed point decimal
+func (d *dynAny) initConstructed() error {
+	switch d.kind {
+	case TC_STRUCT, TC_EXCEPT:
+		for i := 0; i < d.impl.MemberCount(); i++ {
+			memberType, err := d.impl.MemberType(i)
+			if err != nil {
+				return ErrInconsistentTypeCode
+			}
+			member, err := newDynAny(memberType)
+			if err != nil {
+				return err
+			}
+			d.components = append(d.components, member)
+		}
+
+	case TC_SEQUENCE, TC_ARRAY:
+		elementType, err := d.impl.ContentType()
+		if err != nil {
+			return ErrInconsistentTypeCode
+		}
+		// Elements are created as needed, which lets recursive types
+		// contain empty sequences of themselves
+		if _, err := tcKindOf(elementType); err != nil {
+			return ErrInconsistentTypeCode
+		}
+		if d.kind == TC_ARRAY {
+			return d.resize(d.impl.Length())
+		}
+
+	case TC_UNION:
+		discriminatorType, err := d.impl.DiscriminatorType()
+		if err != nil {
+			return ErrInconsistentTypeCode
+		}
+		discriminator, err := newDynAny(discriminatorType)
+		if err != nil {
+			return err
+		}
+		if !isBasicKind(discriminator.kind) && discriminator.kind != TC_ENUM {
+			return ErrInconsistentTypeCode
+		}
+		d.components = []*dynAny{discriminator}
+
+		// The first member is active
+		if d.impl.MemberCount() > 0 {
+			if d.impl.DefaultIndex() == 0 {
+				return (&dynUnion{d}).SetToDefaultMember()
+			}
+			label, err := d.impl.MemberLabel(0)
+			if err != nil {
+				return ErrInconsistentTypeCode
+			}
+			value, ok := d.labelValue(label)
+			if !ok {
+				return ErrInconsistentTypeCode
+			}
+			discriminator.value = value
+			return d.selectMember()
+		}
+
+	case TC_ENUM:
+		if d.impl.MemberCount() == 0 {
+			return ErrInconsistentTypeCode
+		}
+		d.value = uint32(0)
+
+	case TC_FIXED:
+		if _, ok := d.impl.(fixedTypeInfo); !ok {
+			return ErrInconsistentTypeCode
+		}
+		d.value = new(big.Rat)
+
+	default:
+		return ErrInconsistentTypeCode
+	}
+	return nil
+}
+
+// constructedValue returns the value of a constructed type, an enum or a
+// fixed point decimal in the representation held by an Any
+func (d *dynAny) constructedValue() interface{} {
+	switch d.kind {
+	case TC_STRUCT, TC_EXCEPT:
+		members := make(map[string]interface{}, len(d.components))
+		for i, member := range d.components {
+			name, _ := d.impl.MemberName(i)
+			members[name] = member.toValue()
+		}
+		return members
+
+	case TC_SEQUENCE, TC_ARRAY:
+		if elementType, _ := d.impl.ContentType(); tcKindIs(elementType, TC_OCTET) {
+			octets := make([]byte, len(d.components))
+			for i, element := range d.components {
+				octets[i] = element.value.(byte)
+			}
+			return octets
+		}
+		elements := make([]interface{}, len(d.components))
+		for i, element := range d.components {
+			elements[i] = element.toValue()
+		}
+		return elements
+
+	case TC_UNION:
+		d.refresh()
+		value := UnionValue{Discriminant: d.components[0].toValue()}
+		if d.member >= 0 {
+			value.Value = d.components[1].toValue()
+		}
+		return value
+
+	case TC_FIXED:
+		return d.fixedString()
+	}
+	return nil
+}
+
+// setConstructedFrom sets a default-initialized value of a constructed type,
+// an enum or a fixed point decimal from a Go value
+func (d *dynAny) setConstructedFrom(value interface{}) error {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		if _, ok := value.(interface {
+			GetMember(string) (interface{}, bool)
+		}); ok {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	switch d.kind {
+	case TC_STRUCT, TC_EXCEPT:
+		switch rv.Kind() {
+		case reflect.Map, reflect.Struct, reflect.Pointer:
+		default:
+			return ErrDynAnyInvalidValue
+		}
+		for i, member := range d.components {
+			name, _ := d.impl.MemberName(i)
+			if err := member.setFrom(memberValue(value, name)); err != nil {
+				return err
+			}
+		}
+
+	case TC_SEQUENCE, TC_ARRAY:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return ErrDynAnyInvalidValue
+		}
+		if d.kind == TC_ARRAY && rv.Len() != d.impl.Length() {
+			return ErrDynAnyInvalidValue
+		}
+		if err := d.resize(rv.Len()); err != nil {
+			return err
+		}
+		for i, element := range d.components {
+			if err := element.setFrom(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+
+	case TC_UNION:
+		if rv.Kind() != reflect.Struct {
+			return ErrDynAnyInvalidValue
+		}
+		discriminant, member := rv.FieldByName("Discriminant"), rv.FieldByName("Value")
+		if !discriminant.IsValid() || !member.IsValid() {
+			return ErrDynAnyInvalidValue
+		}
+		if err := d.components[0].setFrom(discriminant.Interface()); err != nil {
+			return err
+		}
+		if err := d.selectMember(); err != nil {
+			return err
+		}
+		if d.member >= 0 {
+			return d.components[1].setFrom(member.Interface())
+		}
+
+	case TC_ENUM:
+		if rv.Kind() == reflect.String {
+			return (&dynEnum{d}).SetAsString(rv.String())
+		}
+		ordinal, ok := convertBasic(TC_ULONG, rv.Interface())
+		if !ok {
+			return ErrDynAnyInvalidValue
+		}
+		return (&dynEnum{d}).SetAsULong(ordinal.(uint32))
+
+	case TC_FIXED:
+		r, ok := decimalValue(rv)
+		if !ok {
+			return ErrDynAnyInvalidValue
+		}
+		_, err := d.setFixed(r)
+		return err
+	}
+	return nil
+}
+
+// resize sets the number of elements of a sequence or array, adding default
+// elements at the end or removing them from there
+func (d *dynAny) resize(length int) error {
+	if length < len(d.components) {
+		d.components = d.components[:length]
+		return nil
+	}
+
+	elementType, err := d.impl.ContentType()
+	if err != nil {
+		return ErrInconsistentTypeCode
+	}
+	for len(d.components) < length {
+		element, err := newDynAny(elementType)
+		if err != nil {
+			return err
+		}
+		d.components = append(d.components, element)
+	}
+	return nil
+}
+
+// tcKindIs reports whether a TypeCode is of a kind
+func tcKindIs(tc TypeCode, kind TCKind) bool {
+	k, err := tcKindOf(tc)
+	return err == nil && k == kind
+}
+
+// dynStruct is a DynAny holding a struct or exception
+type dynStruct struct {
+	*dynAny
+}
+
+// CurrentMemberName returns the name of the current member
+func (s *dynStruct) CurrentMemberName() (string, error) {
+	if s.current < 0 {
+		return "", ErrDynAnyInvalidValue
+	}
+	return s.impl.MemberName(s.current)
+}
+
+// CurrentMemberKind returns the kind of the current member
+func (s *dynStruct) CurrentMemberKind() (TCKind, error) {
+	if s.current < 0 {
+		return TC_NULL, ErrDynAnyInvalidValue
+	}
+	return s.components[s.current].kind, nil
+}
+
+// GetMembers returns the members as Any values
+func (s *dynStruct) GetMembers() []NameValuePair {
+	members := make([]NameValuePair, len(s.components))
+	for i, member := range s.components {
+		name, _ := s.impl.MemberName(i)
+		members[i] = NameValuePair{Name: name, Value: member.ToAny()}
+	}
+	return members
+}
+
+// SetMembers sets all members
+func (s *dynStruct) SetMembers(members []NameValuePair) error {
+	values := make([]*dynAny, len(members))
+	for i, member := range members {
+		if member.Value == nil {
+			return ErrDynAnyInvalidValue
+		}
+		value, err := newDynAny(member.Value.TypeCode())
+		if err != nil {
+			return ErrDynAnyTypeMismatch
+		}
+		if err := value.setFrom(member.Value.Value()); err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	return s.setMembers(func(i int) string { return members[i].Name }, values)
+}
+
+// GetMembersAsDynAny returns the members as DynAny values
+func (s *dynStruct) GetMembersAsDynAny() []NameDynAnyPair {
+	members := make([]NameDynAnyPair, len(s.components))
+	for i, member := range s.components {
+		name, _ := s.impl.MemberName(i)
+		members[i] = NameDynAnyPair{Name: name, Value: member.wrap()}
+	}
+	return members
+}
+
+// SetMembersAsDynAny sets all members from DynAny values
+func (s *dynStruct) SetMembersAsDynAny(members []NameDynAnyPair) error {
+	values := make([]*dynAny, len(members))
+	for i, member := range members {
+		value, ok := nodeOf(member.Value)
+		if !ok {
+			return ErrDynAnyInvalidValue
+		}
+		values[i] = value.copyNode()
+	}
+	return s.setMembers(func(i int) string { return members[i].Name }, values)
+}
+
+// setMembers replaces the members after checking their names and types
+func (s *dynStruct) setMembers(name func(int) string, values []*dynAny) error {
+	if len(values) != len(s.components) {
+		return ErrDynAnyInvalidValue
+	}
+	for i, value := range values {
+		memberName, _ := s.impl.MemberName(i)
+		if n := name(i); n != "" && n != memberName {
+			return ErrDynAnyTypeMismatch
+		}
+		if !sameType(value.tc, s.components[i].tc) {
+			return ErrDynAnyTypeMismatch
+		}
+	}
+	s.components = values
+	s.current = s.firstComponent()
+	return nil
+}
+
+// dynUnion is a DynAny holding a union
+type dynUnion struct {
+	*dynAny
+}
+
+// refresh selects the member denoted by the discriminator of a union, which
+// may have been changed through its DynAny
+func (d *dynAny) refresh() {
+	if d.kind != TC_UNION {
+		return
+	}
+	d.selectMember()
+	if d.current >= len(d.components) {
+		d.current = -1
+	}
+}
+
+// selectMember makes the member denoted by the discriminator active. A newly
+// selected member has its default value.
+func (d *dynAny) selectMember() error {
+	member := d.memberFor(d.components[0].value)
+	if member == d.member && len(d.components) == 1+min(member+1, 1) {
+		return nil
+	}
+
+	d.member = member
+	d.components = d.components[:1]
+	if member < 0 {
+		return nil
+	}
+	memberType, err := d.impl.MemberType(member)
+	if err != nil {
+		return ErrInconsistentTypeCode
+	}
+	value, err := newDynAny(memberType)
+	if err != nil {
+		return err
+	}
+	d.components = append(d.components, value)
+	return nil
+}
+
+// memberFor returns the index of the member a discriminator value denotes:
+// the member with a matching label, or the default member. It returns -1 if
+// there is none.
+func (d *dynAny) memberFor(discriminator interface{}) int {
+	defaultIndex := d.impl.DefaultIndex()
+	for i := 0; i < d.impl.MemberCount(); i++ {
+		if i == defaultIndex {
+			continue
+		}
+		label, err := d.impl.MemberLabel(i)
+		if err != nil {
+			continue
+		}
+		if value, ok := d.labelValue(label); ok && value == discriminator {
+			return i
+		}
+	}
+	return defaultIndex
+}
+
+// labelValue converts a union label to a discriminator value. Labels of enum
+// discriminators may be enumerator names.
+func (d *dynAny) labelValue(label interface{}) (interface{}, bool) {
+	discriminator := d.components[0]
+	if discriminator.kind == TC_ENUM {
+		if name, ok := label.(string); ok {
+			for i := 0; i < discriminator.impl.MemberCount(); i++ {
+				if member, _ := discriminator.impl.MemberName(i); member == name {
+					return uint32(i), true
+				}
+			}
+			return nil, false
+		}
+		return convertBasic(TC_ULONG, label)
+	}
+	return convertBasic(discriminator.kind, label)
+}
+
+// unusedDiscriminator returns a discriminator value that matches no label
+func (d *dynAny) unusedDiscriminator() (interface{}, bool) {
+	discriminator := d.components[0]
+	candidates := d.impl.MemberCount() + 1
+
+	for i := 0; i <= candidates; i++ {
+		var value interface{}
+		var ok bool
+		switch discriminator.kind {
+		case TC_BOOLEAN:
+			value, ok = i == 1, i < 2
+		case TC_ENUM:
+			value, ok = uint32(i), i < discriminator.impl.MemberCount()
+		default:
+			value, ok = convertBasic(discriminator.kind, i)
+		}
+		if !ok {
+			return nil, false
+		}
+
+		used := false
+		for j := 0; j < d.impl.MemberCount() && !used; j++ {
+			if j == d.impl.DefaultIndex() {
+				continue
+			}
+			label, _ := d.impl.MemberLabel(j)
+			labelValue, ok := d.labelValue(label)
+			used = ok && labelValue == value
+		}
+		if !used {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// GetDiscriminator returns the discriminator
+func (u *dynUnion) GetDiscriminator() DynAny {
+	return u.components[0].wrap()
+}
+
+// SetDiscriminator sets the discriminator and selects the member it denotes
+func (u *dynUnion) SetDiscriminator(d DynAny) error {
+	discriminator, ok := nodeOf(d)
+	if !ok || !sameType(discriminator.tc, u.components[0].tc) {
+		return ErrDynAnyTypeMismatch
+	}
+	u.components[0].value = discriminator.value
+	if err := u.selectMember(); err != nil {
+		return err
+	}
+	u.current = len(u.components) - 1
+	return nil
+}
+
+// SetToDefaultMember selects the default member
+func (u *dynUnion) SetToDefaultMember() error {
+	if u.impl.DefaultIndex() < 0 {
+		return ErrDynAnyTypeMismatch
+	}
+	value, ok := u.unusedDiscriminator()
+	if !ok {
+		return ErrDynAnyTypeMismatch
+	}
+	u.components[0].value = value
+	u.current = 0
+	return u.selectMember()
+}
+
+// SetToNoActiveMember sets a discriminator that selects no member
+func (u *dynUnion) SetToNoActiveMember() error {
+	if u.impl.DefaultIndex() >= 0 {
+		return ErrDynAnyTypeMismatch
+	}
+	value, ok := u.unusedDiscriminator()
+	if !ok {
+		return ErrDynAnyTypeMismatch
+	}
+	u.components[0].value = value
+	u.current = 0
+	return u.selectMember()
+}
+
+// HasNoActiveMember reports whether the discriminator selects no member
+func (u *dynUnion) HasNoActiveMember() bool {
+	u.refresh()
+	return u.member < 0
+}
+
+// DiscriminatorKind returns the kind of the discriminator
+func (u *dynUnion) DiscriminatorKind() TCKind {
+	return u.components[0].kind
+}
+
+// Member returns the active member
+func (u *dynUnion) Member() (DynAny, error) {
+	if u.HasNoActiveMember() {
+		return nil, ErrDynAnyInvalidValue
+	}
+	return u.components[1].wrap(), nil
+}
+
+// MemberName returns the name of the active member
+func (u *dynUnion) MemberName() (string, error) {
+	if u.HasNoActiveMember() {
+		return "", ErrDynAnyInvalidValue
+	}
+	return u.impl.MemberName(u.member)
+}
+
+// MemberKind returns the kind of the active member
+func (u *dynUnion) MemberKind() (TCKind, error) {
+	if u.HasNoActiveMember() {
+		return TC_NULL, ErrDynAnyInvalidValue
+	}
+	return u.components[1].kind, nil
+}
+
+// dynElements implements the operations shared by sequences and arrays
+type dynElements struct {
+	*dynAny
+}
+
+// GetElements returns the elements as Any values
+func (e *dynElements) GetElements() []*Any {
+	elements := make([]*Any, len(e.components))
+	for i, element := range e.components {
+		elements[i] = element.ToAny()
+	}
+	return elements
+}
+
+// SetElements replaces the elements
+func (e *dynElements) SetElements(elements []*Any) error {
+	values := make([]*dynAny, len(elements))
+	for i, element := range elements {
+		if element == nil {
+			return ErrDynAnyInvalidValue
+		}
+		value, err := newDynAny(element.TypeCode())
+		if err != nil {
+			return ErrDynAnyTypeMismatch
+		}
+		if err := value.setFrom(element.Value()); err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	return e.setElements(values)
+}
+
+// GetElementsAsDynAny returns the elements as DynAny values
+func (e *dynElements) GetElementsAsDynAny() []DynAny {
+	elements := make([]DynAny, len(e.components))
+	for i, element := range e.components {
+		elements[i] = element.wrap()
+	}
+	return elements
+}
+
+// SetElementsAsDynAny replaces the elements with DynAny values
+func (e *dynElements) SetElementsAsDynAny(elements []DynAny) error {
+	values := make([]*dynAny, len(elements))
+	for i, element := range elements {
+		value, ok := nodeOf(element)
+		if !ok {
+			return ErrDynAnyInvalidValue
+		}
+		values[i] = value.copyNode()
+	}
+	return e.setElements(values)
+}
+
+// setElements replaces the elements after checking their number and type
+func (e *dynElements) setElements(values []*dynAny) error {
+	if bound := e.impl.Length(); bound > 0 && len(values) > bound ||
+		e.kind == TC_ARRAY && len(values) != bound {
+		return ErrDynAnyInvalidValue
+	}
+	elementType, _ := e.impl.ContentType()
+	for _, value := range values {
+		if !sameType(value.tc, elementType) {
+			return ErrDynAnyTypeMismatch
+		}
+	}
+	e.components = values
+	e.current = e.firstComponent()
+	return nil
+}
+
+// dynSequence is a DynAny holding a sequence
+type dynSequence struct {
+	dynElements
+}
+
+// GetLength returns the number of elements
+func (s *dynSequence) GetLength() int {
+	return len(s.components)
+}
+
+// SetLength adds default elements to the end of the sequence or removes
+// elements from it. The first added element becomes current if there was no
+// current element; removing the current element leaves none.
+func (s *dynSequence) SetLength(length int) error {
+	if length < 0 || s.impl.Length() > 0 && length > s.impl.Length() {
+		return ErrDynAnyInvalidValue
+	}
+
+	previous := len(s.components)
+	if err := s.resize(length); err != nil {
+		return err
+	}
+	if length > previous && s.current < 0 {
+		s.current = previous
+	} else if s.current >= length {
+		s.current = -1
+	}
+	return nil
+}
+
+// dynArray is a DynAny holding an array
+type dynArray struct {
+	dynElements
+}
+
+// dynEnum is a DynAny holding an enum
+type dynEnum struct {
+	*dynAny
+}
+
+// GetAsString returns the name of the enumerator
+func (e *dynEnum) GetAsString() string {
+	name, _ := e.impl.MemberName(int(e.value.(uint32)))
+	return name
+}
+
+// SetAsString sets the enumerator by name
+func (e *dynEnum) SetAsString(name string) error {
+	for i := 0; i < e.impl.MemberCount(); i++ {
+		if member, _ := e.impl.MemberName(i); member == name {
+			e.value = uint32(i)
+			return nil
+		}
+	}
+	return ErrDynAnyInvalidValue
+}
+
+// GetAsULong returns the ordinal of the enumerator
+func (e *dynEnum) GetAsULong() uint32 {
+	return e.value.(uint32)
+}
+
+// SetAsULong sets the enumerator by ordinal
+func (e *dynEnum) SetAsULong(ordinal uint32) error {
+	if int(ordinal) >= e.impl.MemberCount() {
+		return ErrDynAnyInvalidValue
+	}
+	e.value = ordinal
+	return nil
+}
+
+// dynFixed is a DynAny holding a fixed point decimal
+type dynFixed struct {
+	*dynAny
+}
+
+// GetValue returns the value as a decimal string
+func (f *dynFixed) GetValue() string {
+	return f.fixedString()
+}
+
+// SetValue sets the value from a decimal string, with an optional d or D suffix
+func (f *dynFixed) SetValue(value string) (bool, error) {
+	r, ok := decimalValue(reflect.ValueOf(strings.TrimSpace(value)))
+	if !ok {
+		return false, ErrDynAnyTypeMismatch
+	}
+	return f.setFixed(r)
+}
+
+// setFixed sets a fixed point decimal, truncating digits beyond the scale of
+// its type. Values with more integer digits than the type allows are invalid.
+func (d *dynAny) setFixed(r *big.Rat) (bool, error) {
+	info := d.impl.(fixedTypeInfo)
+	units, truncated, ok := fixedUnits(r, info.FixedDigits(), info.FixedScale())
+	if !ok {
+		return false, ErrDynAnyInvalidValue
+	}
+
+	d.value = new(big.Rat).SetFrac(units, fixedUnit(info.FixedScale()))
+	return truncated, nil
+}
+
+// fixedString formats a fixed point decimal with the scale of its type
+func (d *dynAny) fixedString() string {
+	return d.value.(*big.Rat).FloatString(int(d.impl.(fixedTypeInfo).FixedScale()))
+}
+
+// String returns the value of the enum, for debugging
+func (e *dynEnum) String() string {
+	return fmt.Sprintf("%s(%s)", e.tc.Name(), e.GetAsString())
+}
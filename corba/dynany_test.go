@@ -0,0 +1,181 @@
+package corba_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+func basicTC(t *testing.T, kind corba.TCKind) corba.TypeCodeImpl {
+	t.Helper()
+	tc, err := corba.GetBasicTypeCode(kind)
+	if err != nil {
+		t.Fatalf("GetBasicTypeCode(%v): %v", kind, err)
+	}
+	return tc
+}
+
+func TestDynStruct(t *testing.T) {
+	point, _ := corba.CreateStructTypeCode("IDL:Test/Point:1.0", "Point")
+	point.AddMember("x", basicTC(t, corba.TC_LONG))
+	point.AddMember("label", basicTC(t, corba.TC_STRING))
+
+	factory := corba.Init().GetDynAnyFactory()
+	dyn, err := factory.CreateDynAnyFromTypeCode(point)
+	if err != nil {
+		t.Fatalf("CreateDynAnyFromTypeCode: %v", err)
+	}
+	s := dyn.(corba.DynStruct)
+	if s.ComponentCount() != 2 {
+		t.Fatalf("ComponentCount = %d, want 2", s.ComponentCount())
+	}
+
+	if err := s.InsertLong(7); err != nil {
+		t.Fatalf("InsertLong: %v", err)
+	}
+	if err := s.InsertString("oops"); err != corba.ErrDynAnyTypeMismatch {
+		t.Errorf("InsertString on a long member = %v, want TypeMismatch", err)
+	}
+	if !s.Next() {
+		t.Fatal("Next found no second member")
+	}
+	if name, _ := s.CurrentMemberName(); name != "label" {
+		t.Errorf("CurrentMemberName = %q, want label", name)
+	}
+	s.InsertString("origin")
+	if s.Next() {
+		t.Error("Next moved past the last member")
+	}
+
+	value := s.ToAny().Value()
+	want := map[string]interface{}{"x": int32(7), "label": "origin"}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("ToAny = %#v, want %#v", value, want)
+	}
+
+	any, _ := corba.NewAnyWithTypeCode(point, map[string]interface{}{"x": int32(3), "label": "p"})
+	copied, err := factory.CreateDynAny(any)
+	if err != nil {
+		t.Fatalf("CreateDynAny: %v", err)
+	}
+	if x, _ := copied.GetLong(); x != 3 {
+		t.Errorf("first member = %d, want 3", x)
+	}
+	if err := copied.Assign(s); err != nil || !copied.Equal(s) {
+		t.Errorf("Assign = %v, Equal = %v", err, copied.Equal(s))
+	}
+}
+
+func TestDynSequence(t *testing.T) {
+	longs, _ := corba.CreateSequenceTypeCode("IDL:Test/Longs:1.0", "Longs", basicTC(t, corba.TC_LONG), 3)
+
+	dyn, err := corba.Init().GetDynAnyFactory().CreateDynAnyFromTypeCode(longs)
+	if err != nil {
+		t.Fatalf("CreateDynAnyFromTypeCode: %v", err)
+	}
+	seq := dyn.(corba.DynSequence)
+	if c, _ := seq.CurrentComponent(); c != nil {
+		t.Error("an empty sequence has a current component")
+	}
+
+	if err := seq.SetLength(2); err != nil {
+		t.Fatalf("SetLength: %v", err)
+	}
+	seq.InsertLong(1)
+	seq.Next()
+	seq.InsertLong(2)
+	if err := seq.SetLength(4); err != corba.ErrDynAnyInvalidValue {
+		t.Errorf("SetLength beyond the bound = %v, want InvalidValue", err)
+	}
+
+	any := seq.ToAny()
+	if want := []interface{}{int32(1), int32(2)}; !reflect.DeepEqual(any.Value(), want) {
+		t.Errorf("ToAny = %#v, want %#v", any.Value(), want)
+	}
+
+	other, _ := corba.Init().GetDynAnyFactory().CreateDynAnyFromTypeCode(longs)
+	if err := other.FromAny(any); err != nil {
+		t.Fatalf("FromAny: %v", err)
+	}
+	if !other.Equal(seq) {
+		t.Error("sequence differs after a round trip through Any")
+	}
+
+	seq.Seek(1)
+	seq.SetLength(1)
+	if c, _ := seq.CurrentComponent(); c != nil {
+		t.Error("removed element is still current")
+	}
+}
+
+func TestDynUnion(t *testing.T) {
+	shape, _ := corba.CreateUnionTypeCode("IDL:Test/Shape:1.0", "Shape", basicTC(t, corba.TC_LONG))
+	shape.AddMember("radius", int32(1), basicTC(t, corba.TC_DOUBLE))
+	shape.AddMember("name", int32(2), basicTC(t, corba.TC_STRING))
+
+	factory := corba.Init().GetDynAnyFactory()
+	dyn, err := factory.CreateDynAnyFromTypeCode(shape)
+	if err != nil {
+		t.Fatalf("CreateDynAnyFromTypeCode: %v", err)
+	}
+	u := dyn.(corba.DynUnion)
+	if name, _ := u.MemberName(); name != "radius" {
+		t.Errorf("initial member = %q, want radius", name)
+	}
+
+	u.GetDiscriminator().InsertLong(2)
+	if name, _ := u.MemberName(); name != "name" {
+		t.Errorf("member after changing the discriminator = %q, want name", name)
+	}
+	member, _ := u.Member()
+	member.InsertString("square")
+
+	want := corba.UnionValue{Discriminant: int32(2), Value: "square"}
+	if got := u.ToAny().Value(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToAny = %#v, want %#v", got, want)
+	}
+
+	if err := u.SetToDefaultMember(); err != corba.ErrDynAnyTypeMismatch {
+		t.Errorf("SetToDefaultMember without a default = %v, want TypeMismatch", err)
+	}
+	if err := u.SetToNoActiveMember(); err != nil {
+		t.Fatalf("SetToNoActiveMember: %v", err)
+	}
+	if !u.HasNoActiveMember() || u.ComponentCount() != 1 {
+		t.Errorf("HasNoActiveMember = %v with %d components", u.HasNoActiveMember(), u.ComponentCount())
+	}
+}
+
+func TestDynEnumAndFixed(t *testing.T) {
+	color, _ := corba.CreateEnumTypeCode("IDL:Test/Color:1.0", "Color")
+	color.AddMember("red")
+	color.AddMember("green")
+
+	factory := corba.Init().GetDynAnyFactory()
+	dyn, _ := factory.CreateDynAnyFromTypeCode(color)
+	e := dyn.(corba.DynEnum)
+	if err := e.SetAsString("green"); err != nil || e.GetAsULong() != 1 {
+		t.Errorf("SetAsString = %v, ordinal %d", err, e.GetAsULong())
+	}
+	if err := e.SetAsULong(2); err != corba.ErrDynAnyInvalidValue {
+		t.Errorf("SetAsULong out of range = %v, want InvalidValue", err)
+	}
+
+	money, err := corba.CreateFixedTypeCode(5, 2)
+	if err != nil {
+		t.Fatalf("CreateFixedTypeCode: %v", err)
+	}
+	dyn, _ = factory.CreateDynAnyFromTypeCode(money)
+	f := dyn.(corba.DynFixed)
+	truncated, err := f.SetValue("123.456d")
+	if err != nil || !truncated || f.GetValue() != "123.45" {
+		t.Errorf("SetValue = %v, %v, value %s", truncated, err, f.GetValue())
+	}
+	if _, err := f.SetValue("1234"); err != corba.ErrDynAnyInvalidValue {
+		t.Errorf("SetValue with too many digits = %v, want InvalidValue", err)
+	}
+	if got := f.ToAny().Value(); got != "123.45" {
+		t.Errorf("ToAny = %#v", got)
+	}
+}
@@ -24,6 +24,15 @@ func GetByteOrder(flag CDRByteOrder) binary.ByteOrder {
 	return binary.LittleEndian
 }
 
+// bodyByteOrder returns the byte order of a message body: the one recorded
+// when the message was unmarshalled, or big endian for messages built in process
+func bodyByteOrder(order binary.ByteOrder) binary.ByteOrder {
+	if order == nil {
+		return binary.BigEndian
+	}
+	return order
+}
+
 // GetByteOrderFromData extracts the byte order from the first byte of a CDR encoded component
 // Returns the byte order and the data with the flag byte removed
 func GetByteOrderFromData(data []byte) (binary.ByteOrder, []byte, error) {
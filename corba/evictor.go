@@ -0,0 +1,278 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// Evictor is a servant manager that incarnates servants on demand and keeps at
+// most a fixed number of them active. When the limit is exceeded, the least
+// recently used servant with no request in flight is etherealized.
+//
+// An Evictor can be registered with a POA of either servant retention policy.
+// Under NON_RETAIN it acts as a ServantLocator and caches the servants itself;
+// under RETAIN it acts as a ServantActivator, and evicted objects are
+// deactivated in the POA's active object map.
+type Evictor struct {
+	capacity    int
+	incarnate   func(ObjectID, *POA) (interface{}, error)
+	etherealize func(ObjectID, *POA, interface{})
+
+	mu        sync.Mutex
+	entries   map[evictorKey]*list.Element
+	lru       *list.List // Of *evictorEntry, most recently used first
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+var _ ServantActivator = &Evictor{}
+var _ ServantLocator = &Evictor{}
+
+// EvictorStats reports the cache behaviour of an Evictor
+type EvictorStats struct {
+	Hits      uint64 // Requests served by a cached servant
+	Misses    uint64 // Requests that incarnated a servant
+	Evictions uint64 // Servants etherealized to stay within the capacity
+	Active    int    // Servants currently cached
+}
+
+// evictorKey identifies an object across the POAs an Evictor serves
+type evictorKey struct {
+	poa *POA
+	oid string
+}
+
+// evictorEntry is a cached servant
+type evictorEntry struct {
+	key      evictorKey
+	servant  interface{}
+	inFlight int
+	retained bool // Whether the servant is in the POA's active object map
+}
+
+// NewEvictor creates an evictor keeping at most capacity servants active.
+// incarnate creates the servant for an ObjectID; etherealize, which may be
+// nil, is called for every servant the evictor or the POA lets go of.
+func NewEvictor(capacity int, incarnate func(ObjectID, *POA) (interface{}, error), etherealize func(ObjectID, *POA, interface{})) (*Evictor, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid evictor capacity: %d", capacity)
+	}
+	if incarnate == nil {
+		return nil, fmt.Errorf("evictor needs an incarnate function")
+	}
+
+	return &Evictor{
+		capacity:    capacity,
+		incarnate:   incarnate,
+		etherealize: etherealize,
+		entries:     make(map[evictorKey]*list.Element),
+		lru:         list.New(),
+	}, nil
+}
+
+// Stats returns the hit, miss and eviction counts and the number of cached servants
+func (e *Evictor) Stats() EvictorStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return EvictorStats{
+		Hits:      e.hits,
+		Misses:    e.misses,
+		Evictions: e.evictions,
+		Active:    e.lru.Len(),
+	}
+}
+
+// Preinvoke returns the cached servant for an ObjectID, incarnating it on a
+// miss. It is called by POAs with the NON_RETAIN policy.
+func (e *Evictor) Preinvoke(objectID ObjectID, adapter *POA, operation string) (interface{}, interface{}, error) {
+	key := evictorKey{poa: adapter, oid: string(objectID)}
+
+	e.mu.Lock()
+	if elem, ok := e.entries[key]; ok {
+		entry := elem.Value.(*evictorEntry)
+		entry.inFlight++
+		e.lru.MoveToFront(elem)
+		e.hits++
+		e.mu.Unlock()
+		return entry.servant, entry, nil
+	}
+	e.mu.Unlock()
+
+	servant, err := e.incarnate(objectID, adapter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if servant == nil {
+		return nil, nil, ErrNoServant
+	}
+
+	e.mu.Lock()
+	e.misses++
+	if elem, ok := e.entries[key]; ok {
+		// Another request incarnated the object in the meantime
+		entry := elem.Value.(*evictorEntry)
+		entry.inFlight++
+		e.lru.MoveToFront(elem)
+		e.mu.Unlock()
+		e.release(objectID, adapter, servant)
+		return entry.servant, entry, nil
+	}
+	entry := e.add(key, servant, false)
+	entry.inFlight++
+	victims := e.victims()
+	e.mu.Unlock()
+
+	e.evict(victims)
+	return servant, entry, nil
+}
+
+// Postinvoke ends a request started by Preinvoke and evicts servants that
+// could not be evicted while requests were in flight on them
+func (e *Evictor) Postinvoke(objectID ObjectID, adapter *POA, operation string, servant interface{}, cookieVal interface{}) error {
+	entry, ok := cookieVal.(*evictorEntry)
+	if !ok {
+		return nil
+	}
+	e.done(entry)
+	return nil
+}
+
+// Incarnate creates the servant for an ObjectID. It is called by POAs with the
+// RETAIN policy when the object is not in the active object map.
+func (e *Evictor) Incarnate(objectID ObjectID, adapter *POA) (interface{}, error) {
+	key := evictorKey{poa: adapter, oid: string(objectID)}
+
+	e.mu.Lock()
+	if elem, ok := e.entries[key]; ok {
+		// Concurrent requests for an object being incarnated share the servant
+		e.mu.Unlock()
+		return elem.Value.(*evictorEntry).servant, nil
+	}
+	e.mu.Unlock()
+
+	servant, err := e.incarnate(objectID, adapter)
+	if err != nil {
+		return nil, err
+	}
+	if servant == nil {
+		return nil, ErrNoServant
+	}
+	if !comparableServant(servant) {
+		e.release(objectID, adapter, servant)
+		return nil, BAD_PARAM(MinorUncomparableServant, CompletionStatusNo)
+	}
+
+	e.mu.Lock()
+	e.misses++
+	if elem, ok := e.entries[key]; ok {
+		e.mu.Unlock()
+		e.release(objectID, adapter, servant)
+		return elem.Value.(*evictorEntry).servant, nil
+	}
+	e.add(key, servant, true)
+	e.mu.Unlock()
+
+	// Eviction happens once the request that caused the incarnation completes
+	return servant, nil
+}
+
+// Etherealize forgets a servant the POA has removed from its active object map
+func (e *Evictor) Etherealize(objectID ObjectID, adapter *POA, servant interface{}, cleanupInProgress bool, remainingActivations bool) error {
+	key := evictorKey{poa: adapter, oid: string(objectID)}
+
+	e.mu.Lock()
+	if elem, ok := e.entries[key]; ok && sameServant(elem.Value.(*evictorEntry).servant, servant) {
+		e.lru.Remove(elem)
+		delete(e.entries, key)
+	}
+	e.mu.Unlock()
+
+	e.release(objectID, adapter, servant)
+	return nil
+}
+
+// servantInUse records a request dispatched to a servant in the active object
+// map of a RETAIN POA. The returned function ends the request.
+func (e *Evictor) servantInUse(objectID ObjectID, adapter *POA, servant interface{}, incarnated bool) func() {
+	key := evictorKey{poa: adapter, oid: string(objectID)}
+
+	e.mu.Lock()
+	elem, ok := e.entries[key]
+	if !ok || !sameServant(elem.Value.(*evictorEntry).servant, servant) {
+		// The object was activated without the evictor, or its servant is
+		// being evicted; track it from now on
+		if ok {
+			e.lru.Remove(elem)
+		}
+		e.add(key, servant, true)
+		elem = e.entries[key]
+	}
+	if !incarnated {
+		e.hits++
+	}
+	entry := elem.Value.(*evictorEntry)
+	entry.inFlight++
+	e.lru.MoveToFront(elem)
+	e.mu.Unlock()
+
+	return func() { e.done(entry) }
+}
+
+// add caches a servant as the most recently used one. The lock must be held.
+func (e *Evictor) add(key evictorKey, servant interface{}, retained bool) *evictorEntry {
+	entry := &evictorEntry{key: key, servant: servant, retained: retained}
+	e.entries[key] = e.lru.PushFront(entry)
+	return entry
+}
+
+// done ends a request on a cached servant and evicts servants beyond the capacity
+func (e *Evictor) done(entry *evictorEntry) {
+	e.mu.Lock()
+	entry.inFlight--
+	victims := e.victims()
+	e.mu.Unlock()
+
+	e.evict(victims)
+}
+
+// victims removes least recently used servants with no request in flight
+// until the cache is within its capacity, and returns them. The lock must be held.
+func (e *Evictor) victims() []*evictorEntry {
+	var victims []*evictorEntry
+	for elem := e.lru.Back(); elem != nil && e.lru.Len() > e.capacity; {
+		prev := elem.Prev()
+		if entry := elem.Value.(*evictorEntry); entry.inFlight == 0 {
+			e.lru.Remove(elem)
+			delete(e.entries, entry.key)
+			victims = append(victims, entry)
+			e.evictions++
+		}
+		elem = prev
+	}
+	return victims
+}
+
+// evict etherealizes evicted servants. Servants retained by a POA are
+// deactivated, and the POA etherealizes them through Etherealize.
+func (e *Evictor) evict(victims []*evictorEntry) {
+	for _, entry := range victims {
+		oid := ObjectID(entry.key.oid)
+		if !entry.retained {
+			e.release(oid, entry.key.poa, entry.servant)
+			continue
+		}
+		// An error means the object has been deactivated by other means
+		entry.key.poa.DeactivateObject(oid)
+	}
+}
+
+// release passes a servant the evictor no longer holds to the etherealize function
+func (e *Evictor) release(objectID ObjectID, adapter *POA, servant interface{}) {
+	if e.etherealize != nil {
+		e.etherealize(objectID, adapter, servant)
+	}
+}
@@ -0,0 +1,190 @@
+package corba_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// evictorRecorder incarnates concurrency servants and records the objects etherealized
+type evictorRecorder struct {
+	mu           sync.Mutex
+	servants     map[string]*concurrencyServant
+	incarnated   chan string
+	etherealized []string
+}
+
+func newEvictor(t *testing.T, capacity int) (*corba.Evictor, *evictorRecorder) {
+	t.Helper()
+
+	rec := &evictorRecorder{
+		servants:   make(map[string]*concurrencyServant),
+		incarnated: make(chan string, 16),
+	}
+	evictor, err := corba.NewEvictor(capacity,
+		func(id corba.ObjectID, _ *corba.POA) (interface{}, error) {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			servant := newConcurrencyServant()
+			rec.servants[string(id)] = servant
+			rec.incarnated <- string(id)
+			return servant, nil
+		},
+		func(id corba.ObjectID, _ *corba.POA, _ interface{}) {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.etherealized = append(rec.etherealized, string(id))
+		})
+	if err != nil {
+		t.Fatalf("NewEvictor: %v", err)
+	}
+	return evictor, rec
+}
+
+func (r *evictorRecorder) evicted() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.etherealized)
+}
+
+func (r *evictorRecorder) servant(id string) *concurrencyServant {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.servants[id]
+}
+
+// evictorPOA creates a POA served by an evictor under the given retention policy
+func evictorPOA(t *testing.T, orb *corba.ORB, retention int, evictor *corba.Evictor) *corba.POA {
+	t.Helper()
+
+	poa, err := orb.GetRootPOA().CreatePOA("Evicted", nil, []corba.POAPolicy{
+		corba.NewServantRetentionPolicy(retention),
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	if err := poa.SetServantManager(evictor); err != nil {
+		t.Fatalf("SetServantManager: %v", err)
+	}
+	return poa
+}
+
+func wantEvictorStats(t *testing.T, evictor *corba.Evictor, want corba.EvictorStats) {
+	t.Helper()
+	if got := evictor.Stats(); got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestEvictorLeastRecentlyUsed(t *testing.T) {
+	for _, retention := range []int{corba.NonRetainServants, corba.RetainServants} {
+		orb := corba.Init()
+		client, ep := poaTestServer(t, orb, "evictor-lru")
+		evictor, rec := newEvictor(t, 2)
+		poa := evictorPOA(t, orb, retention, evictor)
+
+		invoke := func(id string) {
+			t.Helper()
+			ref := poa.CreateReferenceWithId(corba.ObjectID(id), "IDL:Test/Echo:1.0")
+			if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+				t.Fatalf("Invoke %s: %v", id, err)
+			}
+		}
+
+		invoke("a")
+		invoke("b")
+		invoke("a")
+		invoke("c")
+		wantEvictorStats(t, evictor, corba.EvictorStats{Hits: 1, Misses: 3, Evictions: 1, Active: 2})
+		if got := rec.evicted(); !slices.Equal(got, []string{"b"}) {
+			t.Errorf("etherealized %v, want [b]", got)
+		}
+
+		if retention == corba.RetainServants {
+			// The evicted object left the active object map
+			ref := poa.CreateReferenceWithId(corba.ObjectID("b"), "IDL:Test/Echo:1.0")
+			if _, err := poa.ReferenceToServant(ref); err != corba.ErrObjectNotActive {
+				t.Errorf("ReferenceToServant of an evicted object = %v, want ObjectNotActive", err)
+			}
+		}
+
+		// An evicted object is incarnated again
+		invoke("b")
+		wantEvictorStats(t, evictor, corba.EvictorStats{Hits: 1, Misses: 4, Evictions: 2, Active: 2})
+
+		orb.Shutdown(true)
+	}
+}
+
+func TestEvictorKeepsServantsInUse(t *testing.T) {
+	for _, retention := range []int{corba.NonRetainServants, corba.RetainServants} {
+		orb := corba.Init()
+		client, ep := poaTestServer(t, orb, "evictor-busy")
+		evictor, rec := newEvictor(t, 1)
+		poa := evictorPOA(t, orb, retention, evictor)
+
+		refA := poa.CreateReferenceWithId(corba.ObjectID("a"), "IDL:Test/Echo:1.0")
+		refB := poa.CreateReferenceWithId(corba.ObjectID("b"), "IDL:Test/Echo:1.0")
+
+		// Keep a request in flight on a, over a connection of its own
+		blocked := make(chan error, 1)
+		other := corba.Init().CreateClient()
+		go func() { blocked <- invokeRef(t, other, ep, refA, "block") }()
+		<-rec.incarnated
+		<-rec.servant("a").started
+
+		// Serving b exceeds the capacity, but a cannot be evicted
+		if err := invokeRef(t, client, ep, refB, "ping"); err != nil {
+			t.Fatalf("Invoke b: %v", err)
+		}
+		if got := rec.evicted(); slices.Contains(got, "a") {
+			t.Errorf("servant etherealized with a request in flight: %v", got)
+		}
+
+		close(rec.servant("a").gate)
+		if err := <-blocked; err != nil {
+			t.Fatalf("Invoke a: %v", err)
+		}
+		if stats := evictor.Stats(); stats.Active != 1 {
+			t.Errorf("%d servants active after the requests completed, want 1", stats.Active)
+		}
+
+		orb.Shutdown(true)
+	}
+}
+
+// dispatchFunc is a servant Go cannot compare with ==
+type dispatchFunc func(methodName string) (interface{}, error)
+
+func (f dispatchFunc) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return f(methodName)
+}
+
+func TestEvictorNonComparableServants(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "evictor-func")
+	released := make(chan string, 1)
+	evictor, err := corba.NewEvictor(1,
+		func(id corba.ObjectID, _ *corba.POA) (interface{}, error) {
+			return dispatchFunc(func(string) (interface{}, error) { return nil, nil }), nil
+		},
+		func(id corba.ObjectID, _ *corba.POA, _ interface{}) {
+			released <- string(id)
+		})
+	if err != nil {
+		t.Fatalf("NewEvictor: %v", err)
+	}
+	poa := evictorPOA(t, orb, corba.RetainServants, evictor)
+
+	// A servant without an identity cannot enter the active object map
+	ref := poa.CreateReferenceWithId(corba.ObjectID("a"), "IDL:Test/Echo:1.0")
+	err = invokeRef(t, client, ep, ref, "ping")
+	wantMinorCode(t, err, "BAD_PARAM", corba.MinorUncomparableServant)
+	if id := <-released; id != "a" {
+		t.Errorf("released %q, want %q", id, "a")
+	}
+	wantEvictorStats(t, evictor, corba.EvictorStats{})
+}
@@ -2,9 +2,13 @@
 package corba
 
 import (
+	"encoding/binary"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+
+	"github.com/ifabos/go-corba/giop"
 )
 
 // CompletionStatus indicates the status of an operation that raised an exception
@@ -376,15 +380,18 @@ func CreateSystemExceptionTypeCode(name string) (TypeCode, error) {
 		return tc, nil
 	}
 
-	// Create a new struct-like TypeCode for system exceptions
-	sysExTc, err := CreateStructTypeCode(id, name)
+	// Create a new exception TypeCode for system exceptions
+	sysExTc, err := CreateExceptionTypeCode(id, name)
 	if err != nil {
 		return nil, err
 	}
 
 	// Add the standard members of system exceptions
 	minorTc, _ := GetBasicTypeCode(TC_ULONG)
-	completedTc, _ := GetBasicTypeCode(TC_LONG)
+	completedTc, err := completionStatusTypeCode()
+	if err != nil {
+		return nil, err
+	}
 
 	// Add members
 	sysExTc.AddMember("minor", minorTc)
@@ -393,16 +400,29 @@ func CreateSystemExceptionTypeCode(name string) (TypeCode, error) {
 	return sysExTc, nil
 }
 
-// IsSystemException checks if an error is a CORBA system exception
+// completionStatusTypeCode returns the TypeCode of the CompletionStatus enum
+var completionStatusTypeCode = sync.OnceValues(func() (TypeCode, error) {
+	tc, err := CreateEnumTypeCode("IDL:omg.org/CORBA/CompletionStatus:1.0", "CompletionStatus")
+	if err != nil {
+		return nil, err
+	}
+	tc.AddMember("COMPLETED_YES")
+	tc.AddMember("COMPLETED_NO")
+	tc.AddMember("COMPLETED_MAYBE")
+	return tc, nil
+})
+
+// IsSystemException checks if an error is a CORBA system exception: an
+// exception whose repository ID names one of the standard system exceptions
 func IsSystemException(err error) bool {
-	_, ok := err.(*SystemException)
-	return ok
+	ex, ok := err.(Exception)
+	return ok && isSystemExceptionID(ex.ID())
 }
 
 // IsUserException checks if an error is a CORBA user exception
 func IsUserException(err error) bool {
-	_, ok := err.(*UserException)
-	return ok
+	_, ok := err.(Exception)
+	return ok && !IsSystemException(err)
 }
 
 // IsException checks if an error is a CORBA exception (system or user)
@@ -424,88 +444,106 @@ func GetExceptionTypeCode(ex Exception) (TypeCode, error) {
 	return holder.TypeCode, nil
 }
 
-// MarshalException serializes an exception for transmission
+// MarshalException encodes an exception as the body of an exception reply.
+// System exceptions are encoded as their repository ID, minor code and
+// completion status. User exceptions are encoded as their repository ID
+// followed by the members described by the TypeCode registered for the ID;
+// without a TypeCode, no members are sent. The encoding is CDR in big endian
+// byte order.
 func MarshalException(ex Exception) ([]byte, error) {
-	// This is a placeholder for actual marshalling code
-	// In a real implementation, this would use CDR marshalling to encode the exception
+	if ex == nil {
+		return nil, fmt.Errorf("cannot marshal nil exception")
+	}
 
-	// For now, we'll simulate it with a simple string encoding
-	var payload string
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	m.WriteString(ex.ID())
 
-	if sysEx, ok := ex.(*SystemException); ok {
-		payload = fmt.Sprintf("SYSTEM:%s:%d:%d", sysEx.Name(), sysEx.Minor(), sysEx.Completed())
-	} else if userEx, ok := ex.(*UserException); ok {
-		payload = fmt.Sprintf("USER:%s:%s", userEx.ID(), userEx.Name())
-		// In a real implementation, we would also marshal the member values
-	} else {
-		return nil, fmt.Errorf("unsupported exception type: %T", ex)
+	if IsSystemException(ex) {
+		m.WriteULong(ex.Minor())
+		m.WriteULong(uint32(ex.Completed()))
+		return m.Bytes(), nil
 	}
 
-	return []byte(payload), nil
+	if tc, err := GetTypeCode(ex.ID()); err == nil {
+		if err := marshalMembers(m, tc, ex); err != nil {
+			return nil, err
+		}
+	}
+	return m.Bytes(), nil
 }
 
-// UnmarshalException deserializes an exception from transmission
+// UnmarshalException decodes the body of an exception reply. Exceptions whose
+// repository ID names a CORBA system exception are decoded as system
+// exceptions. The members of user exceptions are decoded with tc, or with the
+// TypeCode registered for the repository ID if tc is nil, and the exception
+// is rebuilt as the Go type registered for the ID, or as a UserException.
+// The encoding is CDR in big endian byte order, as with MarshalException.
 func UnmarshalException(data []byte, tc TypeCode) (Exception, error) {
-	// This is a placeholder for actual unmarshalling code
-	// In a real implementation, this would use CDR unmarshalling to decode the exception
+	return unmarshalException(data, binary.BigEndian, tc)
+}
 
-	// For now, we'll simulate it with a simple string decoding
-	payload := string(data)
-	parts := strings.Split(payload, ":")
+// unmarshalException decodes the body of an exception reply encoded in the
+// given byte order
+func unmarshalException(data []byte, order binary.ByteOrder, tc TypeCode) (Exception, error) {
+	u := giop.NewCDRUnmarshaller(data, order)
 
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid exception data format")
+	id, err := u.ReadString()
+	if err != nil {
+		return nil, fmt.Errorf("invalid exception repository ID: %w", err)
 	}
 
-	switch parts[0] {
-	case "SYSTEM":
-		if len(parts) < 4 {
-			return nil, fmt.Errorf("invalid system exception data format")
-		}
-
-		name := parts[1]
-		minor, err := parseUint32(parts[2])
+	if isSystemExceptionID(id) {
+		minor, err := u.ReadULong()
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid system exception minor code: %w", err)
 		}
-
-		completed, err := parseInt32(parts[3])
+		completed, err := u.ReadULong()
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid system exception completion status: %w", err)
 		}
-
+		name := strings.TrimSuffix(strings.TrimPrefix(id, systemExceptionIDPrefix), ":1.0")
 		return NewCORBASystemException(name, minor, CompletionStatus(completed)), nil
+	}
 
-	case "USER":
-		if len(parts) < 3 {
-			return nil, fmt.Errorf("invalid user exception data format")
+	if tc == nil {
+		if registered, err := GetTypeCode(id); err == nil {
+			tc = registered
 		}
+	}
 
-		id := parts[1]
-		name := parts[2]
-
-		return NewCORBAUserException(name, id), nil
-
-	default:
-		return nil, fmt.Errorf("unknown exception type: %s", parts[0])
+	var members map[string]interface{}
+	if tc != nil {
+		if members, err = unmarshalMembers(u, tc); err != nil {
+			return nil, fmt.Errorf("invalid members of exception %s: %w", id, err)
+		}
 	}
+
+	return globalExceptionRegistry.newException(id, tc, members)
 }
 
-// Helper functions for parsing
-func parseUint32(s string) (uint32, error) {
-	var result uint32
-	_, err := fmt.Sscanf(s, "%d", &result)
-	return result, err
+// systemExceptionIDPrefix starts the repository IDs of CORBA system exceptions
+const systemExceptionIDPrefix = "IDL:omg.org/CORBA/"
+
+// isSystemExceptionID reports whether a repository ID names a CORBA system exception
+func isSystemExceptionID(id string) bool {
+	return strings.HasPrefix(id, systemExceptionIDPrefix)
 }
 
-func parseInt32(s string) (int32, error) {
-	var result int32
-	_, err := fmt.Sscanf(s, "%d", &result)
-	return result, err
+// exceptionName returns the unqualified name of an exception from its repository ID
+func exceptionName(id string) string {
+	name := strings.TrimPrefix(id, "IDL:")
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
 }
 
 // ExceptionRegistry maintains a registry of user-defined exceptions
 type ExceptionRegistry struct {
+	mu         sync.RWMutex
 	exceptions map[string]reflect.Type
 }
 
@@ -519,18 +557,52 @@ func NewExceptionRegistry() *ExceptionRegistry {
 	}
 }
 
-// Register registers a user-defined exception type with the registry
+// Register registers a user-defined exception type with the registry. The
+// type must be a pointer to a struct implementing Exception; the members of
+// exceptions received with the repository ID are stored in the fields tagged
+// `corba:"member"` or named like the members.
 func (r *ExceptionRegistry) Register(id string, exType reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.exceptions[id] = exType
 }
 
 // Lookup looks up a user-defined exception type in the registry
 func (r *ExceptionRegistry) Lookup(id string) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	t, ok := r.exceptions[id]
 	return t, ok
 }
 
-// RegisterException registers a user-defined exception type with the global registry
+// newException rebuilds a user exception received with its decoded members,
+// as the registered Go type or as a UserException
+func (r *ExceptionRegistry) newException(id string, tc TypeCode, members map[string]interface{}) (Exception, error) {
+	if t, ok := r.Lookup(id); ok && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		v := reflect.New(t.Elem())
+		if ex, ok := v.Interface().(Exception); ok {
+			if err := assignValue(v.Elem(), members); err != nil {
+				return nil, fmt.Errorf("cannot rebuild exception %s: %w", id, err)
+			}
+			return ex, nil
+		}
+	}
+
+	name := exceptionName(id)
+	if tc != nil && tc.Name() != "" {
+		name = tc.Name()
+	}
+	ex := NewCORBAUserException(name, id)
+	for member, value := range members {
+		ex.SetMember(member, value)
+	}
+	return ex, nil
+}
+
+// RegisterException registers a user-defined exception type with the global
+// registry, so that exceptions received with the repository ID are rebuilt as
+// values of the type of ex. Register the exception's TypeCode with
+// RegisterTypeCode for its members to be sent and received.
 func RegisterException(id string, ex interface{}) {
 	globalExceptionRegistry.Register(id, reflect.TypeOf(ex))
 }
@@ -567,11 +639,11 @@ func ThrowableToException(err interface{}) Exception {
 
 	case error:
 		// Convert Go error to UNKNOWN system exception
-		return UNKNOWN(0, CompletionStatusNo)
+		return UNKNOWN(MinorGoError, CompletionStatusNo)
 
 	default:
 		// Convert other panics to UNKNOWN system exception
-		return UNKNOWN(0, CompletionStatusNo)
+		return UNKNOWN(MinorServantPanic, CompletionStatusNo)
 	}
 }
 
@@ -586,11 +658,16 @@ func RecoverException() Exception {
 }
 
 // SafeInvoke safely invokes a function and converts any panics to exceptions
-func SafeInvoke(fn func() (interface{}, error)) (interface{}, Exception) {
-	var ex Exception
+func SafeInvoke(fn func() (interface{}, error)) (result interface{}, ex Exception) {
 	defer func() {
 		if r := recover(); r != nil {
-			ex = ThrowableToException(r)
+			result = nil
+			if e, ok := r.(Exception); ok {
+				ex = e
+			} else {
+				// The servant stopped part way through the operation
+				ex = UNKNOWN(MinorServantPanic, CompletionStatusMaybe)
+			}
 		}
 	}()
 
@@ -599,7 +676,7 @@ func SafeInvoke(fn func() (interface{}, error)) (interface{}, Exception) {
 		return nil, ThrowableToException(err)
 	}
 
-	return result, ex
+	return result, nil
 }
 
 // UpdateExceptionHandling updates the exception handling in the CORBA server
@@ -0,0 +1,134 @@
+package corba_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// raisingServant raises the same error from every operation
+type raisingServant struct {
+	err error
+}
+
+func (s *raisingServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return nil, s.err
+}
+
+// insufficientFunds is a user exception with a Go type of its own
+type insufficientFunds struct {
+	Balance int32
+	Owners  []string `corba:"holders"`
+}
+
+func (e *insufficientFunds) Error() string {
+	return fmt.Sprintf("insufficient funds: balance %d", e.Balance)
+}
+func (e *insufficientFunds) ID() string                        { return "IDL:Test/InsufficientFunds:1.0" }
+func (e *insufficientFunds) Name() string                      { return "InsufficientFunds" }
+func (e *insufficientFunds) Minor() uint32                     { return 0 }
+func (e *insufficientFunds) Completed() corba.CompletionStatus { return corba.CompletionStatusNo }
+
+// defineExceptionTypeCode registers an exception TypeCode with a long
+// "balance" and a sequence of strings "holders"
+func defineExceptionTypeCode(t *testing.T, id, name string) {
+	t.Helper()
+
+	tc, err := corba.CreateExceptionTypeCode(id, name)
+	if err != nil {
+		t.Fatalf("CreateExceptionTypeCode: %v", err)
+	}
+	if tc.MemberCount() > 0 {
+		return
+	}
+	long, _ := corba.GetBasicTypeCode(corba.TC_LONG)
+	str, _ := corba.GetBasicTypeCode(corba.TC_STRING)
+	names, err := corba.CreateSequenceTypeCode("IDL:Test/Names:1.0", "Names", str, 0)
+	if err != nil {
+		t.Fatalf("CreateSequenceTypeCode: %v", err)
+	}
+	tc.AddMember("balance", long)
+	tc.AddMember("holders", names)
+}
+
+// raise makes a servant raise err over the wire and returns the exception the client receives
+func raise(t *testing.T, err error) error {
+	t.Helper()
+
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name()+"/"+err.(corba.Exception).Name())
+	ref, activateErr := orb.GetRootPOA().ServantToReference(&raisingServant{err: err})
+	if activateErr != nil {
+		t.Fatalf("ServantToReference: %v", activateErr)
+	}
+	return invokeRef(t, client, ep, ref, "withdraw")
+}
+
+func TestSystemExceptionReplyBody(t *testing.T) {
+	data, err := corba.MarshalException(corba.TRANSIENT(3, corba.CompletionStatusMaybe))
+	if err != nil {
+		t.Fatalf("MarshalException: %v", err)
+	}
+
+	// The body is the repository ID, minor code and completion status
+	u := giop.NewCDRUnmarshaller(data, binary.BigEndian)
+	id, _ := u.ReadString()
+	minor, _ := u.ReadULong()
+	completed, err := u.ReadULong()
+	if err != nil || id != "IDL:omg.org/CORBA/TRANSIENT:1.0" || minor != 3 || completed != 2 {
+		t.Errorf("body = %q, %d, %d (%v), want the TRANSIENT ID, 3, 2", id, minor, completed, err)
+	}
+
+	err = raise(t, corba.NO_PERMISSION(7, corba.CompletionStatusYes))
+	wantSystemException(t, err, "NO_PERMISSION")
+	if ex := err.(corba.Exception); ex.Minor() != 7 || ex.Completed() != corba.CompletionStatusYes {
+		t.Errorf("received minor %d, completed %d, want 7, yes", ex.Minor(), ex.Completed())
+	}
+}
+
+func TestUserExceptionRebuiltAsRegisteredType(t *testing.T) {
+	defineExceptionTypeCode(t, "IDL:Test/InsufficientFunds:1.0", "InsufficientFunds")
+	corba.RegisterException("IDL:Test/InsufficientFunds:1.0", &insufficientFunds{})
+
+	err := raise(t, &insufficientFunds{Balance: 42, Owners: []string{"ann", "bob"}})
+	if !corba.IsUserException(err) {
+		t.Fatalf("got %v, want a user exception", err)
+	}
+	got, ok := err.(*insufficientFunds)
+	if !ok {
+		t.Fatalf("got %T, want *insufficientFunds", err)
+	}
+	if got.Balance != 42 || !slices.Equal(got.Owners, []string{"ann", "bob"}) {
+		t.Errorf("members = %+v, want balance 42 and holders [ann bob]", got)
+	}
+}
+
+func TestUserExceptionMembers(t *testing.T) {
+	defineExceptionTypeCode(t, "IDL:Test/Overdrawn:1.0", "Overdrawn")
+
+	sent := corba.NewCORBAUserException("Overdrawn", "IDL:Test/Overdrawn:1.0")
+	sent.SetMember("balance", int32(-5))
+	sent.SetMember("holders", []string{"ann"})
+
+	err := raise(t, sent)
+	got, ok := err.(*corba.UserException)
+	if !ok || got.ID() != "IDL:Test/Overdrawn:1.0" || got.Name() != "Overdrawn" {
+		t.Fatalf("got %v, want the Overdrawn user exception", err)
+	}
+	if balance, _ := got.GetMember("balance"); balance != int32(-5) {
+		t.Errorf("balance = %v, want -5", balance)
+	}
+	if holders, _ := got.GetMember("holders"); !slices.Equal(holders.([]interface{}), []interface{}{"ann"}) {
+		t.Errorf("holders = %v, want [ann]", holders)
+	}
+
+	// Without a TypeCode only the repository ID travels
+	err = raise(t, corba.NewCORBAUserException("Unknown", "IDL:Test/Unknown:1.0"))
+	if got, ok := err.(*corba.UserException); !ok || got.Name() != "Unknown" || len(got.Members()) != 0 {
+		t.Errorf("got %v, want the Unknown user exception without members", err)
+	}
+}
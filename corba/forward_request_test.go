@@ -0,0 +1,286 @@
+package corba_test
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// shardTargets activates two servants and returns references to them through
+// the test server: requests for the first are to be forwarded to the second
+func shardTargets(t *testing.T, orb *corba.ORB, client *corba.Client, ep corba.Endpoint) (from, to *corba.ObjectRef, servant *concurrencyServant) {
+	t.Helper()
+
+	refs := make([]*corba.ObjectRef, 2)
+	servants := []*concurrencyServant{newConcurrencyServant(), newConcurrencyServant()}
+	for i, s := range servants {
+		ref, err := orb.GetRootPOA().ServantToReference(s)
+		if err != nil {
+			t.Fatalf("ServantToReference: %v", err)
+		}
+		if refs[i], err = client.GetObjectAt(string(ref.GetObjectKey()), ep); err != nil {
+			t.Fatalf("GetObjectAt: %v", err)
+		}
+	}
+	return refs[0], refs[1], servants[1]
+}
+
+// forwardingServerInterceptor forwards the requests for one object key to
+// another reference
+type forwardingServerInterceptor struct {
+	from    string
+	to      *corba.ObjectRef
+	forward chan *corba.ObjectRef
+}
+
+func (i *forwardingServerInterceptor) Name() string { return "forwarding" }
+
+func (i *forwardingServerInterceptor) ReceiveRequest(info *corba.RequestInfo) error {
+	if info.ObjectKey == i.from {
+		return corba.NewForwardRequest(i.to)
+	}
+	return nil
+}
+
+func (i *forwardingServerInterceptor) SendReply(info *corba.RequestInfo) error { return nil }
+
+func (i *forwardingServerInterceptor) SendException(info *corba.RequestInfo, ex corba.Exception) error {
+	return nil
+}
+
+func (i *forwardingServerInterceptor) SendOther(info *corba.RequestInfo) error {
+	i.forward <- info.ForwardReference
+	return nil
+}
+
+// forwardingClientInterceptor retries the requests for one object key at
+// another reference
+type forwardingClientInterceptor struct {
+	from string
+	to   *corba.ObjectRef
+}
+
+func (i *forwardingClientInterceptor) Name() string { return "forwarding" }
+
+func (i *forwardingClientInterceptor) SendRequest(info *corba.RequestInfo) error {
+	if string(info.EffectiveTarget.GetObjectKey()) == i.from {
+		return corba.NewForwardRequest(i.to)
+	}
+	return nil
+}
+
+func (i *forwardingClientInterceptor) ReceiveReply(info *corba.RequestInfo) error { return nil }
+
+func (i *forwardingClientInterceptor) ReceiveException(info *corba.RequestInfo, ex corba.Exception) error {
+	return nil
+}
+
+func (i *forwardingClientInterceptor) ReceiveOther(info *corba.RequestInfo) error { return nil }
+
+func TestForwardRequestFromServerInterceptor(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	from, to, servant := shardTargets(t, orb, client, ep)
+
+	interceptor := &forwardingServerInterceptor{
+		from:    string(from.GetObjectKey()),
+		to:      to,
+		forward: make(chan *corba.ObjectRef, 1),
+	}
+	orb.RegisterServerRequestInterceptor(interceptor)
+
+	if _, err := from.Invoke("ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-servant.started
+	if got := <-interceptor.forward; got != to {
+		t.Errorf("SendOther saw forward reference %v, want %v", got, to)
+	}
+}
+
+func TestForwardRequestFromServantLocator(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	_, to, servant := shardTargets(t, orb, client, ep)
+
+	poa, err := orb.GetRootPOA().CreatePOA("Sharded", nil, []corba.POAPolicy{
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+		corba.NewServantRetentionPolicy(corba.NonRetainServants),
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	poa.SetServantManager(&corba.BasicServantLocator{
+		PreinvokeFunc: func(id corba.ObjectID, adapter *corba.POA, operation string) (interface{}, interface{}, error) {
+			return nil, nil, corba.NewForwardRequest(to)
+		},
+	})
+
+	ref := poa.CreateReferenceWithId(corba.ObjectID("moved"), "IDL:Test/Moved:1.0")
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-servant.started
+}
+
+func TestForwardRequestFromClientInterceptor(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	from, to, servant := shardTargets(t, orb, client, ep)
+
+	orb.RegisterClientRequestInterceptor(&forwardingClientInterceptor{from: string(from.GetObjectKey()), to: to})
+
+	if _, err := from.Invoke("ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-servant.started
+}
+
+// vetoingServerInterceptor raises an exception from SendOther
+type vetoingServerInterceptor struct {
+	err error
+}
+
+func (i *vetoingServerInterceptor) Name() string { return "vetoing" }
+
+func (i *vetoingServerInterceptor) ReceiveRequest(info *corba.RequestInfo) error { return nil }
+
+func (i *vetoingServerInterceptor) SendReply(info *corba.RequestInfo) error { return nil }
+
+func (i *vetoingServerInterceptor) SendException(info *corba.RequestInfo, ex corba.Exception) error {
+	return nil
+}
+
+func (i *vetoingServerInterceptor) SendOther(info *corba.RequestInfo) error { return i.err }
+
+func TestSendOtherReplacesForward(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	from, to, _ := shardTargets(t, orb, client, ep)
+
+	orb.RegisterServerRequestInterceptor(&forwardingServerInterceptor{
+		from:    string(from.GetObjectKey()),
+		to:      to,
+		forward: make(chan *corba.ObjectRef, 2),
+	})
+	veto := &vetoingServerInterceptor{err: corba.NO_PERMISSION(0, corba.CompletionStatusNo)}
+	orb.RegisterServerRequestInterceptor(veto)
+
+	// A system exception raised by SendOther is sent instead of the forward
+	_, err := from.Invoke("ping")
+	wantSystemException(t, err, "NO_PERMISSION")
+
+	// A ForwardRequest raised by SendOther redirects to its reference; a nil
+	// one raises OBJECT_NOT_EXIST
+	veto.err = corba.NewForwardRequest(nil)
+	if msg := veto.err.Error(); msg == "" {
+		t.Error("ForwardRequest to nil has no message")
+	}
+	_, err = from.Invoke("ping")
+	wantSystemException(t, err, "OBJECT_NOT_EXIST")
+}
+
+// droppingResponder accepts connections and answers their requests, except
+// for crash, which closes the connection without a reply. The operations
+// received are sent to the returned channel.
+func droppingResponder(t *testing.T, name string) (corba.Endpoint, chan string) {
+	t.Helper()
+
+	ep := corba.NewMemEndpoint(name)
+	l, err := corba.NewMemTransport().Listen(ep)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					header := make([]byte, 12)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					body := make([]byte, binary.BigEndian.Uint32(header[8:]))
+					if _, err := io.ReadFull(conn, body); err != nil {
+						return
+					}
+					msg, err := giop.UnmarshalGIOPMessage(append(header, body...))
+					if err != nil {
+						return
+					}
+					request := msg.Body.(*giop.RequestHeader)
+					received <- request.Operation
+					if request.Operation == "crash" {
+						return
+					}
+					data, _ := giop.MarshalGIOPMessage(&giop.Message{
+						Header: giop.NewMessageHeader(giop.MsgReply, 0),
+						Body: &giop.ReplyHeader{
+							ServiceContexts: make(giop.ServiceContextList, 0),
+							RequestID:       request.RequestID,
+							ReplyStatus:     giop.ReplyStatusNoException,
+						},
+					})
+					conn.Write(data)
+				}
+			}()
+		}
+	}()
+	return ep, received
+}
+
+func TestForwardedRequestRunsAtMostOnce(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	forwardEp, received := droppingResponder(t, t.Name()+"-forward")
+	to, err := client.GetObjectAt("moved", forwardEp)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	poa, err := orb.GetRootPOA().CreatePOA("Sharded", nil, []corba.POAPolicy{
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+		corba.NewServantRetentionPolicy(corba.NonRetainServants),
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	poa.SetServantManager(&corba.BasicServantLocator{
+		PreinvokeFunc: func(id corba.ObjectID, adapter *corba.POA, operation string) (interface{}, interface{}, error) {
+			return nil, nil, corba.NewForwardRequest(to)
+		},
+	})
+	ref := poa.CreateReferenceWithId(corba.ObjectID("moved"), "IDL:Test/Moved:1.0")
+	target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// The reference remembers the location it was forwarded to
+	if _, err := target.Invoke("ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-received
+
+	// A request lost after reaching the forwarded location is not sent again
+	_, err = target.Invoke("crash")
+	wantSystemException(t, err, "COMM_FAILURE")
+	<-received
+	select {
+	case op := <-received:
+		t.Errorf("%s was retried after reaching the servant", op)
+	default:
+	}
+}
@@ -0,0 +1,750 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ifabos/go-corba/giop"
+)
+
+// ImplRepositoryObjectKey is the object key of the registration interface of an
+// implementation repository
+const ImplRepositoryObjectKey = "ImplRepository"
+
+// Environment variables set for servers launched by an implementation repository.
+// See ORB.UseImplRepositoryFromEnv.
+const (
+	ImplRepositoryEndpointEnv = "CORBA_IMR_ENDPOINT" // Endpoint of the repository
+	ImplRepositoryServerEnv   = "CORBA_IMR_SERVER"   // Name the server registers under
+	ImplRepositoryTokenEnv    = "CORBA_IMR_TOKEN"    // Token the server proves it was launched with
+)
+
+// DefaultServerStartupTimeout is how long an implementation repository waits
+// for a launched server to register before failing requests with TRANSIENT
+const DefaultServerStartupTimeout = 10 * time.Second
+
+// Operations of the registration interface
+const (
+	imrRegisterPOA      = "register_poa"
+	imrUnregisterServer = "unregister_server"
+)
+
+// imrPOAPathSeparator joins the names of a POA path in registrations
+const imrPOAPathSeparator = "/"
+
+// imrReachableTimeout bounds the check that a registered server is still up
+const imrReachableTimeout = 2 * time.Second
+
+// ServerDefinition describes a server known to an implementation repository
+type ServerDefinition struct {
+	Name    string   // Name the server's ORB registers under
+	Command string   // Program that starts the server; empty if it is started by other means
+	Args    []string // Arguments passed to Command
+	POAs    []string // Paths of the server's persistent POAs, such as "Bank/Accounts"
+}
+
+// ImplRepository is an implementation repository. Servers with PERSISTENT POAs
+// register the endpoint they listen on, and the references those POAs create
+// point at the repository instead. The repository answers requests and
+// LocateRequests for such references with a LOCATION_FORWARD to the running
+// server, launching the server first if it is not running and has a command.
+//
+// Each registration carries a token. While an instance of a server runs, or
+// once the repository has launched one, only registrations with the token of
+// that instance are accepted for the server's name; launched servers receive
+// their token in ImplRepositoryTokenEnv.
+type ImplRepository struct {
+	orb            *ORB
+	client         *Client  // Asks servers for the type of forwarded objects
+	endpoint       Endpoint // Endpoint of the repository, passed to launched servers
+	mu             sync.Mutex
+	servers        map[string]*imrServer // Servers by name
+	poas           map[string]string     // Server names by POA path
+	startupTimeout time.Duration
+}
+
+// imrServer is the state of a server known to the repository
+type imrServer struct {
+	def      ServerDefinition
+	endpoint Endpoint          // Endpoint of the running instance; zero while stopped
+	token    string            // Token of the running or launched instance
+	process  *exec.Cmd         // Process launched by the repository, if any
+	started  chan struct{}     // Closed once a running instance has registered
+	verified bool              // The running instance was reached since it registered
+	typeIDs  map[string]string // Repository IDs reported by the running instance, by object key
+}
+
+// NewImplRepository creates an implementation repository served by the ORB
+func NewImplRepository(orb *ORB) *ImplRepository {
+	client := orb.CreateClient()
+	client.SetRequestTimeout(imrReachableTimeout)
+	return &ImplRepository{
+		orb:            orb,
+		client:         client,
+		servers:        make(map[string]*imrServer),
+		poas:           make(map[string]string),
+		startupTimeout: DefaultServerStartupTimeout,
+	}
+}
+
+// Start creates a server on ep that handles registrations and forwards clients
+func (imr *ImplRepository) Start(ep Endpoint) (*Server, error) {
+	server, err := imr.orb.CreateServerOn(ep)
+	if err != nil {
+		return nil, err
+	}
+
+	server.mu.Lock()
+	server.implRepository = imr
+	server.mu.Unlock()
+
+	if err := server.Run(); err != nil {
+		return nil, err
+	}
+
+	imr.mu.Lock()
+	imr.endpoint = publish(server.Endpoint(), imr.orb.PublishedHost())
+	imr.mu.Unlock()
+	return server, nil
+}
+
+// AddServer makes a server known to the repository, so that it can be launched
+// before it has ever registered
+func (imr *ImplRepository) AddServer(def ServerDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("server definition has no name")
+	}
+
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	if _, exists := imr.servers[def.Name]; exists {
+		return fmt.Errorf("server %q is already defined", def.Name)
+	}
+	for _, path := range def.POAs {
+		if owner, exists := imr.poas[path]; exists {
+			return fmt.Errorf("POA %q already belongs to server %q", path, owner)
+		}
+	}
+
+	imr.servers[def.Name] = &imrServer{def: def, started: make(chan struct{})}
+	for _, path := range def.POAs {
+		imr.poas[path] = def.Name
+	}
+	return nil
+}
+
+// RemoveServer forgets a server and its POAs. A running instance is not stopped.
+func (imr *ImplRepository) RemoveServer(name string) error {
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	srv, exists := imr.servers[name]
+	if !exists {
+		return fmt.Errorf("unknown server %q", name)
+	}
+	for _, path := range srv.def.POAs {
+		delete(imr.poas, path)
+	}
+	delete(imr.servers, name)
+	return nil
+}
+
+// ServerEndpoint returns the endpoint a server has registered, if it is running
+func (imr *ImplRepository) ServerEndpoint(name string) (Endpoint, bool) {
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	srv, exists := imr.servers[name]
+	if !exists || srv.endpoint.IsZero() {
+		return Endpoint{}, false
+	}
+	return srv.endpoint, true
+}
+
+// SetStartupTimeout sets how long to wait for a launched server to register
+func (imr *ImplRepository) SetStartupTimeout(d time.Duration) {
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	imr.startupTimeout = d
+}
+
+// handles reports whether requests for an object key are answered by the
+// repository: registrations and requests for the POAs of known servers
+func (imr *ImplRepository) handles(objectKey []byte) bool {
+	if string(objectKey) == ImplRepositoryObjectKey {
+		return true
+	}
+	_, ok := imr.serverForKey(objectKey)
+	return ok
+}
+
+// serverForKey returns the name of the server hosting the persistent POA an
+// object key belongs to
+func (imr *ImplRepository) serverForKey(objectKey []byte) (string, bool) {
+	if !IsPOAObjectKey(objectKey) {
+		return "", false
+	}
+	key, err := DecodeObjectKey(objectKey)
+	if err != nil || !key.Persistent {
+		return "", false
+	}
+
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	name, ok := imr.poas[strings.Join(key.POAPath, imrPOAPathSeparator)]
+	return name, ok
+}
+
+// serveRequest answers a request handled by the repository
+func (imr *ImplRepository) serveRequest(s *Server, conn *serverConn, request *giop.RequestHeader) {
+	if string(request.ObjectKey) == ImplRepositoryObjectKey {
+		if ex := imr.serveRegistration(request.Operation, request.Body, bodyByteOrder(request.ByteOrder)); ex != nil {
+			s.sendExceptionReply(conn, request.RequestID, ex)
+			return
+		}
+		s.sendSuccessReply(conn, request.RequestID, nil)
+		return
+	}
+
+	ior, ex := imr.forward(request.ObjectKey)
+	if ex != nil {
+		s.sendExceptionReply(conn, request.RequestID, ex)
+		return
+	}
+	s.sendForwardReply(conn, request.RequestID, ior)
+}
+
+// serveLocate answers a LocateRequest handled by the repository
+func (imr *ImplRepository) serveLocate(s *Server, conn *serverConn, request *giop.LocateRequestHeader) {
+	if string(request.ObjectKey) == ImplRepositoryObjectKey {
+		s.sendLocateReply(conn, request.RequestID, giop.LocateStatusObjectHere, nil)
+		return
+	}
+
+	ior, ex := imr.forward(request.ObjectKey)
+	if ex != nil {
+		if IsSystemException(ex) && ex.Name() == "OBJECT_NOT_EXIST" {
+			s.sendLocateReply(conn, request.RequestID, giop.LocateStatusUnknownObject, nil)
+			return
+		}
+		// Locate replies carry a system exception, which always marshals
+		if !IsSystemException(ex) {
+			ex = UNKNOWN(MinorGoError, CompletionStatusNo)
+		}
+		data, _ := MarshalException(ex)
+		s.sendLocateReply(conn, request.RequestID, giop.LocateStatusLOC_SYSTEM_EXCEPTION, data)
+		return
+	}
+	s.sendLocateReply(conn, request.RequestID, giop.LocateStatusObjectForward, ior.Encode())
+}
+
+// serveRegistration handles an operation of the registration interface. The
+// arguments are CDR strings in the byte order of the request.
+func (imr *ImplRepository) serveRegistration(operation string, body []byte, order binary.ByteOrder) Exception {
+	u := giop.NewCDRUnmarshaller(body, order)
+
+	switch operation {
+	case imrRegisterPOA:
+		name, err1 := u.ReadString()
+		path, err2 := u.ReadString()
+		endpoint, err3 := u.ReadString()
+		token, err4 := u.ReadString()
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return MARSHAL(MinorMalformedRequest, CompletionStatusNo)
+		}
+		ep, err := ParseEndpoint(endpoint)
+		if err != nil {
+			return BAD_PARAM(MinorInvalidEndpoint, CompletionStatusNo)
+		}
+		return imr.register(name, path, ep, token)
+
+	case imrUnregisterServer:
+		name, err1 := u.ReadString()
+		token, err2 := u.ReadString()
+		if err1 != nil || err2 != nil {
+			return MARSHAL(MinorMalformedRequest, CompletionStatusNo)
+		}
+		return imr.unregister(name, token)
+
+	default:
+		return BAD_OPERATION(MinorOperationUnknown, CompletionStatusNo)
+	}
+}
+
+// register records that a running server hosts a persistent POA at an endpoint.
+// Servers that were not defined beforehand are added on their first registration.
+// Registrations for a server that runs or was launched must carry the token
+// of that instance.
+func (imr *ImplRepository) register(name, path string, ep Endpoint, token string) Exception {
+	if !imr.claim(name, token) {
+		return NO_PERMISSION(MinorAccessDenied, CompletionStatusNo)
+	}
+
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	if owner, exists := imr.poas[path]; exists && owner != name {
+		return BAD_PARAM(MinorAdapterPathInUse, CompletionStatusNo)
+	}
+
+	srv, exists := imr.servers[name]
+	if !exists {
+		srv = &imrServer{def: ServerDefinition{Name: name}, started: make(chan struct{})}
+		imr.servers[name] = srv
+	}
+	if srv.token != token {
+		// The instance may have changed since it was claimed
+		if srv.process != nil || !srv.endpoint.IsZero() {
+			return NO_PERMISSION(MinorAccessDenied, CompletionStatusNo)
+		}
+		srv.token = token
+	}
+	if _, exists := imr.poas[path]; !exists {
+		imr.poas[path] = name
+		srv.def.POAs = append(srv.def.POAs, path)
+	}
+
+	if srv.endpoint.IsZero() {
+		close(srv.started)
+	}
+	if srv.endpoint != ep {
+		srv.verified = false
+		srv.typeIDs = nil
+	}
+	srv.endpoint = ep
+	return nil
+}
+
+// claim reports whether an instance presenting token may register under a
+// server name: the server is unknown or stopped, or token is the one of its
+// running or launched instance. A running instance that cannot be reached is
+// considered stopped.
+func (imr *ImplRepository) claim(name, token string) bool {
+	imr.mu.Lock()
+	srv, exists := imr.servers[name]
+	if !exists || srv.token == token || srv.process == nil && srv.endpoint.IsZero() {
+		imr.mu.Unlock()
+		return true
+	}
+	if srv.process != nil {
+		imr.mu.Unlock()
+		return false
+	}
+	ep := srv.endpoint
+	imr.mu.Unlock()
+
+	if imr.reachable(ep) {
+		return false
+	}
+
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+	if srv.endpoint == ep {
+		srv.stopped()
+	}
+	return true
+}
+
+// unregister records that a server has stopped. Only the running instance
+// may unregister it.
+func (imr *ImplRepository) unregister(name, token string) Exception {
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	srv, exists := imr.servers[name]
+	if !exists || srv.endpoint.IsZero() {
+		return nil
+	}
+	if srv.token != token {
+		return NO_PERMISSION(MinorAccessDenied, CompletionStatusNo)
+	}
+	srv.stopped()
+	return nil
+}
+
+// stopped resets the state of a server whose instance is gone. The caller must
+// hold the repository's lock.
+func (srv *imrServer) stopped() {
+	if !srv.endpoint.IsZero() {
+		srv.endpoint = Endpoint{}
+		srv.started = make(chan struct{})
+	}
+	srv.verified = false
+	srv.typeIDs = nil
+}
+
+// forward returns the reference clients are forwarded to for an object key
+func (imr *ImplRepository) forward(objectKey []byte) (*IOR, Exception) {
+	name, ok := imr.serverForKey(objectKey)
+	if !ok {
+		return nil, OBJECT_NOT_EXIST(MinorServerUnknown, CompletionStatusNo)
+	}
+
+	ep, ex := imr.activeEndpoint(name)
+	if ex != nil {
+		return nil, ex
+	}
+
+	transport, err := imr.orb.GetTransport(ep.Protocol)
+	if err != nil {
+		transport = profileTransport(ep.Protocol)
+	}
+	ior := NewIOR(imr.typeID(name, ep, objectKey))
+	ior.Profiles = append(ior.Profiles, transport.CreateProfile(ep, objectKey))
+	return ior, nil
+}
+
+// typeID returns the repository ID of an object, as reported by the instance
+// of a server running at ep, or the ID of CORBA::Object if it cannot tell.
+// The IDs reported are kept until the instance stops.
+func (imr *ImplRepository) typeID(name string, ep Endpoint, objectKey []byte) string {
+	imr.mu.Lock()
+	srv, exists := imr.servers[name]
+	if exists && srv.endpoint == ep {
+		if id, ok := srv.typeIDs[string(objectKey)]; ok {
+			imr.mu.Unlock()
+			return id
+		}
+	}
+	imr.mu.Unlock()
+
+	ref := imr.client.referenceAt(objectLocation{endpoint: ep, objectKey: objectKey})
+	id, err := ref.RepositoryID()
+	if err != nil || id == "" {
+		return ObjectTypeID
+	}
+
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+	if exists && srv.endpoint == ep {
+		if srv.typeIDs == nil {
+			srv.typeIDs = make(map[string]string)
+		}
+		srv.typeIDs[string(objectKey)] = id
+	}
+	return id
+}
+
+// activeEndpoint returns the endpoint of a running instance of a server. A
+// registered instance is checked to be reachable once after it registers, and
+// considered stopped if it is not. Stopped servers with a command are launched
+// and waited for.
+func (imr *ImplRepository) activeEndpoint(name string) (Endpoint, Exception) {
+	imr.mu.Lock()
+	srv, exists := imr.servers[name]
+	if !exists {
+		imr.mu.Unlock()
+		return Endpoint{}, OBJECT_NOT_EXIST(MinorServerUnknown, CompletionStatusNo)
+	}
+
+	if ep := srv.endpoint; !ep.IsZero() {
+		if srv.verified {
+			imr.mu.Unlock()
+			return ep, nil
+		}
+		imr.mu.Unlock()
+		reachable := imr.reachable(ep)
+		imr.mu.Lock()
+		if reachable {
+			if srv.endpoint == ep {
+				srv.verified = true
+			}
+			imr.mu.Unlock()
+			return ep, nil
+		}
+		if srv.endpoint == ep {
+			srv.stopped()
+		}
+	}
+
+	if srv.def.Command == "" {
+		imr.mu.Unlock()
+		return Endpoint{}, TRANSIENT(MinorServerNotRunning, CompletionStatusNo)
+	}
+	if srv.process == nil {
+		if err := imr.launch(srv); err != nil {
+			imr.mu.Unlock()
+			fmt.Printf("Error launching server %s: %v\n", name, err)
+			return Endpoint{}, TRANSIENT(MinorServerLaunchFailed, CompletionStatusNo)
+		}
+	}
+	started := srv.started
+	timeout := imr.startupTimeout
+	imr.mu.Unlock()
+
+	select {
+	case <-started:
+	case <-time.After(timeout):
+		return Endpoint{}, TRANSIENT(MinorServerStartupTimeout, CompletionStatusNo)
+	}
+
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+	if srv.endpoint.IsZero() {
+		return Endpoint{}, TRANSIENT(MinorServerNotRunning, CompletionStatusNo)
+	}
+	return srv.endpoint, nil
+}
+
+// launch starts a server's command. The caller must hold the repository's lock.
+func (imr *ImplRepository) launch(srv *imrServer) error {
+	token, err := newImplRepositoryToken()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(srv.def.Command, srv.def.Args...)
+	cmd.Env = append(os.Environ(),
+		ImplRepositoryEndpointEnv+"="+imr.endpoint.String(),
+		ImplRepositoryServerEnv+"="+srv.def.Name,
+		ImplRepositoryTokenEnv+"="+token,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	srv.process = cmd
+	srv.token = token
+
+	go func() {
+		cmd.Wait()
+
+		imr.mu.Lock()
+		defer imr.mu.Unlock()
+		if srv.process == cmd {
+			srv.process = nil
+			srv.stopped()
+		}
+	}()
+	return nil
+}
+
+// reachable reports whether a connection to an endpoint can be established
+func (imr *ImplRepository) reachable(ep Endpoint) bool {
+	transport, err := imr.orb.GetTransport(ep.Protocol)
+	if err != nil {
+		transport = profileTransport(ep.Protocol)
+	}
+
+	done := make(chan bool, 1)
+	go func() {
+		conn, err := transport.Connect(ep)
+		if err == nil {
+			conn.Close()
+		}
+		done <- err == nil
+	}()
+
+	select {
+	case ok := <-done:
+		return ok
+	case <-time.After(imrReachableTimeout):
+		return false
+	}
+}
+
+// submitIMRRequest runs a request handled by the repository on the worker pool,
+// since forwarding may wait for a server to start
+func (s *Server) submitIMRRequest(conn *serverConn, requestID uint32, serve func()) {
+	if !s.beginRequest(conn) {
+		s.sendExceptionReply(conn, requestID, TRANSIENT(MinorServerShuttingDown, CompletionStatusNo))
+		return
+	}
+
+	s.orb.workers.submit(func() {
+		defer s.endRequest(conn)
+		serve()
+	})
+}
+
+// imrBinding is the implementation repository an ORB's persistent POAs use
+type imrBinding struct {
+	endpoint Endpoint
+	server   string
+	token    string // Identifies this instance of the server to the repository
+}
+
+// newImplRepositoryToken returns a random token identifying a server instance
+func newImplRepositoryToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// UseImplRepository makes the ORB's PERSISTENT POAs register with the
+// implementation repository at ep under serverName. Registration happens when
+// a server starts running and when a persistent POA is created while one is
+// running. References created by persistent POAs point at the repository.
+// The repository refuses the registration while another instance of the
+// server is running.
+func (orb *ORB) UseImplRepository(ep Endpoint, serverName string) error {
+	token, err := newImplRepositoryToken()
+	if err != nil {
+		return err
+	}
+	orb.useImplRepository(ep, serverName, token)
+	return nil
+}
+
+// useImplRepository binds the ORB to a repository with the token of the instance
+func (orb *ORB) useImplRepository(ep Endpoint, serverName, token string) {
+	orb.mu.Lock()
+	defer orb.mu.Unlock()
+
+	orb.implRepository = &imrBinding{endpoint: ep, server: serverName, token: token}
+}
+
+// UseImplRepositoryFromEnv configures the implementation repository from the
+// environment variables set for servers launched by a repository, including
+// the token the repository expects from the launched instance. It reports
+// whether the variables were present.
+func (orb *ORB) UseImplRepositoryFromEnv() (bool, error) {
+	endpoint, name := os.Getenv(ImplRepositoryEndpointEnv), os.Getenv(ImplRepositoryServerEnv)
+	if endpoint == "" || name == "" {
+		return false, nil
+	}
+
+	ep, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return false, err
+	}
+	if token := os.Getenv(ImplRepositoryTokenEnv); token != "" {
+		orb.useImplRepository(ep, name, token)
+		return true, nil
+	}
+	return true, orb.UseImplRepository(ep, name)
+}
+
+// implRepositoryBinding returns the repository the ORB uses, or nil
+func (orb *ORB) implRepositoryBinding() *imrBinding {
+	orb.mu.RLock()
+	defer orb.mu.RUnlock()
+
+	return orb.implRepository
+}
+
+// registerPersistentPOAs registers every persistent POA of the ORB with its
+// implementation repository
+func (orb *ORB) registerPersistentPOAs() error {
+	if orb.implRepositoryBinding() == nil {
+		return nil
+	}
+
+	orb.mu.RLock()
+	root := orb.rootPOA
+	orb.mu.RUnlock()
+	if root == nil {
+		return nil
+	}
+
+	for _, poa := range root.descendants() {
+		if poa.lifespan != PersistentLifespan {
+			continue
+		}
+		if err := orb.registerPOA(poa); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerPOA registers a persistent POA with the ORB's implementation
+// repository. Nothing is registered while no server is running.
+func (orb *ORB) registerPOA(poa *POA) error {
+	binding := orb.implRepositoryBinding()
+	if binding == nil {
+		return nil
+	}
+	published := orb.publishedEndpoints(true)
+	if len(published) == 0 {
+		return nil
+	}
+
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	m.WriteString(binding.server)
+	m.WriteString(strings.Join(poa.path(), imrPOAPathSeparator))
+	m.WriteString(published[0].endpoint.String())
+	m.WriteString(binding.token)
+
+	if err := orb.callImplRepository(binding.endpoint, imrRegisterPOA, m.Bytes()); err != nil {
+		return fmt.Errorf("failed to register POA %s with the implementation repository: %w", poa.name, err)
+	}
+	return nil
+}
+
+// unregisterFromImplRepository tells the ORB's implementation repository that
+// the server is stopping. Errors are ignored, since the repository also
+// detects servers that cannot be reached.
+func (orb *ORB) unregisterFromImplRepository() {
+	binding := orb.implRepositoryBinding()
+	if binding == nil {
+		return
+	}
+
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	m.WriteString(binding.server)
+	m.WriteString(binding.token)
+	orb.callImplRepository(binding.endpoint, imrUnregisterServer, m.Bytes())
+}
+
+// callImplRepository invokes an operation of a repository's registration interface
+func (orb *ORB) callImplRepository(ep Endpoint, operation string, args []byte) error {
+	client := orb.CreateClient()
+	defer client.DisconnectEndpoint(ep)
+
+	requestID := client.NextRequestID()
+	request := giop.NewRequestMessage(requestID, ObjectKeyFromString(ImplRepositoryObjectKey), operation, true)
+	request.Body.(*giop.RequestHeader).Body = args
+
+	data, err := giop.MarshalGIOPMessage(request)
+	if err != nil {
+		return err
+	}
+	msg, err := client.exchange(ep, requestID, data)
+	if err != nil {
+		return err
+	}
+
+	reply, ok := msg.Body.(*giop.ReplyHeader)
+	if !ok || reply.RequestID != requestID {
+		return fmt.Errorf("unexpected reply from the implementation repository")
+	}
+	if reply.ReplyStatus != giop.ReplyStatusNoException {
+		ex, err := client.handleExceptionReply(reply, nil)
+		if err != nil {
+			return err
+		}
+		return ex
+	}
+	return nil
+}
+
+// descendants returns the POA and all POAs below it
+func (p *POA) descendants() []*POA {
+	p.mutex.RLock()
+	children := make([]*POA, 0, len(p.children))
+	for _, child := range p.children {
+		children = append(children, child)
+	}
+	p.mutex.RUnlock()
+
+	poas := []*POA{p}
+	for _, child := range children {
+		poas = append(poas, child.descendants()...)
+	}
+	return poas
+}
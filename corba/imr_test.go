@@ -0,0 +1,230 @@
+package corba_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// startImplRepository runs an implementation repository on an in-memory endpoint
+func startImplRepository(t *testing.T, name string) (*corba.ImplRepository, corba.Endpoint) {
+	t.Helper()
+
+	imr := corba.NewImplRepository(corba.Init())
+	server, err := imr.Start(corba.NewMemEndpoint(name))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { server.Shutdown() })
+	return imr, server.Endpoint()
+}
+
+// startBankServer runs a server ORB that registers its persistent "Bank" POA
+// with the repository, and activates acct-1 in it
+func startBankServer(t *testing.T, imrEp corba.Endpoint, name string) (*corba.ORB, *corba.POA, *recordingServant) {
+	t.Helper()
+
+	orb := corba.Init()
+	orb.UseImplRepository(imrEp, "bank")
+	poaTestServer(t, orb, name)
+
+	bank := persistentPOA(t, orb)
+	servant := &recordingServant{calls: make(chan string, 1)}
+	if err := bank.ActivateObjectWithID(corba.ObjectID("acct-1"), servant); err != nil {
+		t.Fatalf("ActivateObjectWithID: %v", err)
+	}
+	return orb, bank, servant
+}
+
+func TestImplRepositoryForwardsClients(t *testing.T) {
+	imr, imrEp := startImplRepository(t, "imr-forward")
+	orb1, bank, servant1 := startBankServer(t, imrEp, "bank-forward-1")
+
+	if ep, ok := imr.ServerEndpoint("bank"); !ok || ep != corba.NewMemEndpoint("bank-forward-1") {
+		t.Fatalf("registered endpoint = %v, %v", ep, ok)
+	}
+	ref := bank.CreateReferenceWithId(corba.ObjectID("acct-1"), "IDL:Test/Account:1.0")
+	if ref.Endpoint() != imrEp {
+		t.Fatalf("persistent reference points at %v, want the repository at %v", ref.Endpoint(), imrEp)
+	}
+	ior, err := orb1.ObjectToString(ref)
+	if err != nil {
+		t.Fatalf("ObjectToString: %v", err)
+	}
+
+	// A LocateRequest to the repository is answered with OBJECT_FORWARD
+	conn, err := corba.NewMemTransport().Connect(imrEp)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	data, _ := giop.MarshalGIOPMessage(&giop.Message{
+		Header: giop.NewMessageHeader(giop.MsgLocateRequest, 0),
+		Body:   &giop.LocateRequestHeader{RequestID: 7, ObjectKey: ref.GetObjectKey()},
+	})
+	conn.Write(data)
+	reply, ok := readRawMessage(t, conn).Body.(*giop.LocateReplyHeader)
+	if !ok || reply.Status != giop.LocateStatusObjectForward {
+		t.Fatalf("locate reply = %+v, want OBJECT_FORWARD", reply)
+	}
+	forward, err := corba.DecodeIOR(reply.Body)
+	if err != nil {
+		t.Fatalf("DecodeIOR: %v", err)
+	}
+	if ep, _, err := corba.Init().EndpointFromIOR(forward); err != nil || ep != corba.NewMemEndpoint("bank-forward-1") {
+		t.Fatalf("forwarded to %v (%v)", ep, err)
+	}
+
+	// Requests through the reference are forwarded to the server
+	client := corba.Init()
+	target, err := client.StringToObject(ior)
+	if err != nil {
+		t.Fatalf("StringToObject: %v", err)
+	}
+	if _, err := target.Invoke("balance"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if op := <-servant1.calls; op != "balance" {
+		t.Errorf("servant received %q", op)
+	}
+
+	// After a restart on another endpoint the same reference reaches the new instance
+	orb1.Shutdown(true)
+	if _, ok := imr.ServerEndpoint("bank"); ok {
+		t.Fatal("server still registered after shutdown")
+	}
+	_, _, servant2 := startBankServer(t, imrEp, "bank-forward-2")
+	if _, err := target.Invoke("deposit"); err != nil {
+		t.Fatalf("Invoke after restart: %v", err)
+	}
+	if op := <-servant2.calls; op != "deposit" {
+		t.Errorf("restarted servant received %q", op)
+	}
+}
+
+func TestImplRepositoryStoppedServer(t *testing.T) {
+	imr, imrEp := startImplRepository(t, "imr-stopped")
+	imr.SetStartupTimeout(100 * time.Millisecond)
+	if err := imr.AddServer(corba.ServerDefinition{Name: "manual", POAs: []string{"Bank"}}); err != nil {
+		t.Fatalf("AddServer: %v", err)
+	}
+	if err := imr.AddServer(corba.ServerDefinition{Name: "other", POAs: []string{"Bank"}}); err == nil {
+		t.Error("two servers claimed the same POA")
+	}
+
+	key := corba.ObjectKey{POAPath: []string{"Bank"}, Persistent: true, ObjectID: corba.ObjectID("acct-1")}
+	target, err := corba.Init().CreateClient().GetObjectAt(string(key.Encode()), imrEp)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// Without a command the repository cannot start the server
+	_, err = target.Invoke("balance")
+	wantSystemException(t, err, "TRANSIENT")
+
+	// A server whose command cannot be started fails the same way
+	imr.AddServer(corba.ServerDefinition{Name: "broken", Command: "/nonexistent/server", POAs: []string{"Broken"}})
+	broken := corba.ObjectKey{POAPath: []string{"Broken"}, Persistent: true, ObjectID: corba.ObjectID("x")}
+	target, _ = corba.Init().CreateClient().GetObjectAt(string(broken.Encode()), imrEp)
+	_, err = target.Invoke("balance")
+	wantSystemException(t, err, "TRANSIENT")
+
+	// Unknown POAs are not forwarded
+	unknown := corba.ObjectKey{POAPath: []string{"Nowhere"}, Persistent: true, ObjectID: corba.ObjectID("x")}
+	target, _ = corba.Init().CreateClient().GetObjectAt(string(unknown.Encode()), imrEp)
+	_, err = target.Invoke("balance")
+	wantSystemException(t, err, "OBJECT_NOT_EXIST")
+}
+
+// repositoryIDCounter counts the _repository_id requests a server receives
+type repositoryIDCounter struct {
+	calls atomic.Int32
+}
+
+func (c *repositoryIDCounter) Name() string { return "repository-id-counter" }
+
+func (c *repositoryIDCounter) ReceiveRequest(info *corba.RequestInfo) error {
+	if info.Operation == "_repository_id" {
+		c.calls.Add(1)
+	}
+	return nil
+}
+
+func (c *repositoryIDCounter) SendReply(info *corba.RequestInfo) error { return nil }
+
+func (c *repositoryIDCounter) SendException(info *corba.RequestInfo, ex corba.Exception) error {
+	return nil
+}
+
+func TestImplRepositoryForwardKeepsTypeID(t *testing.T) {
+	_, imrEp := startImplRepository(t, "imr-typeid")
+	orb, bank, _ := startBankServer(t, imrEp, "bank-typeid")
+	if err := bank.ActivateObjectWithID(corba.ObjectID("acct-2"), &accountServant{}); err != nil {
+		t.Fatalf("ActivateObjectWithID: %v", err)
+	}
+	counter := &repositoryIDCounter{}
+	orb.RegisterServerRequestInterceptor(counter)
+
+	conn, err := corba.NewMemTransport().Connect(imrEp)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	key := corba.ObjectKey{POAPath: []string{"Bank"}, Persistent: true, ObjectID: corba.ObjectID("acct-2")}
+	for id := uint32(1); id <= 3; id++ {
+		data, _ := giop.MarshalGIOPMessage(&giop.Message{
+			Header: giop.NewMessageHeader(giop.MsgLocateRequest, 0),
+			Body:   &giop.LocateRequestHeader{RequestID: id, ObjectKey: key.Encode()},
+		})
+		conn.Write(data)
+		reply, ok := readRawMessage(t, conn).Body.(*giop.LocateReplyHeader)
+		if !ok || reply.Status != giop.LocateStatusObjectForward {
+			t.Fatalf("locate reply = %+v, want OBJECT_FORWARD", reply)
+		}
+		forward, err := corba.DecodeIOR(reply.Body)
+		if err != nil {
+			t.Fatalf("DecodeIOR: %v", err)
+		}
+		if forward.TypeID != "IDL:Bank/Account:1.0" {
+			t.Errorf("forward type ID = %q", forward.TypeID)
+		}
+	}
+
+	// The repository asks the running server for the type only once
+	if n := counter.calls.Load(); n != 1 {
+		t.Errorf("server asked for the type ID %d times, want 1", n)
+	}
+}
+
+func TestImplRepositoryRejectsImpostors(t *testing.T) {
+	imr, imrEp := startImplRepository(t, "imr-impostor")
+	orb1, _, _ := startBankServer(t, imrEp, "bank-impostor-1")
+
+	// Another process cannot take over the name of a running server
+	impostor := corba.Init()
+	impostor.UseImplRepository(imrEp, "bank")
+	poaTestServer(t, impostor, "bank-impostor-2")
+	_, err := impostor.GetRootPOA().CreatePOA("Bank", nil, []corba.POAPolicy{
+		corba.NewLifespanPolicy(corba.PersistentLifespan),
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+	})
+	wantSystemException(t, errors.Unwrap(err), "NO_PERMISSION")
+	if ep, _ := imr.ServerEndpoint("bank"); ep != corba.NewMemEndpoint("bank-impostor-1") {
+		t.Fatalf("server endpoint = %v after impostor registration", ep)
+	}
+
+	// Nor can it unregister the running server
+	impostor.Shutdown(true)
+	if _, ok := imr.ServerEndpoint("bank"); !ok {
+		t.Fatal("impostor unregistered the running server")
+	}
+
+	orb1.Shutdown(true)
+	if _, ok := imr.ServerEndpoint("bank"); ok {
+		t.Fatal("server still registered after shutdown")
+	}
+}
@@ -244,7 +244,7 @@ func (i *SecurityInterceptor) ReceiveRequest(info *RequestInfo) error {
 
 	// Validate token
 	if token != i.authToken {
-		return OBJECT_NOT_EXIST(1, CompletionStatusNo)
+		return OBJECT_NOT_EXIST(MinorAuthenticationFailed, CompletionStatusNo)
 	}
 
 	// Check if method has role requirements
@@ -476,7 +476,7 @@ func (i *ParameterValidationInterceptor) AddValidator(operation string, validato
 func (i *ParameterValidationInterceptor) SendRequest(info *RequestInfo) error {
 	if validator, ok := i.validators[info.Operation]; ok {
 		if err := validator(info.Arguments); err != nil {
-			return BAD_PARAM(1, CompletionStatusNo)
+			return BAD_PARAM(MinorInvalidArgument, CompletionStatusNo)
 		}
 	}
 	return nil
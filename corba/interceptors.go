@@ -3,6 +3,7 @@ package corba
 
 import (
 	"context"
+	"fmt"
 	"sync"
 )
 
@@ -56,6 +57,120 @@ type RequestInfo struct {
 	Servant interface{}
 	// Adapter that received the request
 	Adapter string
+
+	// The reference the client invoked, and the reference and profile the
+	// request is actually sent to after any location forwards. Set on the
+	// client side only.
+	Target           *ObjectRef
+	EffectiveTarget  *ObjectRef
+	EffectiveProfile TaggedProfile
+	// For LOCATION_FORWARD replies, the reference the request is forwarded to
+	ForwardReference *ObjectRef
+	// The outcome of the request, once a reply was received or is being sent
+	ReplyStatus ReplyStatus
+	// How far the request travels before a oneway invocation returns
+	SyncScope SyncScope
+
+	// slots is the request's PICurrent slot table
+	slots []interface{}
+}
+
+// ReplyStatus is the outcome of a request as seen by interceptors
+type ReplyStatus int16
+
+// Reply statuses defined by PortableInterceptor
+const (
+	ReplySuccessful ReplyStatus = iota
+	ReplySystemException
+	ReplyUserException
+	ReplyLocationForward
+	ReplyTransportRetry
+)
+
+// SyncScope tells how far a request has travelled when its invocation returns
+type SyncScope int16
+
+// Synchronization scopes defined by Messaging
+const (
+	SyncNone SyncScope = iota
+	SyncWithTransport
+	SyncWithServer
+	SyncWithTarget
+)
+
+// replyStatusOf returns the reply status of a request that raised ex, or
+// ReplySuccessful if ex is nil
+func replyStatusOf(ex Exception) ReplyStatus {
+	switch {
+	case ex == nil:
+		return ReplySuccessful
+	case IsUserException(ex):
+		return ReplyUserException
+	default:
+		return ReplySystemException
+	}
+}
+
+// GetSlot returns the data in a PICurrent slot of the request. On the client
+// side the slots hold the PICurrent of the invocation; on the server side they
+// start empty and are set by the interceptors.
+func (info *RequestInfo) GetSlot(id SlotID) (interface{}, error) {
+	if int(id) >= len(info.slots) {
+		return nil, ErrInvalidSlot
+	}
+	return info.slots[id], nil
+}
+
+// SetSlot sets the data in a PICurrent slot of the request. Slots set by
+// server interceptors before the servant is invoked are visible to it
+// through PICurrentFromContext.
+func (info *RequestInfo) SetSlot(id SlotID, data interface{}) error {
+	if int(id) >= len(info.slots) {
+		return ErrInvalidSlot
+	}
+	info.slots[id] = data
+	return nil
+}
+
+// ForwardRequest redirects a request to another object. Server interceptors,
+// servant activators and servant locators raise it to have the ORB answer
+// with a LOCATION_FORWARD reply carrying the reference; client interceptors
+// raise it to have the request retried at the reference.
+type ForwardRequest struct {
+	Forward *ObjectRef
+}
+
+// NewForwardRequest creates a ForwardRequest to a reference
+func NewForwardRequest(forward *ObjectRef) *ForwardRequest {
+	return &ForwardRequest{Forward: forward}
+}
+
+// Error implements the error interface
+func (e *ForwardRequest) Error() string {
+	if e.Forward.IsNil() {
+		return "CORBA User Exception: ForwardRequest to a nil reference"
+	}
+	return fmt.Sprintf("CORBA User Exception: ForwardRequest to %s", e.Forward.Endpoint())
+}
+
+// ID returns the repository ID of the exception
+func (e *ForwardRequest) ID() string {
+	return "IDL:omg.org/PortableInterceptor/ForwardRequest:1.0"
+}
+
+// Name returns the name of the exception
+func (e *ForwardRequest) Name() string {
+	return "ForwardRequest"
+}
+
+// Minor returns 0, as user exceptions have no minor code
+func (e *ForwardRequest) Minor() uint32 {
+	return 0
+}
+
+// Completed returns CompletionStatusNo: forwarded requests were not processed
+func (e *ForwardRequest) Completed() CompletionStatus {
+	return CompletionStatusNo
 }
 
 // ServiceContext represents a service context entry
@@ -81,6 +196,26 @@ type ServerRequestInterceptor interface {
 	SendException(info *RequestInfo, ex Exception) error
 }
 
+// ServiceContextsInterceptor is implemented by server request interceptors
+// that want the service contexts of a request before its servant is looked
+// up, for example to set PICurrent slots from them. It corresponds to the
+// receive_request_service_contexts interception point.
+type ServiceContextsInterceptor interface {
+	// ReceiveRequestServiceContexts is called before the servant is located.
+	// The Servant of info is not set yet.
+	ReceiveRequestServiceContexts(info *RequestInfo) error
+}
+
+// SendOtherInterceptor is implemented by server request interceptors that
+// want to see requests answered with a LOCATION_FORWARD reply, because a
+// ForwardRequest was raised. It corresponds to the send_other interception
+// point.
+type SendOtherInterceptor interface {
+	// SendOther is called before a LOCATION_FORWARD reply is sent. The
+	// ForwardReference of info is set.
+	SendOther(info *RequestInfo) error
+}
+
 // ClientRequestInterceptor is invoked during client-side request processing
 type ClientRequestInterceptor interface {
 	// Name returns the name of the interceptor
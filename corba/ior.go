@@ -49,6 +49,10 @@ const (
 	TAG_MULTIPLE_COMPONENTS uint32 = 1 // For multiple components
 	TAG_SCCP_IOP            uint32 = 2 // For SCCP transport
 	TAG_UIPMC               uint32 = 3 // For unreliable multicast
+
+	// Vendor profile tags
+	TAG_UIOP    uint32 = 0x54414F00 // IIOP over Unix domain sockets (same tag as TAO's UIOP)
+	TAG_MEM_IOP uint32 = 0x474F4300 // In-process pipe transport, only meaningful within one process
 )
 
 // Known component tags from the CORBA specification
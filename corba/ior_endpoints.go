@@ -0,0 +1,224 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"net"
+)
+
+// publishedEndpoint is an endpoint advertised in IORs together with the
+// transport that builds its profile
+type publishedEndpoint struct {
+	endpoint  Endpoint
+	transport Transport
+}
+
+// SetPublishedHost sets the host name or address advertised in the TCP and TLS
+// profiles of new object references, instead of the address the servers listen
+// on. Use it when clients reach the server through NAT or a container network.
+// An empty host restores the default.
+func (orb *ORB) SetPublishedHost(host string) {
+	orb.mu.Lock()
+	defer orb.mu.Unlock()
+
+	orb.publishedHost = host
+}
+
+// PublishedHost returns the host set with SetPublishedHost
+func (orb *ORB) PublishedHost() string {
+	orb.mu.RLock()
+	defer orb.mu.RUnlock()
+
+	return orb.publishedHost
+}
+
+// Endpoints returns the endpoints advertised in new object references: one per
+// running server, in creation order, with ports resolved and the published
+// host applied
+func (orb *ORB) Endpoints() []Endpoint {
+	published := orb.publishedEndpoints(false)
+	endpoints := make([]Endpoint, 0, len(published))
+	for _, p := range published {
+		endpoints = append(endpoints, p.endpoint)
+	}
+	return endpoints
+}
+
+// publishedEndpoints returns the advertised endpoints of all running servers.
+// When fixedOnly is set, servers listening on an ephemeral port are left out,
+// unless no server has a fixed port. While no server runs, the servers
+// created with a fixed port advertise the endpoint they will listen on.
+func (orb *ORB) publishedEndpoints(fixedOnly bool) []publishedEndpoint {
+	orb.mu.RLock()
+	servers := append([]*Server(nil), orb.servers...)
+	publishedHost := orb.publishedHost
+	orb.mu.RUnlock()
+
+	if fixedOnly {
+		var fixed []*Server
+		for _, server := range servers {
+			if server.IsRunning() && !server.ephemeral {
+				fixed = append(fixed, server)
+			}
+		}
+		if len(fixed) > 0 {
+			servers = fixed
+		}
+	}
+
+	var published []publishedEndpoint
+	for _, server := range servers {
+		if !server.IsRunning() {
+			continue
+		}
+
+		server.mu.RLock()
+		ep := server.endpoint
+		transport := server.transport
+		server.mu.RUnlock()
+
+		published = append(published, publishedEndpoint{endpoint: publish(ep, publishedHost), transport: transport})
+	}
+	if len(published) > 0 {
+		return published
+	}
+
+	for _, server := range servers {
+		if server.ephemeral {
+			continue
+		}
+
+		server.mu.RLock()
+		ep := server.endpoint
+		transport := server.transport
+		server.mu.RUnlock()
+
+		published = append(published, publishedEndpoint{endpoint: publish(ep, publishedHost), transport: transport})
+	}
+	return published
+}
+
+// createReference builds an object reference whose IOR carries one profile per
+// published endpoint. The first endpoint is used for invocations through the
+// reference. Persistent references skip servers on ephemeral ports, and point
+// at the implementation repository when the ORB uses one. A reference created
+// while no endpoint is published has no profile until one is.
+func (orb *ORB) createReference(typeID string, objectKey []byte, persistent bool) *ObjectRef {
+	ref := &ObjectRef{
+		Name:      ObjectKeyToString(objectKey),
+		ior:       NewIOR(typeID),
+		objectKey: objectKey,
+		typeID:    typeID,
+		client:    orb.CreateClient(),
+	}
+	orb.addProfiles(ref, persistent)
+	return ref
+}
+
+// addProfiles adds a profile per endpoint published for a reference to its IOR
+func (orb *ORB) addProfiles(ref *ObjectRef, persistent bool) {
+	published := orb.publishedEndpoints(persistent)
+	if binding := orb.implRepositoryBinding(); persistent && binding != nil {
+		transport, err := orb.GetTransport(binding.endpoint.Protocol)
+		if err != nil {
+			transport = profileTransport(binding.endpoint.Protocol)
+		}
+		published = []publishedEndpoint{{endpoint: binding.endpoint, transport: transport}}
+	}
+
+	for i, p := range published {
+		ref.ior.Profiles = append(ref.ior.Profiles, p.transport.CreateProfile(p.endpoint, ref.objectKey))
+		if i == 0 {
+			ref.endpoint = p.endpoint
+			ref.ServerHost = p.endpoint.Host
+			ref.ServerPort = p.endpoint.Port
+		}
+	}
+}
+
+// bindProfiles gives a reference created while no endpoint was published the
+// profiles of the endpoints published now. It reports whether the reference
+// has a profile. Concurrent first invocations bind the reference once.
+func (ref *ObjectRef) bindProfiles() bool {
+	if ref.ior == nil {
+		return true
+	}
+	ref.bindMu.Lock()
+	defer ref.bindMu.Unlock()
+
+	if len(ref.ior.Profiles) > 0 {
+		return true
+	}
+	if ref.client == nil || ref.client.orb == nil {
+		return false
+	}
+
+	persistent := false
+	if key, err := DecodeObjectKey(ref.objectKey); err == nil {
+		persistent = key.Persistent
+	}
+	ref.client.orb.addProfiles(ref, persistent)
+	return len(ref.ior.Profiles) > 0
+}
+
+// publish returns the form of a listen endpoint advertised to clients: TCP
+// and TLS hosts are replaced by the published host or an interface address
+func publish(ep Endpoint, publishedHost string) Endpoint {
+	if ep.Protocol == TransportTCP || ep.Protocol == TransportTLS {
+		if publishedHost != "" {
+			ep.Host = publishedHost
+		} else {
+			ep.Host = advertisedHost(ep.Host)
+		}
+	}
+	return ep
+}
+
+// advertisedHost returns the host to publish for a listen host. Unspecified
+// addresses are replaced by an address of one of the machine's interfaces,
+// in the same address family; an empty host prefers IPv4.
+func advertisedHost(host string) string {
+	if host == "" {
+		if addr := interfaceAddress(false); addr != "" {
+			return addr
+		}
+		if addr := interfaceAddress(true); addr != "" {
+			return addr
+		}
+		return "127.0.0.1"
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsUnspecified() {
+		return host
+	}
+
+	ipv6 := ip.To4() == nil
+	if addr := interfaceAddress(ipv6); addr != "" {
+		return addr
+	}
+	if ipv6 {
+		return "::1"
+	}
+	return "127.0.0.1"
+}
+
+// interfaceAddress returns the first global unicast address of the requested
+// family among the machine's interfaces, or an empty string
+func interfaceAddress(ipv6 bool) string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if (ipNet.IP.To4() == nil) == ipv6 {
+			return ipNet.IP.String()
+		}
+	}
+
+	return ""
+}
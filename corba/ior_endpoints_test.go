@@ -0,0 +1,205 @@
+package corba_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// runServer starts a server on ep and shuts it down when the test ends
+func runServer(t *testing.T, orb *corba.ORB, ep corba.Endpoint) *corba.Server {
+	t.Helper()
+
+	server, err := orb.CreateServerOn(ep)
+	if err != nil {
+		t.Fatalf("CreateServerOn: %v", err)
+	}
+	if err := server.Run(); err != nil {
+		t.Skipf("cannot listen on %s: %v", ep, err)
+	}
+	t.Cleanup(func() { server.Shutdown() })
+	return server
+}
+
+func TestReferenceUsesEphemeralPort(t *testing.T) {
+	orb := corba.Init()
+	server := runServer(t, orb, corba.NewTCPEndpoint("127.0.0.1", 0))
+	if server.Endpoint().Port == 0 {
+		t.Fatal("server endpoint still has port 0 after Run")
+	}
+
+	servant := &recordingServant{calls: make(chan string, 1)}
+	root := orb.GetRootPOA()
+	oid, err := root.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref := root.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0")
+	if ref.Endpoint() != server.Endpoint() {
+		t.Fatalf("reference endpoint = %v, want %v", ref.Endpoint(), server.Endpoint())
+	}
+
+	// The stringified reference is directly usable
+	ior, err := orb.ObjectToString(ref)
+	if err != nil {
+		t.Fatalf("ObjectToString: %v", err)
+	}
+	parsed, err := orb.StringToObject(ior)
+	if err != nil {
+		t.Fatalf("StringToObject: %v", err)
+	}
+	if _, err := parsed.Invoke("ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-servant.calls
+}
+
+func TestReferenceListsEveryListener(t *testing.T) {
+	orb := corba.Init()
+	runServer(t, orb, corba.NewTCPEndpoint("127.0.0.1", 0))
+	runServer(t, orb, corba.NewMemEndpoint("endpoints-multi"))
+
+	ref := orb.GetRootPOA().CreateReference("IDL:Test/Echo:1.0", nil)
+	profiles := ref.GetIOR().Profiles
+	if len(profiles) != 2 {
+		t.Fatalf("IOR has %d profiles, want 2", len(profiles))
+	}
+	if profiles[0].Tag != corba.TAG_INTERNET_IOP || profiles[1].Tag != corba.TAG_MEM_IOP {
+		t.Errorf("profile tags = %#x, %#x", profiles[0].Tag, profiles[1].Tag)
+	}
+
+	// ObjectToReference follows the same rules
+	objRef, err := orb.ObjectToReference(&recordingServant{})
+	if err != nil {
+		t.Fatalf("ObjectToReference: %v", err)
+	}
+	if len(objRef.GetIOR().Profiles) != 2 {
+		t.Errorf("ObjectToReference IOR has %d profiles, want 2", len(objRef.GetIOR().Profiles))
+	}
+}
+
+func TestPublishedHostOverride(t *testing.T) {
+	orb := corba.Init()
+	server := runServer(t, orb, corba.NewTCPEndpoint("127.0.0.1", 0))
+	orb.SetPublishedHost("orb.example.com")
+
+	endpoints := orb.Endpoints()
+	if len(endpoints) != 1 {
+		t.Fatalf("got %d endpoints, want 1", len(endpoints))
+	}
+	want := corba.NewTCPEndpoint("orb.example.com", server.Endpoint().Port)
+	if endpoints[0] != want {
+		t.Errorf("published endpoint = %v, want %v", endpoints[0], want)
+	}
+}
+
+func TestUnspecifiedHostKeepsAddressFamily(t *testing.T) {
+	tests := []struct {
+		host string
+		ipv6 bool
+	}{
+		{"0.0.0.0", false},
+		{"::", true},
+	}
+
+	for _, tt := range tests {
+		orb := corba.Init()
+		runServer(t, orb, corba.NewTCPEndpoint(tt.host, 0))
+
+		host := orb.Endpoints()[0].Host
+		ip := net.ParseIP(host)
+		if ip == nil || ip.IsUnspecified() {
+			t.Errorf("listening on %q published host %q", tt.host, host)
+			continue
+		}
+		if (ip.To4() == nil) != tt.ipv6 {
+			t.Errorf("listening on %q published %q in the wrong address family", tt.host, host)
+		}
+	}
+}
+
+func TestReferenceWithoutServer(t *testing.T) {
+	orb := corba.Init()
+	root := orb.GetRootPOA()
+	servant := &recordingServant{calls: make(chan string, 1)}
+	oid, err := root.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+
+	// Without any server there is nowhere to send requests
+	ref := root.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0")
+	if _, err := orb.ObjectToString(ref); err == nil {
+		t.Error("ObjectToString succeeded for a reference without profiles")
+	}
+	_, err = ref.Invoke("ping")
+	wantMinorCode(t, err, "TRANSIENT", corba.MinorNoUsableProfile)
+
+	// The reference is bound to the server started afterwards
+	server := runServer(t, orb, corba.NewTCPEndpoint("127.0.0.1", 0))
+	ior, err := orb.ObjectToString(ref)
+	if err != nil {
+		t.Fatalf("ObjectToString: %v", err)
+	}
+	parsed, err := orb.StringToObject(ior)
+	if err != nil {
+		t.Fatalf("StringToObject: %v", err)
+	}
+	if parsed.Endpoint() != server.Endpoint() {
+		t.Errorf("reference endpoint = %v, want %v", parsed.Endpoint(), server.Endpoint())
+	}
+	if _, err := ref.Invoke("ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-servant.calls
+}
+
+func TestReferenceBoundOnceByConcurrentInvocations(t *testing.T) {
+	orb := corba.Init()
+	root := orb.GetRootPOA()
+	const callers = 8
+	servant := &recordingServant{calls: make(chan string, callers)}
+	oid, err := root.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref := root.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0")
+	runServer(t, orb, corba.NewTCPEndpoint("127.0.0.1", 0))
+
+	// The first invocations race to bind the reference
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ref.Invoke("ping")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Invoke: %v", err)
+		}
+	}
+	if n := len(ref.GetIOR().Profiles); n != 1 {
+		t.Errorf("reference has %d profiles, want 1", n)
+	}
+}
+
+func TestReferenceBeforeServerRuns(t *testing.T) {
+	orb := corba.Init()
+	if _, err := orb.CreateServerOn(corba.NewTCPEndpoint("127.0.0.1", 8765)); err != nil {
+		t.Fatalf("CreateServerOn: %v", err)
+	}
+
+	// A server with a fixed port is advertised before it runs
+	ref := orb.GetRootPOA().CreateReference("IDL:Test/Echo:1.0", nil)
+	if got, want := ref.Endpoint(), corba.NewTCPEndpoint("127.0.0.1", 8765); got != want {
+		t.Errorf("reference endpoint = %v, want %v", got, want)
+	}
+}
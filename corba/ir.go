@@ -220,6 +220,9 @@ type InterfaceDef interface {
 	// Get the list of base interfaces
 	BaseInterfaces() []InterfaceDef
 
+	// Set the list of base interfaces
+	SetBaseInterfaces(bases []InterfaceDef)
+
 	// Create a new IDL Attribute
 	CreateAttribute(id string, name string, type_code TypeCode, mode int) (AttributeDef, error)
 
@@ -468,9 +468,17 @@ func (i *interfaceDefImpl) Name() string {
 var _ InterfaceDef = &interfaceDefImpl{}
 
 func (i *interfaceDefImpl) BaseInterfaces() []InterfaceDef {
+	i.containerBase.mu.RLock()
+	defer i.containerBase.mu.RUnlock()
 	return i.bases
 }
 
+func (i *interfaceDefImpl) SetBaseInterfaces(bases []InterfaceDef) {
+	i.containerBase.mu.Lock()
+	defer i.containerBase.mu.Unlock()
+	i.bases = bases
+}
+
 func (i *interfaceDefImpl) CreateAttribute(id string, name string, type_code TypeCode, mode int) (AttributeDef, error) {
 	attr := &attributeDefImpl{
 		containedBase: *newContainedBase(id, name, DK_ATTRIBUTE, i),
@@ -0,0 +1,168 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"fmt"
+
+	"github.com/ifabos/go-corba/giop"
+)
+
+// maxLocationForwards bounds the number of LOCATION_FORWARD replies followed
+// for a single request, so that forwarding loops end with TRANSIENT
+const maxLocationForwards = 8
+
+// objectLocation is the endpoint and object key requests for an object are sent to
+type objectLocation struct {
+	endpoint  Endpoint
+	objectKey []byte
+}
+
+// forwardLocation decodes the IOR carried by a LOCATION_FORWARD reply or an
+// OBJECT_FORWARD locate reply
+func (c *Client) forwardLocation(body []byte) (*objectLocation, error) {
+	ior, err := DecodeIOR(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid forward reference: %w", err)
+	}
+
+	var ep Endpoint
+	var objectKey []byte
+	if c.orb != nil {
+		ep, objectKey, err = c.orb.EndpointFromIOR(ior)
+	} else {
+		ep, objectKey, err = selectProfile(ior, defaultProfileTransports())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &objectLocation{endpoint: ep, objectKey: objectKey}, nil
+}
+
+// locate sends a LocateRequest for an object location. It returns the location
+// to retry at for OBJECT_FORWARD replies, or nil when the object is there.
+func (c *Client) locate(loc objectLocation) (*objectLocation, error) {
+	requestID := c.NextRequestID()
+	data, err := giop.MarshalGIOPMessage(&giop.Message{
+		Header: giop.NewMessageHeader(giop.MsgLocateRequest, 0),
+		Body: &giop.LocateRequestHeader{
+			RequestID: requestID,
+			ObjectKey: loc.objectKey,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal locate request: %w", err)
+	}
+
+	msg, err := c.exchange(loc.endpoint, requestID, data)
+	if err != nil {
+		return nil, err
+	}
+	reply, ok := msg.Body.(*giop.LocateReplyHeader)
+	if msg.Header.MsgType != giop.MsgLocateReply || !ok {
+		return nil, fmt.Errorf("expected locate reply, got message type %d", msg.Header.MsgType)
+	}
+	if reply.RequestID != requestID {
+		return nil, fmt.Errorf("mismatched request ID: expected %d, got %d", requestID, reply.RequestID)
+	}
+
+	switch reply.Status {
+	case giop.LocateStatusObjectHere:
+		return nil, nil
+	case giop.LocateStatusUnknownObject:
+		return nil, OBJECT_NOT_EXIST(MinorUnknownObjectKey, CompletionStatusNo)
+	case giop.LocateStatusObjectForward, giop.LocateStatusObjectForwardPerm:
+		return c.forwardLocation(reply.Body)
+	case giop.LocateStatusLOC_SYSTEM_EXCEPTION:
+		ex, err := unmarshalException(reply.Body, bodyByteOrder(reply.ByteOrder), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal locate exception: %w", err)
+		}
+		return nil, ex
+	default:
+		return nil, fmt.Errorf("unsupported locate reply status: %d", reply.Status)
+	}
+}
+
+// location returns where requests through the reference are sent: the
+// location a previous request was forwarded to, or the reference itself
+func (ref *ObjectRef) location() objectLocation {
+	if forward := ref.forward.Load(); forward != nil {
+		return *forward
+	}
+	return objectLocation{endpoint: ref.endpoint, objectKey: ref.key()}
+}
+
+// rememberForward records the location that answered a request, so that later
+// requests go there directly. The original location clears the record.
+func (ref *ObjectRef) rememberForward(loc objectLocation) {
+	if loc.endpoint == ref.endpoint && string(loc.objectKey) == string(ref.key()) {
+		ref.forward.Store(nil)
+		return
+	}
+	ref.forward.Store(&loc)
+}
+
+// invokeForwarded invokes an operation at the reference's current location.
+// If a location the reference was forwarded to can no longer be reached, the
+// request is retried at the original location, which forwards it afresh. A
+// request that may have reached the servant is not retried, so that it runs
+// at most once.
+func (ref *ObjectRef) invokeForwarded(inv invocation) (interface{}, error) {
+	forwarded := ref.forward.Load() != nil
+
+	result, loc, err := ref.client.invokeAt(ref.location(), inv)
+	if err != nil && forwarded && isUnsentCommunicationFailure(err) {
+		ref.forward.Store(nil)
+		result, loc, err = ref.client.invokeAt(ref.location(), inv)
+	}
+	if err == nil || !isCommunicationFailure(err) {
+		ref.rememberForward(loc)
+	}
+	return result, err
+}
+
+// Locate asks the server whether it hosts the referenced object, following
+// OBJECT_FORWARD replies. Subsequent invocations use the location found.
+func (ref *ObjectRef) Locate() error {
+	if ref == nil || ref.client == nil {
+		return OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	loc := objectLocation{endpoint: ref.Endpoint(), objectKey: ref.key()}
+	for hops := 0; hops <= maxLocationForwards; hops++ {
+		forward, err := ref.client.locate(loc)
+		if err != nil {
+			return err
+		}
+		if forward == nil {
+			if !ref.endpoint.IsZero() {
+				ref.rememberForward(loc)
+			}
+			return nil
+		}
+		loc = *forward
+	}
+	return TRANSIENT(MinorForwardLoop, CompletionStatusNo)
+}
+
+// isUnsentCommunicationFailure reports whether an invocation failed because
+// the server could not be reached, before the request was processed
+func isUnsentCommunicationFailure(err error) bool {
+	ex, ok := err.(Exception)
+	return ok && isCommunicationFailure(err) && ex.Completed() == CompletionStatusNo
+}
+
+// isCommunicationFailure reports whether an invocation failed because the
+// server could not be reached, as opposed to an exception raised by the server
+func isCommunicationFailure(err error) bool {
+	ex, ok := err.(Exception)
+	if !ok {
+		return true
+	}
+	switch ex.Name() {
+	case "TRANSIENT", "COMM_FAILURE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import "fmt"
+
+// A minor code carries a vendor minor codeset ID (VMCID) in its upper 20 bits
+// and the code proper in its lower 12 bits. Codes with the OMG's VMCID are
+// defined by the CORBA specification for each system exception; codes with
+// VendorVMCID are specific to this ORB.
+const (
+	// OMGVMCID is the VMCID of the minor codes standardized by the OMG
+	OMGVMCID uint32 = 0x4f4d0000
+
+	// VendorVMCID is the VMCID of the minor codes defined by this ORB. It is
+	// not registered with the OMG.
+	VendorVMCID uint32 = 0x47430000
+
+	// vmcidMask selects the VMCID of a minor code
+	vmcidMask uint32 = 0xfffff000
+)
+
+// Standard minor codes raised by the ORB, named after their meaning for the
+// system exception they are defined for
+const (
+	// TRANSIENT
+	MinorRequestDiscarded = OMGVMCID | 1 // POA discarding requests or out of resources
+	MinorNoUsableProfile  = OMGVMCID | 2 // No usable profile in the IOR
+	MinorRequestCancelled = OMGVMCID | 3
+	MinorPOADestroyed     = OMGVMCID | 4
+
+	// OBJECT_NOT_EXIST
+	MinorUnactivatedObject = OMGVMCID | 1
+	MinorAdapterNotFound   = OMGVMCID | 2
+	MinorAdapterInactive   = OMGVMCID | 4
+
+	// OBJ_ADAPTER
+	MinorAdapterActivatorFailed   = OMGVMCID | 1
+	MinorWrongServantType         = OMGVMCID | 2
+	MinorNoDefaultServant         = OMGVMCID | 3
+	MinorNoServantManager         = OMGVMCID | 4 // Or a servant manager of the wrong kind
+	MinorIncarnatePolicyViolation = OMGVMCID | 5
+	MinorNullServant              = OMGVMCID | 7
+
+	// BAD_INV_ORDER
+	MinorWouldDeadlock        = OMGVMCID | 3
+	MinorORBShutdown          = OMGVMCID | 4
+	MinorArgumentsCalledTwice = OMGVMCID | 7
+	MinorContextOrder         = OMGVMCID | 8
+	MinorRequestAlreadySent   = OMGVMCID | 10
+	MinorRequestNotSent       = OMGVMCID | 11
+
+	// MARSHAL
+	MinorArgumentsMismatch = OMGVMCID | 3
+
+	// BAD_PARAM
+	MinorNilInitialReference = OMGVMCID | 27
+
+	// BAD_OPERATION
+	MinorOperationUnknown = OMGVMCID | 2
+
+	// UNKNOWN
+	MinorUnlistedUserException = OMGVMCID | 1
+
+	// INTF_REPOS
+	MinorIRNotAvailable = OMGVMCID | 1
+	MinorNoIREntry      = OMGVMCID | 2
+)
+
+// Minor codes specific to this ORB
+const (
+	// OBJECT_NOT_EXIST
+	MinorUnknownObjectKey     = VendorVMCID | 1
+	MinorMalformedObjectKey   = VendorVMCID | 2
+	MinorObjectNotActive      = VendorVMCID | 3
+	MinorNoServantForObject   = VendorVMCID | 4
+	MinorNilReference         = VendorVMCID | 5
+	MinorServerUnknown        = VendorVMCID | 6
+	MinorAuthenticationFailed = VendorVMCID | 7
+	MinorORBInitInfoExpired   = VendorVMCID | 37
+
+	// OBJ_ADAPTER
+	MinorServantNotDispatchable = VendorVMCID | 8
+	MinorManagerInactive        = VendorVMCID | 9
+
+	// TRANSIENT
+	MinorObjectDeactivating   = VendorVMCID | 10
+	MinorServerShuttingDown   = VendorVMCID | 11
+	MinorConnectionClosed     = VendorVMCID | 12
+	MinorForwardLoop          = VendorVMCID | 13
+	MinorServerNotRunning     = VendorVMCID | 14
+	MinorServerLaunchFailed   = VendorVMCID | 15
+	MinorServerStartupTimeout = VendorVMCID | 16
+	MinorConnectFailed        = VendorVMCID | 40
+
+	// MARSHAL
+	MinorMalformedRequest        = VendorVMCID | 17
+	MinorMalformedReply          = VendorVMCID | 18
+	MinorUnmarshallableException = VendorVMCID | 19
+	MinorUnmarshallableArgument  = VendorVMCID | 35
+	MinorUnmarshallableResult    = VendorVMCID | 36
+	MinorFragmentedMessage       = VendorVMCID | 39
+
+	// UNKNOWN
+	MinorEmptyExceptionReply = VendorVMCID | 20
+	MinorGoError             = VendorVMCID | 21
+	MinorServantPanic        = VendorVMCID | 22
+
+	// TIMEOUT
+	MinorRequestTimeout = VendorVMCID | 23
+
+	// COMM_FAILURE
+	MinorSendFailed    = VendorVMCID | 24
+	MinorReceiveFailed = VendorVMCID | 25
+	MinorMessageError  = VendorVMCID | 38
+
+	// BAD_PARAM
+	MinorInvalidEndpoint     = VendorVMCID | 26
+	MinorAdapterPathInUse    = VendorVMCID | 27
+	MinorInvalidArgument     = VendorVMCID | 28
+	MinorUncomparableServant = VendorVMCID | 41
+
+	// NO_PERMISSION
+	MinorInvalidCredentials           = VendorVMCID | 29
+	MinorInvalidToken                 = VendorVMCID | 30
+	MinorSecurityAuthenticationFailed = VendorVMCID | 31
+	MinorAccessDenied                 = VendorVMCID | 32
+	MinorInvalidSecurityPolicy        = VendorVMCID | 33
+	MinorSecurityContextExpired       = VendorVMCID | 34
+)
+
+// omgMinorCodes describes the standard minor codes of each system exception,
+// by their code without the VMCID
+var omgMinorCodes = map[string]map[uint32]string{
+	"UNKNOWN": {
+		1: "unlisted user exception received by client",
+		2: "non-standard system exception not supported",
+		3: "unknown user exception received by a portable interceptor",
+	},
+	"BAD_PARAM": {
+		1:  "failure to register, unregister or look up value factory",
+		2:  "repository ID already defined in Interface Repository",
+		3:  "name already used in the context in Interface Repository",
+		4:  "target is not a valid container",
+		5:  "name clash in inherited context",
+		6:  "incorrect type for abstract interface",
+		7:  "string_to_object conversion failed due to bad scheme name",
+		8:  "string_to_object conversion failed due to bad address",
+		9:  "string_to_object conversion failed due to bad schema specific part",
+		10: "string_to_object conversion failed due to non specific reason",
+		11: "attempt to derive abstract interface from non-abstract base interface",
+		12: "attempt to let a ValueDef support more than one non-abstract interface",
+		13: "attempt to use an incomplete TypeCode as a parameter",
+		14: "invalid object id passed to POA::create_reference_by_id",
+		15: "bad name argument in TypeCode operation",
+		16: "bad repository ID argument in TypeCode operation",
+		17: "invalid member name in TypeCode operation",
+		18: "duplicate label value in create_union_tc",
+		19: "incompatible TypeCode of label and discriminator in create_union_tc",
+		20: "supplied discriminator type illegitimate in create_union_tc",
+		21: "Any passed to ServerRequest::set_exception does not contain an exception",
+		22: "unlisted user exception passed to ServerRequest::set_exception",
+		23: "wchar transmission code set not in service context",
+		24: "service context is not in OMG-defined range",
+		25: "enum value out of range",
+		26: "invalid service context ID in portable interceptor",
+		27: "attempt to call register_initial_reference with a null object",
+		28: "invalid component ID in portable interceptor",
+		29: "invalid profile ID in portable interceptor",
+		30: "two or more policy objects with the same PolicyType value supplied",
+	},
+	"IMP_LIMIT": {
+		1: "unable to use any profile in IOR",
+	},
+	"INV_OBJREF": {
+		1: "wchar code set support not specified",
+		2: "codeset component required for type using wchar or wstring data",
+	},
+	"MARSHAL": {
+		1: "unable to locate value factory",
+		2: "ServerRequest::set_result called before ServerRequest::ctx when the operation has a context clause",
+		3: "NVList passed to ServerRequest::arguments does not describe all parameters passed by client",
+		4: "attempt to marshal local object",
+		5: "wchar or wstring data erroneously sent by client over GIOP 1.0 connection",
+		6: "wchar or wstring data erroneously returned by server over GIOP 1.0 connection",
+		7: "unsupported RMI/IDL custom value type stream format",
+	},
+	"NO_IMPLEMENT": {
+		1: "missing local value implementation",
+		2: "incompatible value implementation version",
+		3: "unable to use any profile in IOR",
+		4: "attempt to use DII on local object",
+	},
+	"BAD_TYPECODE": {
+		1: "attempt to marshal incomplete TypeCode",
+		2: "member type code illegitimate in TypeCode operation",
+		3: "illegal parameter type",
+	},
+	"BAD_OPERATION": {
+		1: "servant manager returned wrong servant type",
+		2: "operation or attribute not known to target object",
+	},
+	"NO_RESOURCES": {
+		1: "portable interceptor operation not supported in this binding",
+		2: "no connection for request's priority",
+	},
+	"BAD_INV_ORDER": {
+		1:  "dependency exists in Interface Repository preventing destruction of this object",
+		2:  "attempt to destroy indestructible objects in Interface Repository",
+		3:  "operation would deadlock",
+		4:  "ORB has shutdown",
+		5:  "attempt to invoke send or invoke operation of the same Request object more than once",
+		6:  "attempt to set a servant manager after one has already been set",
+		7:  "ServerRequest::arguments called more than once or after ServerRequest::set_exception",
+		8:  "ServerRequest::ctx called more than once, before ServerRequest::arguments or after the result was set",
+		9:  "ServerRequest::set_result called more than once, before ServerRequest::arguments or after ServerRequest::set_exception",
+		10: "attempt to send a DII request after it was sent previously",
+		11: "attempt to poll a DII request or to retrieve its result before the request was sent",
+		12: "attempt to poll a DII request or to retrieve its result after the result was retrieved previously",
+		13: "attempt to poll a synchronous DII request or to retrieve results from a synchronous DII request",
+		14: "invalid portable interceptor call",
+		15: "service context add failed because a service context with the given ID already exists",
+		16: "registration of PolicyFactory failed because a factory already exists for the given PolicyType",
+		17: "POA cannot create POAs while undergoing destruction",
+	},
+	"TRANSIENT": {
+		1: "request discarded because of resource exhaustion in POA, or because POA is in discarding state",
+		2: "no usable profile in IOR",
+		3: "request cancelled",
+		4: "POA destroyed",
+	},
+	"INTF_REPOS": {
+		1: "Interface Repository not available",
+		2: "no entry for requested interface in Interface Repository",
+	},
+	"BAD_CONTEXT": {
+		1: "IDL context not found",
+		2: "no matching IDL context property",
+	},
+	"OBJ_ADAPTER": {
+		1: "system exception in AdapterActivator::unknown_adapter",
+		2: "incorrect servant type returned by servant manager",
+		3: "no default servant available",
+		4: "no servant manager available",
+		5: "violation of POA policy by ServantActivator::incarnate",
+		6: "exception in IORInterceptor::components_established",
+		7: "null servant returned by servant manager",
+	},
+	"DATA_CONVERSION": {
+		1: "character does not map to negotiated transmission code set",
+		2: "failure of PriorityMapping object",
+	},
+	"OBJECT_NOT_EXIST": {
+		1: "attempt to pass an unactivated value as an object reference",
+		2: "failed to create or locate object adapter",
+		4: "object adapter inactive",
+		5: "this Poller has already delivered a reply to some client",
+	},
+	"INV_POLICY": {
+		1: "unable to reconcile IOR specified policy with effective policy override",
+		2: "invalid PolicyType",
+		3: "no PolicyFactory for the PolicyType has been registered",
+	},
+	"INITIALIZE": {
+		1: "priority range too restricted for ORB",
+	},
+}
+
+// vendorMinorCodes describes the minor codes of this ORB, by their code
+// without the VMCID. Each code is raised with a single system exception.
+var vendorMinorCodes = map[uint32]string{
+	1:  "object key not registered with the ORB",
+	2:  "malformed POA object key",
+	3:  "object not active in its POA",
+	4:  "servant manager has no servant for the object",
+	5:  "invocation on a nil object reference",
+	6:  "server not known to the implementation repository",
+	7:  "authentication failed",
+	8:  "servant cannot dispatch operations",
+	9:  "POA manager inactive",
+	10: "object being deactivated",
+	11: "server shutting down",
+	12: "connection closed by the server",
+	13: "too many location forwards",
+	14: "server not running and cannot be started",
+	15: "server could not be launched",
+	16: "server did not start in time",
+	17: "malformed request body",
+	18: "malformed reply body",
+	19: "exception could not be marshalled",
+	20: "exception reply without exception data",
+	21: "non-CORBA error raised by a servant or interceptor",
+	22: "servant panicked",
+	23: "no reply within the request timeout",
+	24: "failed to send request",
+	25: "failed to receive reply",
+	26: "invalid endpoint",
+	27: "POA path registered by another server",
+	28: "argument rejected by validator",
+	29: "invalid credentials",
+	30: "invalid security token",
+	31: "security authentication failed",
+	32: "access denied",
+	33: "invalid security policy",
+	34: "security context expired",
+	35: "request arguments could not be marshalled",
+	36: "result could not be marshalled",
+	37: "ORBInitInfo used after ORB initialization",
+	38: "peer reported a GIOP MessageError",
+	39: "fragmented GIOP messages not supported",
+	40: "could not connect to the server",
+	41: "servant cannot be compared; activate a pointer instead",
+}
+
+// ExplainMinorCode returns a human-readable explanation of the minor code of a
+// system exception, such as "OMG minor code 4: POA destroyed" for TRANSIENT.
+// Codes of other vendors are shown with their VMCID.
+func ExplainMinorCode(exceptionName string, minor uint32) string {
+	code := minor &^ vmcidMask
+
+	switch minor & vmcidMask {
+	case OMGVMCID:
+		if text, ok := omgMinorCodes[exceptionName][code]; ok {
+			return fmt.Sprintf("OMG minor code %d: %s", code, text)
+		}
+		return fmt.Sprintf("OMG minor code %d", code)
+
+	case VendorVMCID:
+		if text, ok := vendorMinorCodes[code]; ok {
+			return fmt.Sprintf("go-corba minor code %d: %s", code, text)
+		}
+		return fmt.Sprintf("go-corba minor code %d", code)
+	}
+
+	if minor == 0 {
+		return "no minor code"
+	}
+	return fmt.Sprintf("minor code %d with VMCID 0x%08x", code, minor&vmcidMask)
+}
+
+// Explanation returns a human-readable explanation of the exception's minor code
+func (e *SystemException) Explanation() string {
+	return ExplainMinorCode(e.exceptionName, e.minorCode)
+}
@@ -0,0 +1,101 @@
+package corba_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// wantMinorCode checks that err is the named system exception with a minor code
+func wantMinorCode(t *testing.T, err error, name string, minor uint32) {
+	t.Helper()
+
+	wantSystemException(t, err, name)
+	if got := err.(corba.Exception).Minor(); got != minor {
+		t.Errorf("%s minor code = %s, want %s", name,
+			corba.ExplainMinorCode(name, got), corba.ExplainMinorCode(name, minor))
+	}
+}
+
+// panickingServant panics in every operation
+type panickingServant struct{}
+
+func (s *panickingServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	panic("out of cheese")
+}
+
+func TestExplainMinorCode(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		minor uint32
+		want  string
+	}{
+		{"TRANSIENT", corba.MinorPOADestroyed, "OMG minor code 4: POA destroyed"},
+		{"OBJECT_NOT_EXIST", corba.OMGVMCID | 4, "OMG minor code 4: object adapter inactive"},
+		{"TRANSIENT", corba.OMGVMCID | 99, "OMG minor code 99"},
+		{"OBJECT_NOT_EXIST", corba.MinorUnknownObjectKey, "go-corba minor code 1: object key not registered with the ORB"},
+		{"UNKNOWN", 0, "no minor code"},
+		{"UNKNOWN", 0x12345007, "minor code 7 with VMCID 0x12345000"},
+	} {
+		if got := corba.ExplainMinorCode(tc.name, tc.minor); got != tc.want {
+			t.Errorf("ExplainMinorCode(%s, %#x) = %q, want %q", tc.name, tc.minor, got, tc.want)
+		}
+	}
+
+	if got := corba.BAD_INV_ORDER(corba.MinorWouldDeadlock, corba.CompletionStatusNo).Explanation(); got != "OMG minor code 3: operation would deadlock" {
+		t.Errorf("Explanation = %q", got)
+	}
+}
+
+func TestMinorCodesOnTheWire(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "minor-codes")
+	root := orb.GetRootPOA()
+
+	unknown, err := client.GetObjectAt("NoSuchObject", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+	_, err = unknown.Invoke("ping")
+	wantMinorCode(t, err, "OBJECT_NOT_EXIST", corba.MinorUnknownObjectKey)
+
+	child, err := root.CreatePOA("Gone", nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	gone := child.CreateReferenceWithId(corba.ObjectID("obj"), "IDL:Test/Echo:1.0")
+	if err := child.Destroy(false, false); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	wantMinorCode(t, invokeRef(t, client, ep, gone, "ping"), "OBJECT_NOT_EXIST", corba.MinorAdapterNotFound)
+
+	ref, err := root.ServantToReference(&panickingServant{})
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	err = invokeRef(t, client, ep, ref, "ping")
+	wantMinorCode(t, err, "UNKNOWN", corba.MinorServantPanic)
+	if completed := err.(corba.Exception).Completed(); completed != corba.CompletionStatusMaybe {
+		t.Errorf("completion status of a panic = %v, want maybe", completed)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "minor-codes-timeout")
+
+	servant := newConcurrencyServant()
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	defer close(servant.gate)
+
+	client.SetRequestTimeout(20 * time.Millisecond)
+	err = invokeRef(t, client, ep, ref, "block")
+	wantMinorCode(t, err, "TIMEOUT", corba.MinorRequestTimeout)
+	if completed := err.(corba.Exception).Completed(); completed != corba.CompletionStatusMaybe {
+		t.Errorf("completion status of a timeout = %v, want maybe", completed)
+	}
+}
@@ -0,0 +1,152 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// objectKeyMagic prefixes every object key generated by a POA. Keys without it
+// are treated as plain names registered directly with the ORB.
+var objectKeyMagic = []byte{'G', 'P', 'O', 'A'}
+
+// objectKeyVersion is the version of the object key layout
+const objectKeyVersion byte = 1
+
+// Object key flags
+const (
+	objectKeyPersistent byte = 1 << iota // The key was created by a PERSISTENT POA
+)
+
+// ObjectKey is the structured form of the object keys created by POAs. It
+// records the path of the POA below the root POA, whether the POA has a
+// PERSISTENT lifespan, and the ObjectID within that POA.
+//
+// The encoded layout is:
+//
+//	magic "GPOA" | version | flags | [POA instance (8 bytes), transient only] |
+//	path count (4 bytes) | { name length (4 bytes) | name }... |
+//	ObjectID length (4 bytes) | ObjectID
+//
+// All integers are big endian.
+type ObjectKey struct {
+	POAPath     []string // POA names from below the root POA down to the target POA
+	Persistent  bool     // True when the POA has a PERSISTENT lifespan
+	POAInstance uint64   // Incarnation of a TRANSIENT POA; zero for persistent keys
+	ObjectID    ObjectID // Object identifier within the POA
+}
+
+// Encode returns the wire form of the object key
+func (k ObjectKey) Encode() []byte {
+	var flags byte
+	if k.Persistent {
+		flags |= objectKeyPersistent
+	}
+
+	buf := make([]byte, 0, 16+len(k.ObjectID))
+	buf = append(buf, objectKeyMagic...)
+	buf = append(buf, objectKeyVersion, flags)
+	if !k.Persistent {
+		buf = binary.BigEndian.AppendUint64(buf, k.POAInstance)
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(k.POAPath)))
+	for _, name := range k.POAPath {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(name)))
+		buf = append(buf, name...)
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(k.ObjectID)))
+	buf = append(buf, k.ObjectID...)
+
+	return buf
+}
+
+// String returns a readable form of the key, e.g. "/Child/Grandchild#oid"
+func (k ObjectKey) String() string {
+	path := ""
+	for _, name := range k.POAPath {
+		path += "/" + name
+	}
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s#%s", path, k.ObjectID)
+}
+
+// IsPOAObjectKey reports whether a raw object key was generated by a POA
+func IsPOAObjectKey(data []byte) bool {
+	return bytes.HasPrefix(data, objectKeyMagic)
+}
+
+// DecodeObjectKey parses an object key created by ObjectKey.Encode
+func DecodeObjectKey(data []byte) (ObjectKey, error) {
+	var key ObjectKey
+
+	if !IsPOAObjectKey(data) {
+		return key, fmt.Errorf("not a POA object key")
+	}
+	data = data[len(objectKeyMagic):]
+
+	if len(data) < 2 {
+		return key, fmt.Errorf("object key too short")
+	}
+	if data[0] != objectKeyVersion {
+		return key, fmt.Errorf("unsupported object key version %d", data[0])
+	}
+	key.Persistent = data[1]&objectKeyPersistent != 0
+	data = data[2:]
+
+	if !key.Persistent {
+		if len(data) < 8 {
+			return key, fmt.Errorf("object key too short for POA instance")
+		}
+		key.POAInstance = binary.BigEndian.Uint64(data)
+		data = data[8:]
+	}
+
+	readBytes := func() ([]byte, error) {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("object key truncated")
+		}
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint32(len(data)) < n {
+			return nil, fmt.Errorf("object key truncated")
+		}
+		b := data[:n]
+		data = data[n:]
+		return b, nil
+	}
+
+	if len(data) < 4 {
+		return key, fmt.Errorf("object key truncated")
+	}
+	count := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint64(len(data)) < uint64(count)*4 {
+		return key, fmt.Errorf("object key truncated")
+	}
+
+	key.POAPath = make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		name, err := readBytes()
+		if err != nil {
+			return key, err
+		}
+		key.POAPath = append(key.POAPath, string(name))
+	}
+
+	oid, err := readBytes()
+	if err != nil {
+		return key, err
+	}
+	key.ObjectID = append(ObjectID(nil), oid...)
+
+	if len(data) != 0 {
+		return key, fmt.Errorf("trailing data in object key")
+	}
+
+	return key, nil
+}
@@ -2,6 +2,7 @@
 package corba
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -22,6 +23,16 @@ type ORB struct {
 	poaManagers         []*POAManager           // Add POA managers
 	containerManager    *ContainerManager       // Add container manager for CCM
 	componentServer     *ComponentServerServant // Add component server for CCM
+	transports          map[string]Transport    // Registered transports by protocol name
+	transportOrder      []string                // Protocols in registration order
+	workers             *workerPool             // Dispatches incoming requests
+	servers             []*Server               // Servers created by this ORB
+	publishedHost       string                  // Host advertised in IORs instead of the listen host
+	shutdownOnce        sync.Once               // Guards closing shutdownDone
+	shutdownDone        chan struct{}           // Closed when shutdown has completed
+	destroyed           bool                    // Set by Destroy
+	implRepository      *imrBinding             // Implementation repository persistent POAs register with
+	slotCount           int                     // PICurrent slots allocated by ORB initializers
 }
 
 // Constants for well-known CORBA service names
@@ -39,34 +50,162 @@ var (
 	componentServerInstance *ComponentServerServant // Add global component server instance
 )
 
-// Init initializes and returns a new ORB instance
+// Init initializes and returns a new ORB instance, running the initializers
+// registered with RegisterORBInitializer
 func Init() *ORB {
+	return InitWithInitializers()
+}
+
+// InitWithInitializers initializes and returns a new ORB instance, running the
+// registered initializers followed by the given ones
+func InitWithInitializers(initializers ...ORBInitializer) *ORB {
 	orb := &ORB{
 		objectMap:           make(map[string]interface{}),
 		isInitialized:       true,
 		defaultContext:      NewContext(),
 		interceptorRegistry: NewInterceptorRegistry(), // Initialize interceptor registry
+		transports:          make(map[string]Transport),
+		workers:             newWorkerPool(DefaultMaxConcurrentRequests),
+		shutdownDone:        make(chan struct{}),
 	}
 	orb.requestProcessor = NewRequestProcessor(orb)
+	orb.registerDefaultTransports()
 
 	// Initialize the Interface Repository as part of ORB initialization
 	orb.interfaceRepository = NewInterfaceRepository()
 
+	orb.runORBInitializers(append(registeredORBInitializers(), initializers...))
 	return orb
 }
 
-// Shutdown terminates the ORB
+// Shutdown terminates the ORB. If wait is true, Shutdown blocks until all
+// servers have drained their in-flight requests and the POAs are destroyed;
+// otherwise shutdown proceeds in the background. Servants must not wait from
+// a request; they call ShutdownContext with their request context, which also
+// reports errors.
 func (orb *ORB) Shutdown(wait bool) {
+	if wait {
+		orb.ShutdownContext(context.Background())
+		return
+	}
+	go orb.ShutdownContext(context.Background())
+}
+
+// ShutdownContext terminates the ORB gracefully. Every server stops accepting
+// connections, closes idle connections with a GIOP CloseConnection and waits
+// for in-flight requests, bounded by ctx. Only then is the POA tree destroyed,
+// etherealizing servants incarnated by servant activators, and the worker pool
+// stopped. If ctx ends before the requests have drained, its error is
+// returned and the POAs and workers are left to the remaining requests;
+// ShutdownContext can be called again to finish. Called with the context of a request dispatched by the ORB, it
+// fails with BAD_INV_ORDER, since the request it would wait for is the
+// caller's own.
+func (orb *ORB) ShutdownContext(ctx context.Context) error {
+	if orb.inDispatch(ctx) {
+		return BAD_INV_ORDER(MinorWouldDeadlock, CompletionStatusNo)
+	}
+
+	orb.unregisterFromImplRepository()
+
 	orb.mu.Lock()
-	defer orb.mu.Unlock()
+	servers := orb.servers
+	orb.servers = nil
+	rootPOA := orb.rootPOA
+	orb.mu.Unlock()
+
+	var firstErr error
+	for _, server := range servers {
+		if !server.IsRunning() {
+			continue
+		}
+		if err := server.ShutdownContext(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil && ctx.Err() != nil {
+		// Requests are still running on the POAs and workers
+		return firstErr
+	}
 
-	if orb.serverRunning && wait {
-		// Wait for pending operations to complete
+	if rootPOA != nil {
+		rootPOA.Destroy(true, false)
 	}
 
+	orb.mu.Lock()
 	orb.isInitialized = false
 	orb.serverRunning = false
 	orb.objectMap = make(map[string]interface{})
+	orb.rootPOA = nil
+	orb.mu.Unlock()
+
+	if err := orb.workers.stop(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	orb.shutdownOnce.Do(func() { close(orb.shutdownDone) })
+	return firstErr
+}
+
+// dispatchKey is the context key of the ORB dispatching a request
+type dispatchKey struct{}
+
+// withDispatch returns a context marking the dispatch of a request by an ORB
+func withDispatch(ctx context.Context, orb *ORB) context.Context {
+	return context.WithValue(ctx, dispatchKey{}, orb)
+}
+
+// inDispatch reports whether ctx is the context of a request dispatched by
+// the ORB, as told by the dispatch mark or the POA Current of ctx. Waiting
+// there for requests to complete would wait for the request itself.
+func (orb *ORB) inDispatch(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	if current, err := POACurrentFromContext(ctx); err == nil && current.poa.orb == orb {
+		return true
+	}
+	dispatching, _ := ctx.Value(dispatchKey{}).(*ORB)
+	return dispatching == orb
+}
+
+// Run blocks until the ORB has been shut down. Requests are dispatched by the
+// ORB's worker pool, so calling Run is only needed to keep a server process alive.
+func (orb *ORB) Run() {
+	<-orb.shutdownDone
+}
+
+// WorkPending reports whether requests are waiting for a free worker
+func (orb *ORB) WorkPending() bool {
+	return orb.workers.pending() > 0
+}
+
+// PerformWork dispatches one waiting request, if any, on the calling goroutine
+func (orb *ORB) PerformWork() {
+	orb.workers.performOne()
+}
+
+// Destroy shuts the ORB down, if that has not happened yet, and releases its
+// resources. The ORB must not be used afterwards.
+func (orb *ORB) Destroy() error {
+	orb.mu.RLock()
+	destroyed := orb.destroyed
+	orb.mu.RUnlock()
+	if destroyed {
+		return BAD_INV_ORDER(MinorORBShutdown, CompletionStatusNo)
+	}
+
+	if err := orb.ShutdownContext(context.Background()); err != nil {
+		return err
+	}
+
+	orb.mu.Lock()
+	defer orb.mu.Unlock()
+
+	orb.destroyed = true
+	orb.poaManagers = nil
+	orb.transports = make(map[string]Transport)
+	orb.transportOrder = nil
+	return nil
 }
 
 // CreateClient creates a new CORBA client
@@ -184,16 +323,12 @@ func (orb *ORB) ObjectToReference(obj interface{}) (*ObjectRef, error) {
 		repoID = FormatRepositoryID(fmt.Sprintf("%T", obj), "1.0")
 	}
 
-	// Create a new object reference
-	objRef := &ObjectRef{
-		typeID: repoID,
-		// Generate object key
-		objectKey: GenerateObjectKey(""),
-		Name:      fmt.Sprintf("object_%d", GetNextObjectID()),
-	}
+	// Create a new object reference advertising the ORB's endpoints
+	name := fmt.Sprintf("object_%d", GetNextObjectID())
+	objRef := orb.createReference(repoID, ObjectKeyFromString(name), false)
 
 	// Register the object with the ORB
-	err = orb.RegisterObject(objRef.Name, obj)
+	err = orb.RegisterObject(name, obj)
 	if err != nil {
 		return nil, fmt.Errorf("failed to register object: %w", err)
 	}
@@ -215,17 +350,18 @@ func (orb *ORB) StringToObject(iorString string) (*ObjectRef, error) {
 		typeID: ior.TypeID,
 	}
 
-	// Extract primary profile information
-	profile, err := ior.GetPrimaryIIOPProfile()
+	// Select the first profile one of our transports can reach
+	endpoint, objectKey, err := orb.EndpointFromIOR(ior)
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract IIOP profile: %w", err)
+		return nil, fmt.Errorf("failed to extract profile: %w", err)
 	}
 
 	// Set the ObjectRef fields
-	objRef.ServerHost = profile.Host
-	objRef.ServerPort = int(profile.Port)
-	objRef.objectKey = profile.ObjectKey
-	objRef.Name = ObjectKeyToString(profile.ObjectKey)
+	objRef.endpoint = endpoint
+	objRef.ServerHost = endpoint.Host
+	objRef.ServerPort = endpoint.Port
+	objRef.objectKey = objectKey
+	objRef.Name = ObjectKeyToString(objectKey)
 
 	// Set up the client
 	objRef.client = orb.CreateClient()
@@ -0,0 +1,152 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDuplicateName is raised when an interceptor is added with the name of an
+// interceptor of the same kind already registered
+var ErrDuplicateName = NewCORBAUserException("DuplicateName", "IDL:omg.org/PortableInterceptor/ORBInitInfo/DuplicateName:1.0")
+
+// ORBInitializer is implemented by services that need to take part in ORB
+// initialization, typically to register interceptors and allocate PICurrent
+// slots. PreInit is called on every initializer before PostInit is called on
+// any of them.
+type ORBInitializer interface {
+	PreInit(info *ORBInitInfo)
+	PostInit(info *ORBInitInfo)
+}
+
+var (
+	orbInitializersMu sync.Mutex
+	orbInitializers   []ORBInitializer
+)
+
+// RegisterORBInitializer registers an initializer that is run by every ORB
+// initialized afterwards
+func RegisterORBInitializer(initializer ORBInitializer) {
+	orbInitializersMu.Lock()
+	defer orbInitializersMu.Unlock()
+	orbInitializers = append(orbInitializers, initializer)
+}
+
+// registeredORBInitializers returns the initializers registered so far
+func registeredORBInitializers() []ORBInitializer {
+	orbInitializersMu.Lock()
+	defer orbInitializersMu.Unlock()
+	return append([]ORBInitializer(nil), orbInitializers...)
+}
+
+// ORBInitInfo is passed to ORBInitializers while an ORB is initialized. It
+// may only be used until initialization completes; afterwards its operations
+// fail with OBJECT_NOT_EXIST.
+type ORBInitInfo struct {
+	orb     *ORB
+	expired atomic.Bool
+}
+
+// check fails once the ORB has been initialized
+func (info *ORBInitInfo) check() error {
+	if info.expired.Load() {
+		return OBJECT_NOT_EXIST(MinorORBInitInfoExpired, CompletionStatusNo)
+	}
+	return nil
+}
+
+// AddClientRequestInterceptor registers a client request interceptor with the ORB
+func (info *ORBInitInfo) AddClientRequestInterceptor(interceptor ClientRequestInterceptor) error {
+	if err := info.check(); err != nil {
+		return err
+	}
+	if hasInterceptorNamed(info.orb.interceptorRegistry.GetClientRequestInterceptors(), interceptor.Name()) {
+		return ErrDuplicateName
+	}
+	info.orb.RegisterClientRequestInterceptor(interceptor)
+	return nil
+}
+
+// AddServerRequestInterceptor registers a server request interceptor with the ORB
+func (info *ORBInitInfo) AddServerRequestInterceptor(interceptor ServerRequestInterceptor) error {
+	if err := info.check(); err != nil {
+		return err
+	}
+	if hasInterceptorNamed(info.orb.interceptorRegistry.GetServerRequestInterceptors(), interceptor.Name()) {
+		return ErrDuplicateName
+	}
+	info.orb.RegisterServerRequestInterceptor(interceptor)
+	return nil
+}
+
+// AddIORInterceptor registers an IOR interceptor with the ORB
+func (info *ORBInitInfo) AddIORInterceptor(interceptor IORInterceptor) error {
+	if err := info.check(); err != nil {
+		return err
+	}
+	if hasInterceptorNamed(info.orb.interceptorRegistry.GetIORInterceptors(), interceptor.Name()) {
+		return ErrDuplicateName
+	}
+	info.orb.RegisterIORInterceptor(interceptor)
+	return nil
+}
+
+// AllocateSlotID allocates a slot in the PICurrent of every invocation
+func (info *ORBInitInfo) AllocateSlotID() (SlotID, error) {
+	if err := info.check(); err != nil {
+		return 0, err
+	}
+	id := SlotID(info.orb.slotCount)
+	info.orb.slotCount++
+	return id, nil
+}
+
+// RegisterInitialReference makes an object available to the application
+// under a name, as with ORB.RegisterObject
+func (info *ORBInitInfo) RegisterInitialReference(name string, obj interface{}) error {
+	if err := info.check(); err != nil {
+		return err
+	}
+	if obj == nil {
+		return BAD_PARAM(MinorNilInitialReference, CompletionStatusNo)
+	}
+	return info.orb.RegisterObject(name, obj)
+}
+
+// ResolveInitialReferences returns an object registered with the ORB
+func (info *ORBInitInfo) ResolveInitialReferences(name string) (interface{}, error) {
+	if err := info.check(); err != nil {
+		return nil, err
+	}
+	return info.orb.ResolveObject(name)
+}
+
+// hasInterceptorNamed reports whether an interceptor has a name. Anonymous
+// interceptors never clash.
+func hasInterceptorNamed[T interface{ Name() string }](interceptors []T, name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, interceptor := range interceptors {
+		if interceptor.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runORBInitializers runs the pre- and post-initialization of initializers
+func (orb *ORB) runORBInitializers(initializers []ORBInitializer) {
+	if len(initializers) == 0 {
+		return
+	}
+
+	info := &ORBInitInfo{orb: orb}
+	for _, initializer := range initializers {
+		initializer.PreInit(info)
+	}
+	for _, initializer := range initializers {
+		initializer.PostInit(info)
+	}
+	info.expired.Store(true)
+}
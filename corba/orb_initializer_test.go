@@ -0,0 +1,260 @@
+package corba_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// requestIDContext is the service context carrying the caller's request tag
+const requestIDContext = 0x54414700
+
+// tagInitializer installs interceptors passing a tag set by the caller in a
+// PICurrent slot to the servant, through a service context
+type tagInitializer struct {
+	slot     corba.SlotID
+	info     *corba.ORBInitInfo
+	client   *tagClientInterceptor
+	server   *tagServerInterceptor
+	preInit  bool
+	postInit bool
+}
+
+func (i *tagInitializer) PreInit(info *corba.ORBInitInfo) {
+	i.preInit = true
+	i.info = info
+	var err error
+	if i.slot, err = info.AllocateSlotID(); err != nil {
+		panic(err)
+	}
+}
+
+func (i *tagInitializer) PostInit(info *corba.ORBInitInfo) {
+	i.postInit = i.preInit
+	i.client = &tagClientInterceptor{slot: i.slot}
+	i.server = &tagServerInterceptor{slot: i.slot}
+	if err := info.AddClientRequestInterceptor(i.client); err != nil {
+		panic(err)
+	}
+	if err := info.AddServerRequestInterceptor(i.server); err != nil {
+		panic(err)
+	}
+}
+
+// tagClientInterceptor sends the tag in the request's slot as a service context
+type tagClientInterceptor struct {
+	slot corba.SlotID
+	sent []*corba.RequestInfo
+	got  []*corba.RequestInfo
+}
+
+func (i *tagClientInterceptor) Name() string { return "tag" }
+
+func (i *tagClientInterceptor) SendRequest(info *corba.RequestInfo) error {
+	i.sent = append(i.sent, info)
+	if tag, err := info.GetSlot(i.slot); err == nil && tag != nil {
+		info.ServiceContexts = append(info.ServiceContexts, corba.ServiceContext{
+			ID:   requestIDContext,
+			Data: []byte(tag.(string)),
+		})
+	}
+	return nil
+}
+
+func (i *tagClientInterceptor) ReceiveReply(info *corba.RequestInfo) error {
+	i.got = append(i.got, info)
+	return nil
+}
+
+func (i *tagClientInterceptor) ReceiveException(info *corba.RequestInfo, ex corba.Exception) error {
+	i.got = append(i.got, info)
+	return nil
+}
+
+func (i *tagClientInterceptor) ReceiveOther(info *corba.RequestInfo) error {
+	i.got = append(i.got, info)
+	return nil
+}
+
+// tagServerInterceptor sets the request's slot from the service context
+// before the servant is looked up
+type tagServerInterceptor struct {
+	slot          corba.SlotID
+	servantKnown  chan bool
+	replyStatuses chan corba.ReplyStatus
+}
+
+func (i *tagServerInterceptor) Name() string { return "tag" }
+
+func (i *tagServerInterceptor) ReceiveRequestServiceContexts(info *corba.RequestInfo) error {
+	i.servantKnown <- info.Servant != nil
+	for _, sc := range info.ServiceContexts {
+		if sc.ID == requestIDContext {
+			return info.SetSlot(i.slot, string(sc.Data))
+		}
+	}
+	return nil
+}
+
+func (i *tagServerInterceptor) ReceiveRequest(info *corba.RequestInfo) error {
+	return nil
+}
+
+func (i *tagServerInterceptor) SendReply(info *corba.RequestInfo) error {
+	i.replyStatuses <- info.ReplyStatus
+	return nil
+}
+
+func (i *tagServerInterceptor) SendException(info *corba.RequestInfo, ex corba.Exception) error {
+	i.replyStatuses <- info.ReplyStatus
+	return nil
+}
+
+// tagServant reports the tag found in its PICurrent
+type tagServant struct {
+	slot corba.SlotID
+	tags chan interface{}
+}
+
+func (s *tagServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.DispatchContext(context.Background(), methodName, args)
+}
+
+func (s *tagServant) DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error) {
+	current, ok := corba.PICurrentFromContext(ctx)
+	if !ok {
+		s.tags <- nil
+		return nil, nil
+	}
+	tag, err := current.GetSlot(s.slot)
+	s.tags <- tag
+	return nil, err
+}
+
+func TestORBInitializerPICurrent(t *testing.T) {
+	initializer := &tagInitializer{}
+	orb := corba.InitWithInitializers(initializer)
+	if !initializer.preInit || !initializer.postInit {
+		t.Fatal("initializer not run in order")
+	}
+	server := initializer.server
+	server.servantKnown = make(chan bool, 2)
+	server.replyStatuses = make(chan corba.ReplyStatus, 2)
+
+	client, ep := poaTestServer(t, orb, t.Name())
+	servant := &tagServant{slot: initializer.slot, tags: make(chan interface{}, 1)}
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// The caller's slot reaches the servant through the interceptors
+	current := orb.NewPICurrent()
+	if err := current.SetSlot(initializer.slot, "tag-1"); err != nil {
+		t.Fatalf("SetSlot: %v", err)
+	}
+	if _, err := target.InvokeWithContext(corba.WithPICurrent(context.Background(), current), "ping"); err != nil {
+		t.Fatalf("InvokeWithContext: %v", err)
+	}
+	if tag := <-servant.tags; tag != "tag-1" {
+		t.Errorf("servant slot = %v, want tag-1", tag)
+	}
+	if <-server.servantKnown {
+		t.Error("servant looked up before ReceiveRequestServiceContexts")
+	}
+	if status := <-server.replyStatuses; status != corba.ReplySuccessful {
+		t.Errorf("server reply status = %d, want ReplySuccessful", status)
+	}
+
+	info := initializer.client.sent[0]
+	if info.Target != target {
+		t.Error("RequestInfo.Target is not the invoked reference")
+	}
+	if info.EffectiveTarget == nil || string(info.EffectiveTarget.GetObjectKey()) != string(ref.GetObjectKey()) {
+		t.Errorf("RequestInfo.EffectiveTarget = %v", info.EffectiveTarget)
+	}
+	if info.EffectiveProfile.Tag != corba.TAG_MEM_IOP {
+		t.Errorf("RequestInfo.EffectiveProfile tag = %d, want TAG_MEM_IOP", info.EffectiveProfile.Tag)
+	}
+	if info.SyncScope != corba.SyncWithTarget || info.ReplyStatus != corba.ReplySuccessful {
+		t.Errorf("RequestInfo sync scope %d, reply status %d", info.SyncScope, info.ReplyStatus)
+	}
+
+	// Requests for unknown objects are seen before the lookup fails
+	unknown, err := client.GetObjectAt("NoSuchObject", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+	_, err = unknown.Invoke("ping")
+	wantSystemException(t, err, "OBJECT_NOT_EXIST")
+	<-server.servantKnown
+	wantSentException(t, server, "an unknown object")
+	if got := initializer.client.got[1]; got.ReplyStatus != corba.ReplySystemException {
+		t.Errorf("client reply status = %d, want ReplySystemException", got.ReplyStatus)
+	}
+
+	// So are requests whose IDL context cannot be read
+	greeter, err := orb.GetRootPOA().ServantToReference(&greeterServant{received: make(chan map[string]interface{}, 1)})
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	greeterTarget, err := client.GetObjectAt(string(greeter.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+	_, err = greeterTarget.Invoke("greet")
+	wantMinorCode(t, err, "MARSHAL", corba.MinorMalformedRequest)
+	<-server.servantKnown
+	wantSentException(t, server, "a request without its IDL context")
+
+	// The ORBInitInfo expires with initialization
+	_, err = initializer.info.AllocateSlotID()
+	wantMinorCode(t, err, "OBJECT_NOT_EXIST", corba.MinorORBInitInfoExpired)
+}
+
+// wantSentException checks that the SendException point saw a system exception
+func wantSentException(t *testing.T, server *tagServerInterceptor, request string) {
+	t.Helper()
+
+	select {
+	case status := <-server.replyStatuses:
+		if status != corba.ReplySystemException {
+			t.Errorf("reply status for %s = %d, want ReplySystemException", request, status)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("SendException not called for %s", request)
+	}
+}
+
+func TestORBInitInfoDuplicateName(t *testing.T) {
+	initializer := &duplicateInitializer{}
+	corba.InitWithInitializers(initializer)
+	if initializer.err != corba.ErrDuplicateName {
+		t.Errorf("adding a second interceptor named tag: %v, want DuplicateName", initializer.err)
+	}
+}
+
+// duplicateInitializer adds two client interceptors of the same name
+type duplicateInitializer struct {
+	err error
+}
+
+func (i *duplicateInitializer) PreInit(info *corba.ORBInitInfo) {
+	info.AddClientRequestInterceptor(&tagClientInterceptor{})
+	i.err = info.AddClientRequestInterceptor(&tagClientInterceptor{})
+}
+
+func (i *duplicateInitializer) PostInit(info *corba.ORBInitInfo) {}
+
+func TestPICurrentInvalidSlot(t *testing.T) {
+	current := corba.Init().NewPICurrent()
+	if err := current.SetSlot(0, "x"); err != corba.ErrInvalidSlot {
+		t.Errorf("SetSlot of an unallocated slot: %v, want InvalidSlot", err)
+	}
+}
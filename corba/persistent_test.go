@@ -0,0 +1,98 @@
+package corba_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// freeTCPPort returns a TCP port on the loopback interface that is not in use
+func freeTCPPort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// persistentPOA creates the "Bank" POA with PERSISTENT lifespan and user assigned IDs
+func persistentPOA(t *testing.T, orb *corba.ORB, policies ...corba.POAPolicy) *corba.POA {
+	t.Helper()
+
+	policies = append(policies,
+		corba.NewLifespanPolicy(corba.PersistentLifespan),
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+	)
+	poa, err := orb.GetRootPOA().CreatePOA("Bank", nil, policies)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	return poa
+}
+
+func TestPersistentReferenceSurvivesRestart(t *testing.T) {
+	fixed := corba.NewTCPEndpoint("127.0.0.1", freeTCPPort(t))
+
+	// First incarnation: an ephemeral listener is started before the fixed one
+	orb1 := corba.Init()
+	runServer(t, orb1, corba.NewTCPEndpoint("127.0.0.1", 0))
+	runServer(t, orb1, fixed)
+
+	bank := persistentPOA(t, orb1)
+	if err := bank.ActivateObjectWithID(corba.ObjectID("acct-1"), &recordingServant{calls: make(chan string, 1)}); err != nil {
+		t.Fatalf("ActivateObjectWithID: %v", err)
+	}
+	ref := bank.CreateReferenceWithId(corba.ObjectID("acct-1"), "IDL:Test/Account:1.0")
+	if ref.Endpoint() != fixed || len(ref.GetIOR().Profiles) != 1 {
+		t.Fatalf("persistent reference points at %v with %d profiles, want only %v",
+			ref.Endpoint(), len(ref.GetIOR().Profiles), fixed)
+	}
+	ior, err := orb1.ObjectToString(ref)
+	if err != nil {
+		t.Fatalf("ObjectToString: %v", err)
+	}
+
+	transientOID, err := orb1.GetRootPOA().ActivateObject(&recordingServant{calls: make(chan string, 1)})
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	transientRef := orb1.GetRootPOA().CreateReferenceWithId(transientOID, "IDL:Test/Echo:1.0")
+
+	orb1.Shutdown(true)
+
+	// Second incarnation on the same endpoint incarnates the object on demand
+	orb2 := corba.Init()
+	runServer(t, orb2, fixed)
+
+	bank = persistentPOA(t, orb2, corba.NewRequestProcessingPolicy(corba.UseServantManager))
+	servant := &recordingServant{calls: make(chan string, 1)}
+	incarnated := make(chan corba.ObjectID, 1)
+	bank.SetServantManager(&corba.BasicServantActivator{
+		IncarnateFunc: func(oid corba.ObjectID, poa *corba.POA) (interface{}, error) {
+			incarnated <- oid
+			return servant, nil
+		},
+	})
+
+	restored, err := orb2.StringToObject(ior)
+	if err != nil {
+		t.Fatalf("StringToObject: %v", err)
+	}
+	if _, err := restored.Invoke("deposit"); err != nil {
+		t.Fatalf("Invoke after restart: %v", err)
+	}
+	if oid := <-incarnated; string(oid) != "acct-1" {
+		t.Errorf("incarnated %q, want %q", oid, "acct-1")
+	}
+	if op := <-servant.calls; op != "deposit" {
+		t.Errorf("servant received %q, want %q", op, "deposit")
+	}
+
+	// Transient references die with the POA that created them
+	err = invokeRef(t, orb2.CreateClient(), fixed, transientRef, "ping")
+	wantSystemException(t, err, "OBJECT_NOT_EXIST")
+}
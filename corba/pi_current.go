@@ -0,0 +1,88 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"context"
+	"sync"
+)
+
+// SlotID identifies a PICurrent slot allocated by an ORBInitializer
+type SlotID uint32
+
+// ErrInvalidSlot is raised when a slot ID was not allocated by the ORB
+var ErrInvalidSlot = NewCORBAUserException("InvalidSlot", "IDL:omg.org/PortableInterceptor/InvalidSlot:1.0")
+
+// PICurrent is the PortableInterceptor::Current of an invocation: a table of
+// slots through which services pass data between the application and their
+// interceptors. Go has no thread-specific state, so a PICurrent travels in a
+// context.Context instead of with the calling thread.
+//
+// On the client side, the slots of the PICurrent in the context passed to
+// ObjectRef.InvokeWithContext are copied into the RequestInfo of the request.
+// On the server side, the servant finds a copy of the slots set by the server
+// interceptors in the context of its dispatch.
+type PICurrent struct {
+	mu    sync.RWMutex
+	slots []interface{}
+}
+
+// NewPICurrent creates an empty PICurrent with the slots allocated by the
+// ORB's initializers
+func (orb *ORB) NewPICurrent() *PICurrent {
+	return &PICurrent{slots: make([]interface{}, orb.slotCount)}
+}
+
+// GetSlot returns the data in a slot, or nil if the slot was not set
+func (c *PICurrent) GetSlot(id SlotID) (interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if int(id) >= len(c.slots) {
+		return nil, ErrInvalidSlot
+	}
+	return c.slots[id], nil
+}
+
+// SetSlot sets the data in a slot
+func (c *PICurrent) SetSlot(id SlotID, data interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if int(id) >= len(c.slots) {
+		return ErrInvalidSlot
+	}
+	c.slots[id] = data
+	return nil
+}
+
+// copySlots returns a copy of the slot table, sized for count slots. A nil
+// PICurrent yields empty slots.
+func (c *PICurrent) copySlots(count int) []interface{} {
+	slots := make([]interface{}, count)
+	if c != nil {
+		c.mu.RLock()
+		copy(slots, c.slots)
+		c.mu.RUnlock()
+	}
+	return slots
+}
+
+// piCurrentKey is the context key of the PICurrent
+type piCurrentKey struct{}
+
+// WithPICurrent returns a context carrying a PICurrent, whose slots are sent
+// to the client interceptors of the invocations made with the context
+func WithPICurrent(ctx context.Context, current *PICurrent) context.Context {
+	return context.WithValue(ctx, piCurrentKey{}, current)
+}
+
+// PICurrentFromContext returns the PICurrent carried by a context. Contexts
+// of requests dispatched by the ORB carry the slots set by the server
+// interceptors.
+func PICurrentFromContext(ctx context.Context) (*PICurrent, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	current, ok := ctx.Value(piCurrentKey{}).(*PICurrent)
+	return current, ok
+}
@@ -2,9 +2,13 @@
 package corba
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"slices"
 	"sync"
-	"time"
+
+	"github.com/google/uuid"
 )
 
 // POAPolicyID represents a POA policy ID
@@ -115,18 +119,26 @@ func NewRequestProcessingPolicy(value int) POAPolicy {
 	return &policyImpl{policyID: RequestProcessingPolicyID, value: value}
 }
 
-// POA interface errors
+// newPOAException creates a user exception of the PortableServer::POA interface
+func newPOAException(name string) *UserException {
+	return NewCORBAUserException(name, "IDL:omg.org/PortableServer/POA/"+name+":1.0")
+}
+
+// POA interface errors. Apart from ErrInvalidObjectID they are the CORBA user
+// exceptions of the PortableServer module and can be compared with ==.
 var (
-	ErrAdapterAlreadyExists = fmt.Errorf("adapter already exists")
-	ErrAdapterNonExistent   = fmt.Errorf("adapter non-existent")
-	ErrInvalidPolicy        = fmt.Errorf("invalid policy")
-	ErrNoServant            = fmt.Errorf("no servant")
-	ErrObjectNotActive      = fmt.Errorf("object not active")
-	ErrServantAlreadyActive = fmt.Errorf("servant already active")
-	ErrWrongAdapter         = fmt.Errorf("wrong adapter")
-	ErrWrongPolicy          = fmt.Errorf("wrong policy")
-	ErrObjectAlreadyActive  = fmt.Errorf("object already active")
+	ErrAdapterAlreadyExists = newPOAException("AdapterAlreadyExists")
+	ErrAdapterNonExistent   = newPOAException("AdapterNonExistent")
+	ErrInvalidPolicy        = newPOAException("InvalidPolicy")
+	ErrNoServant            = newPOAException("NoServant")
+	ErrObjectNotActive      = newPOAException("ObjectNotActive")
+	ErrServantAlreadyActive = newPOAException("ServantAlreadyActive")
+	ErrServantNotActive     = newPOAException("ServantNotActive")
+	ErrWrongAdapter         = newPOAException("WrongAdapter")
+	ErrWrongPolicy          = newPOAException("WrongPolicy")
+	ErrObjectAlreadyActive  = newPOAException("ObjectAlreadyActive")
 	ErrInvalidObjectID      = fmt.Errorf("invalid object id")
+	ErrAdapterInactive      = NewCORBAUserException("AdapterInactive", "IDL:omg.org/PortableServer/POAManager/AdapterInactive:1.0")
 )
 
 // ObjectID represents an object identifier in a POA
@@ -141,7 +153,7 @@ type ServantManager interface {
 type ServantActivator interface {
 	ServantManager
 	Incarnate(objectID ObjectID, adapter *POA) (interface{}, error)
-	Etherealize(objectID ObjectID, adapter *POA, servant interface{}, cleanup bool) error
+	Etherealize(objectID ObjectID, adapter *POA, servant interface{}, cleanupInProgress bool, remainingActivations bool) error
 }
 
 // ServantLocator interface for finding servants per-request
@@ -151,20 +163,40 @@ type ServantLocator interface {
 	Postinvoke(objectID ObjectID, adapter *POA, operation string, servant interface{}, cookieVal interface{}) error
 }
 
+// AdapterActivator creates child POAs on demand. It is called when FindPOA is
+// asked to activate, or a request arrives for, a child POA that does not exist.
+type AdapterActivator interface {
+	// UnknownAdapter creates the child POA named name with parent.CreatePOA and
+	// reports whether it did so. Requests for the child are held until it returns.
+	UnknownAdapter(parent *POA, name string) bool
+}
+
 // POA represents a Portable Object Adapter
 type POA struct {
-	name            string
-	parent          *POA
-	orb             *ORB
-	children        map[string]*POA
-	policies        map[POAPolicyID]POAPolicy
-	defaultServant  interface{}
-	servantManager  ServantManager
-	objectMap       map[string]interface{}   // Maps object ID (string) to servant
-	oidToServantMap map[string]interface{}   // For efficient lookup
-	servantToOidMap map[interface{}][]string // For efficient lookup (multiple OIDs per servant if MultipleID policy)
-	mutex           sync.RWMutex
-	isActive        bool
+	name             string
+	parent           *POA
+	orb              *ORB
+	children         map[string]*POA
+	policies         map[POAPolicyID]POAPolicy
+	defaultServant   interface{}
+	servantManager   ServantManager
+	adapterActivator AdapterActivator
+	activating       map[string]chan struct{} // Children being created by the adapter activator
+	objectMap        map[string]interface{}   // Maps object ID (string) to servant
+	oidToServantMap  map[string]interface{}   // For efficient lookup
+	servantToOidMap  map[interface{}][]string // For efficient lookup (multiple OIDs per servant if MultipleID policy)
+	mutex            sync.RWMutex
+	isActive         bool
+	instanceID       uint64          // Distinguishes incarnations of a TRANSIENT POA in object keys
+	manager          *POAManager     // Controls whether requests are dispatched, held or rejected
+	runMu            sync.Mutex      // Guards the run queue and the dispatch limit
+	runQueue         []func()        // Requests waiting for the POA's thread policy or limit
+	running          int             // Requests dispatched or being dispatched by a worker
+	maxConcurrent    int             // Per-POA dispatch limit, zero if there is none
+	inFlight         map[string]int  // Requests being dispatched, per ObjectID
+	deactivating     map[string]bool // Objects to remove once their requests complete
+	active           int             // Requests being dispatched on the POA
+	drained          chan struct{}   // Closed when the last request being dispatched completes
 
 	// Cached policy values for quick access
 	threadModel        int
@@ -197,7 +229,10 @@ func (o *ORB) NewRootPOA() *POA {
 		objectMap:       make(map[string]interface{}),
 		oidToServantMap: make(map[string]interface{}),
 		servantToOidMap: make(map[interface{}][]string),
+		inFlight:        make(map[string]int),
+		deactivating:    make(map[string]bool),
 		isActive:        true,
+		instanceID:      rand.Uint64(),
 
 		// Cache policy values
 		threadModel:        ORBControlledModel,
@@ -210,14 +245,21 @@ func (o *ORB) NewRootPOA() *POA {
 	}
 
 	// Register the POA manager
-	o.poaManagers = append(o.poaManagers, &POAManager{
-		state: POAManagerActive,
-		poas:  []*POA{poa},
-	})
+	poa.manager = &POAManager{
+		state:      POAManagerActive,
+		poas:       []*POA{poa},
+		queueLimit: DefaultPOAManagerQueueLimit,
+	}
+	o.poaManagers = append(o.poaManagers, poa.manager)
 
 	return poa
 }
 
+// GetPOAManager returns the POA manager associated with the POA
+func (p *POA) GetPOAManager() *POAManager {
+	return p.manager
+}
+
 // GetPolicy returns a policy for the POA
 func (p *POA) GetPolicy(policyID POAPolicyID) (POAPolicy, error) {
 	p.mutex.RLock()
@@ -231,6 +273,24 @@ func (p *POA) GetPolicy(policyID POAPolicyID) (POAPolicy, error) {
 
 // CreatePOA creates a new child POA with the given name and policies
 func (p *POA) CreatePOA(name string, manager *POAManager, policies []POAPolicy) (*POA, error) {
+	child, err := p.createChild(name, manager, policies)
+	if err != nil {
+		return nil, err
+	}
+
+	// A persistent POA created while the server runs is registered right away
+	if child.lifespan == PersistentLifespan {
+		if err := p.orb.registerPOA(child); err != nil {
+			child.Destroy(false, false)
+			return nil, err
+		}
+	}
+
+	return child, nil
+}
+
+// createChild creates and attaches a child POA
+func (p *POA) createChild(name string, manager *POAManager, policies []POAPolicy) (*POA, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
@@ -239,16 +299,15 @@ func (p *POA) CreatePOA(name string, manager *POAManager, policies []POAPolicy)
 		return nil, ErrAdapterAlreadyExists
 	}
 
-	// Start with default policies from parent
-	childPolicies := make(map[POAPolicyID]POAPolicy)
-	for id, policy := range p.policies {
-		childPolicies[id] = policy
-	}
-
-	// Override with provided policies
+	// Policies that are not given take their default values, which differ
+	// from the root POA's only in IMPLICIT_ACTIVATION
+	childPolicies := defaultPOAPolicies()
 	for _, policy := range policies {
 		childPolicies[policy.ID()] = policy
 	}
+	if err := validatePolicies(childPolicies); err != nil {
+		return nil, err
+	}
 
 	// Create the child POA
 	child := &POA{
@@ -260,7 +319,10 @@ func (p *POA) CreatePOA(name string, manager *POAManager, policies []POAPolicy)
 		objectMap:       make(map[string]interface{}),
 		oidToServantMap: make(map[string]interface{}),
 		servantToOidMap: make(map[interface{}][]string),
+		inFlight:        make(map[string]int),
+		deactivating:    make(map[string]bool),
 		isActive:        true,
+		instanceID:      rand.Uint64(),
 	}
 
 	// Cache policy values
@@ -269,22 +331,60 @@ func (p *POA) CreatePOA(name string, manager *POAManager, policies []POAPolicy)
 	// Add to parent's children list
 	p.children[name] = child
 
-	// Add to POA manager
-	if manager != nil {
-		manager.addPOA(child)
-	} else {
-		// Use parent's manager by default
-		for _, mgr := range p.orb.poaManagers {
-			if containsPOA(mgr.poas, p) {
-				mgr.addPOA(child)
-				break
-			}
-		}
+	// Add to POA manager, using the parent's manager by default
+	if manager == nil {
+		manager = p.manager
 	}
+	child.manager = manager
+	manager.addPOA(child)
 
 	return child, nil
 }
 
+// defaultPOAPolicies returns the policies of a POA created without any
+func defaultPOAPolicies() map[POAPolicyID]POAPolicy {
+	return map[POAPolicyID]POAPolicy{
+		ThreadPolicyID:             NewThreadPolicy(ORBControlledModel),
+		LifespanPolicyID:           NewLifespanPolicy(TransientLifespan),
+		IdUniquenessPolicyID:       NewIdUniquenessPolicy(UniqueID),
+		IdAssignmentPolicyID:       NewIdAssignmentPolicy(SystemAssignedID),
+		ImplicitActivationPolicyID: NewImplicitActivationPolicy(ImplicitActivationDisabled),
+		ServantRetentionPolicyID:   NewServantRetentionPolicy(RetainServants),
+		RequestProcessingPolicyID:  NewRequestProcessingPolicy(UseActiveObjectMapOnly),
+	}
+}
+
+// validatePolicies rejects the policy combinations the specification forbids
+func validatePolicies(policies map[POAPolicyID]POAPolicy) error {
+	value := func(id POAPolicyID) int {
+		v, ok := policies[id].Value().(int)
+		if !ok {
+			return -1
+		}
+		return v
+	}
+
+	retain := value(ServantRetentionPolicyID) == RetainServants
+	switch value(RequestProcessingPolicyID) {
+	case UseActiveObjectMapOnly:
+		// Without retained servants there would be nothing to dispatch to
+		if !retain {
+			return ErrInvalidPolicy
+		}
+	case UseDefaultServant:
+		if value(IdUniquenessPolicyID) != MultipleID {
+			return ErrInvalidPolicy
+		}
+	}
+
+	if value(ImplicitActivationPolicyID) == ImplicitActivationEnabled &&
+		(value(IdAssignmentPolicyID) != SystemAssignedID || !retain) {
+		return ErrInvalidPolicy
+	}
+
+	return nil
+}
+
 // setCachedPolicyValues caches policy values for quick access
 func (p *POA) setCachedPolicyValues() {
 	if policy, ok := p.policies[ThreadPolicyID]; ok {
@@ -310,83 +410,140 @@ func (p *POA) setCachedPolicyValues() {
 	}
 }
 
-// Helper function to check if a POA slice contains a specific POA
-func containsPOA(poas []*POA, poa *POA) bool {
-	for _, p := range poas {
-		if p == poa {
-			return true
-		}
+// FindPOA finds a child POA by name. If the child does not exist and activate
+// is set, the POA's adapter activator is asked to create it; an activator
+// that panics yields OBJ_ADAPTER. Concurrent calls for a child being created
+// wait for the activator to finish.
+func (p *POA) FindPOA(name string, activate bool) (*POA, error) {
+	p.mutex.Lock()
+	if child, exists := p.children[name]; exists {
+		p.mutex.Unlock()
+		return child, nil
+	}
+	if !activate || p.adapterActivator == nil {
+		p.mutex.Unlock()
+		return nil, ErrAdapterNonExistent
+	}
+	if done, busy := p.activating[name]; busy {
+		p.mutex.Unlock()
+		<-done
+		return p.FindPOA(name, false)
 	}
-	return false
-}
 
-// FindPOA finds a child POA by name
-func (p *POA) FindPOA(name string, activate bool) (*POA, error) {
-	p.mutex.RLock()
+	done := make(chan struct{})
+	if p.activating == nil {
+		p.activating = make(map[string]chan struct{})
+	}
+	p.activating[name] = done
+	activator := p.adapterActivator
+	p.mutex.Unlock()
+
+	// The activator is called without the lock, since it creates the child
+	created, err := p.unknownAdapter(activator, name)
+
+	p.mutex.Lock()
+	delete(p.activating, name)
+	close(done)
 	child, exists := p.children[name]
-	p.mutex.RUnlock()
+	p.mutex.Unlock()
 
-	if !exists {
+	if err != nil {
+		return nil, err
+	}
+	if !created || !exists {
 		return nil, ErrAdapterNonExistent
 	}
+	return child, nil
+}
 
-	if activate && !child.isActive {
-		child.Activate()
-	}
+// unknownAdapter calls an adapter activator. An activator that panics is
+// reported as OBJ_ADAPTER.
+func (p *POA) unknownAdapter(activator AdapterActivator, name string) (created bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			created, err = false, OBJ_ADAPTER(MinorAdapterActivatorFailed, CompletionStatusNo)
+		}
+	}()
+	return activator.UnknownAdapter(p, name), nil
+}
 
-	return child, nil
+// SetAdapterActivator sets the adapter activator that creates child POAs on demand
+func (p *POA) SetAdapterActivator(activator AdapterActivator) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.adapterActivator = activator
+}
+
+// GetAdapterActivator returns the POA's adapter activator, or nil
+func (p *POA) GetAdapterActivator() AdapterActivator {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.adapterActivator
 }
 
-// Destroy destroys this POA and all its children
+// Destroy destroys this POA and all its children. Requests for their objects
+// fail from now on; requests already being dispatched run to completion, after
+// which the active objects are etherealized if etherializeObjects is set and the
+// POA has a servant activator. With waitForCompletion set, Destroy returns once
+// that has happened.
 func (p *POA) Destroy(etherializeObjects bool, waitForCompletion bool) error {
-	p.mutex.Lock()
+	return p.DestroyContext(context.Background(), etherializeObjects, waitForCompletion)
+}
 
-	// First deactivate the POA
-	p.isActive = false
+// DestroyContext is Destroy for callers that have a context. Waiting for
+// completion ends when the context is done, and fails with BAD_INV_ORDER if
+// the context is that of a request dispatched by the same ORB, since the wait
+// would never end. Servants must pass their request context.
+func (p *POA) DestroyContext(ctx context.Context, etherializeObjects bool, waitForCompletion bool) error {
+	if waitForCompletion {
+		if err := p.checkWaitAllowed(ctx); err != nil {
+			return err
+		}
+	}
 
-	// Destroy all children first
+	p.mutex.Lock()
+	p.isActive = false
+	children := make([]*POA, 0, len(p.children))
 	for _, child := range p.children {
-		child.Destroy(etherializeObjects, waitForCompletion)
+		children = append(children, child)
 	}
+	p.mutex.Unlock()
 
-	// Clear children map
-	p.children = make(map[string]*POA)
-
-	if etherializeObjects {
-		// Etherealize all active objects
-		if activator, ok := p.servantManager.(ServantActivator); ok && p.servantRetention == NonRetainServants {
-			for objectID, servant := range p.objectMap {
-				// Convert string objectID back to []byte for ServantActivator
-				oid := []byte(objectID)
-				activator.Etherealize(oid, p, servant, true)
-			}
+	// Destroy all children first
+	for _, child := range children {
+		if err := child.DestroyContext(ctx, etherializeObjects, waitForCompletion); err != nil {
+			return err
 		}
 	}
 
-	// Clear maps
-	p.objectMap = make(map[string]interface{})
-	p.oidToServantMap = make(map[string]interface{})
-	p.servantToOidMap = make(map[interface{}][]string)
+	p.mutex.Lock()
+	p.children = make(map[string]*POA)
 
 	// Remove from manager
 	for _, mgr := range p.orb.poaManagers {
 		mgr.removePOA(p)
 	}
 
-	// If parent exists, remove from parent's children
-	if p.parent != nil {
-		delete(p.parent.children, p.name)
-	}
-
+	drained := p.drainedLocked()
 	p.mutex.Unlock()
 
-	if waitForCompletion {
-		// In a real implementation, we would wait for all outstanding requests to complete
-		// For now, we'll just simulate a small delay
-		time.Sleep(100 * time.Millisecond)
+	// If parent exists, remove from parent's children
+	if p.parent != nil {
+		p.parent.mutex.Lock()
+		if p.parent.children[p.name] == p {
+			delete(p.parent.children, p.name)
+		}
+		p.parent.mutex.Unlock()
 	}
 
-	return nil
+	return p.afterCompletion(ctx, drained, waitForCompletion, func() {
+		if etherializeObjects {
+			p.etherealizeObjects()
+		}
+		p.clearObjects()
+	})
 }
 
 // Activate activates the POA
@@ -396,58 +553,75 @@ func (p *POA) Activate() {
 	p.isActive = true
 }
 
-// Deactivate deactivates the POA
+// Deactivate deactivates the POA. Once the requests being dispatched have
+// completed, the active objects are etherealized if etherializeObjects is set
+// and the POA has a servant activator. With waitForCompletion set, Deactivate
+// returns once that has happened.
 func (p *POA) Deactivate(etherializeObjects bool, waitForCompletion bool) error {
-	p.mutex.Lock()
-	p.isActive = false
-	p.mutex.Unlock()
+	return p.DeactivateContext(context.Background(), etherializeObjects, waitForCompletion)
+}
 
-	if etherializeObjects {
-		// Etherealize all active objects
-		if activator, ok := p.servantManager.(ServantActivator); ok && p.servantRetention == NonRetainServants {
-			p.mutex.RLock()
-			for objectID, servant := range p.objectMap {
-				oid := []byte(objectID)
-				activator.Etherealize(oid, p, servant, true)
-			}
-			p.mutex.RUnlock()
+// DeactivateContext is Deactivate for callers that have a context, with the
+// waiting rules of DestroyContext
+func (p *POA) DeactivateContext(ctx context.Context, etherializeObjects bool, waitForCompletion bool) error {
+	if waitForCompletion {
+		if err := p.checkWaitAllowed(ctx); err != nil {
+			return err
 		}
 	}
 
-	if waitForCompletion {
-		// In a real implementation, we would wait for all outstanding requests to complete
-		// For now, we'll just simulate a small delay
-		time.Sleep(100 * time.Millisecond)
-	}
+	p.mutex.Lock()
+	p.isActive = false
+	drained := p.drainedLocked()
+	p.mutex.Unlock()
 
-	return nil
+	return p.afterCompletion(ctx, drained, waitForCompletion, func() {
+		if etherializeObjects {
+			p.etherealizeObjects()
+		}
+	})
 }
 
-// ServantToID gets the ObjectID associated with a servant
+// ServantToID gets the ObjectID associated with a servant. It requires
+// USE_DEFAULT_SERVANT, or RETAIN together with UNIQUE_ID or IMPLICIT_ACTIVATION.
+// Under IMPLICIT_ACTIVATION a servant that is not active, or any servant under
+// MULTIPLE_ID, is activated with a new ObjectID.
 func (p *POA) ServantToID(servant interface{}) (ObjectID, error) {
-	p.mutex.RLock()
-	defer p.mutex.RUnlock()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	retain := p.servantRetention == RetainServants
+	implicit := p.implicitActivation == ImplicitActivationEnabled
+	if p.requestProcessing != UseDefaultServant && !(retain && (p.uniqueID == UniqueID || implicit)) {
+		return nil, ErrWrongPolicy
+	}
+	if !comparableServant(servant) {
+		return nil, BAD_PARAM(MinorUncomparableServant, CompletionStatusNo)
+	}
 
-	// Check if servant is registered
-	oids, exists := p.servantToOidMap[servant]
-	if !exists || len(oids) == 0 {
-		// Check if implicit activation is enabled
-		if p.implicitActivation == ImplicitActivationEnabled {
-			// Generate a new ObjectID
+	if retain {
+		oids := p.servantToOidMap[servant]
+		if len(oids) > 0 && p.uniqueID == UniqueID {
+			return ObjectID(oids[0]), nil
+		}
+		if implicit {
 			return p.activateObject(servant)
 		}
-		return nil, ErrNoServant
 	}
 
-	// Return first OID (most common case)
-	return []byte(oids[0]), nil
+	return nil, ErrServantNotActive
 }
 
-// IDToServant gets the servant associated with an ObjectID
+// IDToServant gets the servant associated with an ObjectID. It requires RETAIN
+// or USE_DEFAULT_SERVANT. Objects that are not active are not incarnated.
 func (p *POA) IDToServant(id ObjectID) (interface{}, error) {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
+	if p.servantRetention != RetainServants && p.requestProcessing != UseDefaultServant {
+		return nil, ErrWrongPolicy
+	}
+
 	// Convert ObjectID to string for map lookup
 	oidStr := string(id)
 
@@ -463,49 +637,63 @@ func (p *POA) IDToServant(id ObjectID) (interface{}, error) {
 		return p.defaultServant, nil
 	}
 
-	// If we have a servant manager and policy allows it
-	if p.requestProcessing == UseServantManager && p.servantManager != nil {
-		// ServantActivator case
-		if p.servantRetention == RetainServants {
-			if activator, ok := p.servantManager.(ServantActivator); ok {
-				servant, err := activator.Incarnate(id, p)
-				if err != nil {
-					return nil, err
-				}
-
-				// Add to object map
-				p.mutex.RUnlock()
-				p.mutex.Lock()
-				p.objectMap[oidStr] = servant
-				p.oidToServantMap[oidStr] = servant
-
-				// Add to servant-to-OID map based on uniqueness policy
-				if p.uniqueID == UniqueID {
-					p.servantToOidMap[servant] = []string{oidStr}
-				} else {
-					p.servantToOidMap[servant] = append(p.servantToOidMap[servant], oidStr)
-				}
-				p.mutex.Unlock()
-				p.mutex.RLock()
-
-				return servant, nil
-			}
-		}
+	// Servant managers are only consulted for requests
+	return nil, ErrObjectNotActive
+}
+
+// incarnate asks a servant activator for the servant of id and enters it in the
+// active object map. The POA lock must not be held, since activators commonly
+// call back into the POA.
+func (p *POA) incarnate(activator ServantActivator, id ObjectID) (interface{}, error) {
+	servant, err := activator.Incarnate(id, p)
+	if err != nil {
+		return nil, err
+	}
+	if !comparableServant(servant) {
+		return nil, BAD_PARAM(MinorUncomparableServant, CompletionStatusNo)
 	}
 
-	return nil, ErrObjectNotActive
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	oidStr := string(id)
+
+	// Another request may have incarnated the object in the meantime
+	if existing, exists := p.oidToServantMap[oidStr]; exists {
+		return existing, nil
+	}
+
+	p.objectMap[oidStr] = servant
+	p.oidToServantMap[oidStr] = servant
+
+	// Add to servant-to-OID map based on uniqueness policy
+	if p.uniqueID == UniqueID {
+		p.servantToOidMap[servant] = []string{oidStr}
+	} else {
+		p.servantToOidMap[servant] = append(p.servantToOidMap[servant], oidStr)
+	}
+
+	return servant, nil
 }
 
-// ActivateObject activates an object with a system-generated ID
+// ActivateObject activates an object with a system-generated ID. It requires
+// SYSTEM_ID and RETAIN. The servant must be comparable, as pointers are;
+// other servants are refused with BAD_PARAM.
 func (p *POA) ActivateObject(servant interface{}) (ObjectID, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	if p.servantRetention != RetainServants {
+		return nil, ErrWrongPolicy
+	}
 	return p.activateObject(servant)
 }
 
 // internal helper to activate an object
 func (p *POA) activateObject(servant interface{}) (ObjectID, error) {
+	if !comparableServant(servant) {
+		return nil, BAD_PARAM(MinorUncomparableServant, CompletionStatusNo)
+	}
 	// Check servant uniqueness policy
 	if p.uniqueID == UniqueID {
 		// Check if servant is already active
@@ -545,7 +733,9 @@ func (p *POA) activateObject(servant interface{}) (ObjectID, error) {
 	return objectID, nil
 }
 
-// ActivateObjectWithID activates an object with a user-provided ID
+// ActivateObjectWithID activates an object with a user-provided ID. It
+// requires USER_ID and RETAIN. Like ActivateObject, it refuses servants that
+// are not comparable.
 func (p *POA) ActivateObjectWithID(id ObjectID, servant interface{}) error {
 	if len(id) == 0 {
 		return ErrInvalidObjectID
@@ -554,6 +744,13 @@ func (p *POA) ActivateObjectWithID(id ObjectID, servant interface{}) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	if p.idAssignment != UserAssignedID || p.servantRetention != RetainServants {
+		return ErrWrongPolicy
+	}
+	if !comparableServant(servant) {
+		return BAD_PARAM(MinorUncomparableServant, CompletionStatusNo)
+	}
+
 	// Check if this ObjectID is already in use
 	oidStr := string(id)
 	if _, exists := p.oidToServantMap[oidStr]; exists {
@@ -582,33 +779,60 @@ func (p *POA) ActivateObjectWithID(id ObjectID, servant interface{}) error {
 	return nil
 }
 
-// DeactivateObject deactivates an object with the given ObjectID
+// DeactivateObject deactivates an object with the given ObjectID. It requires
+// RETAIN. The object is removed from the active object map, and its servant
+// etherealized by the servant activator if there is one, as soon as no request
+// for it is being dispatched. Until then, new requests for it are rejected.
 func (p *POA) DeactivateObject(id ObjectID) error {
 	if len(id) == 0 {
 		return ErrInvalidObjectID
 	}
 
 	p.mutex.Lock()
-	defer p.mutex.Unlock()
+
+	if p.servantRetention != RetainServants {
+		p.mutex.Unlock()
+		return ErrWrongPolicy
+	}
 
 	oidStr := string(id)
 
 	// Check if the object is active
-	servant, exists := p.oidToServantMap[oidStr]
-	if !exists {
+	if _, exists := p.oidToServantMap[oidStr]; !exists {
+		p.mutex.Unlock()
 		return ErrObjectNotActive
 	}
 
-	// Call etherealize if a servant activator is registered
-	if p.servantManager != nil {
-		if activator, ok := p.servantManager.(ServantActivator); ok {
-			activator.Etherealize(id, p, servant, true)
-		}
+	if p.inFlight[oidStr] > 0 {
+		// The last request to complete removes the object
+		p.deactivating[oidStr] = true
+		p.mutex.Unlock()
+		return nil
+	}
+
+	etherealize := p.removeObject(oidStr, false)
+	p.mutex.Unlock()
+
+	// Activators commonly call back into the POA, so the lock is released first
+	if etherealize != nil {
+		etherealize()
+	}
+	return nil
+}
+
+// removeObject removes an object from the active object map. It returns a
+// function etherealizing the servant if the POA has a servant activator, to be
+// called once the lock is released. The lock must be held.
+func (p *POA) removeObject(oidStr string, cleanup bool) func() {
+	servant, exists := p.oidToServantMap[oidStr]
+	if !exists {
+		return nil
 	}
 
 	// Remove from object maps
 	delete(p.objectMap, oidStr)
 	delete(p.oidToServantMap, oidStr)
+	delete(p.deactivating, oidStr)
 
 	// Update servant-to-OID map
 	oids := p.servantToOidMap[servant]
@@ -625,18 +849,62 @@ func (p *POA) DeactivateObject(id ObjectID) error {
 		p.servantToOidMap[servant] = newOids
 	}
 
-	return nil
+	activator, ok := p.servantManager.(ServantActivator)
+	if !ok {
+		return nil
+	}
+	remaining := len(p.servantToOidMap[servant]) > 0
+	return func() {
+		activator.Etherealize(ObjectID(oidStr), p, servant, cleanup, remaining)
+	}
+}
+
+// etherealizeObjects removes all active objects and etherealizes their servants
+// if the POA has the RETAIN policy and a servant activator
+func (p *POA) etherealizeObjects() {
+	p.mutex.Lock()
+	if _, ok := p.servantManager.(ServantActivator); !ok || p.servantRetention != RetainServants {
+		p.mutex.Unlock()
+		return
+	}
+	var etherealize []func()
+	for oidStr := range p.oidToServantMap {
+		if fn := p.removeObject(oidStr, true); fn != nil {
+			etherealize = append(etherealize, fn)
+		}
+	}
+	p.mutex.Unlock()
+
+	for _, fn := range etherealize {
+		fn()
+	}
+}
+
+// clearObjects empties the active object map without etherealizing servants
+func (p *POA) clearObjects() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.objectMap = make(map[string]interface{})
+	p.oidToServantMap = make(map[string]interface{})
+	p.servantToOidMap = make(map[interface{}][]string)
+	p.deactivating = make(map[string]bool)
 }
 
-// SetServantManager sets the servant manager for this POA
+// SetServantManager sets the servant manager for this POA. It requires
+// USE_SERVANT_MANAGER. The manager must be a ServantActivator under RETAIN and
+// a ServantLocator under NON_RETAIN; otherwise OBJ_ADAPTER is returned.
 func (p *POA) SetServantManager(manager ServantManager) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.servantRetention == RetainServants && !isActivator(manager) {
+	if p.requestProcessing != UseServantManager {
 		return ErrWrongPolicy
+	}
+	if p.servantRetention == RetainServants && !isActivator(manager) {
+		return OBJ_ADAPTER(MinorNoServantManager, CompletionStatusNo)
 	} else if p.servantRetention == NonRetainServants && !isLocator(manager) {
-		return ErrWrongPolicy
+		return OBJ_ADAPTER(MinorNoServantManager, CompletionStatusNo)
 	}
 
 	p.servantManager = manager
@@ -655,11 +923,15 @@ func isLocator(manager ServantManager) bool {
 	return ok
 }
 
-// GetServantManager gets the servant manager for this POA
+// GetServantManager gets the servant manager for this POA. It requires USE_SERVANT_MANAGER.
 func (p *POA) GetServantManager() (ServantManager, error) {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
+	if p.requestProcessing != UseServantManager {
+		return nil, ErrWrongPolicy
+	}
+
 	if p.servantManager == nil {
 		return nil, ErrNoServant
 	}
@@ -667,7 +939,7 @@ func (p *POA) GetServantManager() (ServantManager, error) {
 	return p.servantManager, nil
 }
 
-// SetDefaultServant sets the default servant for this POA
+// SetDefaultServant sets the default servant for this POA. It requires USE_DEFAULT_SERVANT.
 func (p *POA) SetDefaultServant(servant interface{}) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
@@ -675,16 +947,23 @@ func (p *POA) SetDefaultServant(servant interface{}) error {
 	if p.requestProcessing != UseDefaultServant {
 		return ErrWrongPolicy
 	}
+	if !comparableServant(servant) {
+		return BAD_PARAM(MinorUncomparableServant, CompletionStatusNo)
+	}
 
 	p.defaultServant = servant
 	return nil
 }
 
-// GetDefaultServant gets the default servant for this POA
+// GetDefaultServant gets the default servant for this POA. It requires USE_DEFAULT_SERVANT.
 func (p *POA) GetDefaultServant() (interface{}, error) {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
+	if p.requestProcessing != UseDefaultServant {
+		return nil, ErrWrongPolicy
+	}
+
 	if p.defaultServant == nil {
 		return nil, ErrNoServant
 	}
@@ -702,55 +981,126 @@ func (p *POA) StringToObjectID(s string) ObjectID {
 	return ObjectID(s)
 }
 
-// generateObjectID generates a new ObjectID
+// generateObjectID generates a new ObjectID. IDs are UUIDs, so that IDs
+// assigned by a PERSISTENT POA stay unique across server restarts.
 func generateObjectID() ObjectID {
-	// In a real implementation, this would be more sophisticated (UUID)
-	// For now, just use the current time as a string
-	return ObjectID(fmt.Sprintf("OBJ_%d", time.Now().UnixNano()))
+	return ObjectID(uuid.New().String())
 }
 
-// CreateReference creates an object reference with the given repository ID
-func (p *POA) CreateReference(repositoryID string, objectKey []byte) *ObjectRef {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-
+// CreateReference creates an object reference with the given repository ID.
+// The objectID becomes the ObjectID of the reference; when it is empty, a
+// system-generated ObjectID is used. The object key of the reference encodes
+// the path of this POA so that servers can route requests back to it.
+func (p *POA) CreateReference(repositoryID string, objectID []byte) *ObjectRef {
 	// Format repository ID according to CORBA standard if it's not already formatted
 	formattedID := FormatRepositoryID(repositoryID, "")
 
-	// Create a new IOR
-	ior := NewIOR(formattedID)
+	// Determine the object ID to use
+	oid := ObjectID(objectID)
+	if len(oid) == 0 {
+		oid = generateObjectID()
+	}
 
-	// Determine the object key to use
-	var keyToUse []byte
-	if len(objectKey) > 0 {
-		keyToUse = objectKey
+	// The reference advertises the endpoints of the ORB's running servers.
+	// References of a PERSISTENT POA must outlive the process, so they only
+	// advertise endpoints whose address is fixed by configuration.
+	return p.orb.createReference(formattedID, p.objectKey(oid), p.lifespan == PersistentLifespan)
+}
+
+// path returns the names of the POAs from below the root POA down to this POA
+func (p *POA) path() []string {
+	var path []string
+	for poa := p; poa.parent != nil; poa = poa.parent {
+		path = append([]string{poa.name}, path...)
+	}
+	return path
+}
+
+// objectKey returns the encoded object key for an ObjectID of this POA
+func (p *POA) objectKey(id ObjectID) []byte {
+	key := ObjectKey{
+		POAPath:  p.path(),
+		ObjectID: id,
+	}
+	if p.lifespan == PersistentLifespan {
+		key.Persistent = true
 	} else {
-		// Generate a new object key with POA name prefix
-		keyToUse = GenerateObjectKey(p.name + "_")
+		key.POAInstance = p.instanceID
 	}
+	return key.Encode()
+}
 
-	// Get the server information from the ORB/Server
-	// For now, let's use a placeholder; in a real implementation this would come from configuration
-	host := "localhost"  // Default host
-	port := uint16(8000) // Default port
+// keyID parses an object key back into an ObjectID of this POA. Keys that were
+// not generated by this POA fail with ErrWrongAdapter.
+func (p *POA) keyID(objectKey []byte) (ObjectID, error) {
+	if !IsPOAObjectKey(objectKey) {
+		return nil, ErrWrongAdapter
+	}
+	key, err := DecodeObjectKey(objectKey)
+	if err != nil {
+		return nil, ErrWrongAdapter
+	}
 
-	// Add an IIOP profile to the IOR
-	ior.AddIIOPProfile(IIOPVersion{Major: 1, Minor: 2}, host, port, keyToUse)
+	persistent := p.lifespan == PersistentLifespan
+	if !slices.Equal(key.POAPath, p.path()) || key.Persistent != persistent ||
+		(!persistent && key.POAInstance != p.instanceID) {
+		return nil, ErrWrongAdapter
+	}
+	return key.ObjectID, nil
+}
 
-	// Create the object reference
-	ref := &ObjectRef{
-		Name:       ObjectKeyToString(keyToUse),
-		ServerHost: host,
-		ServerPort: int(port),
-		ior:        ior,
-		objectKey:  keyToUse,
-		typeID:     formattedID,
+// ReferenceToID returns the ObjectID encapsulated by a reference created by
+// this POA. References created by any other POA fail with ErrWrongAdapter.
+func (p *POA) ReferenceToID(ref *ObjectRef) (ObjectID, error) {
+	if ref == nil {
+		return nil, ErrWrongAdapter
 	}
+	return p.keyID(ref.GetObjectKey())
+}
 
-	// Set a client reference - would typically be done when the client resolves the reference
-	ref.client = p.orb.CreateClient()
+// ReferenceToServant returns the servant incarnating the object a reference
+// denotes: the active servant under RETAIN, otherwise the default servant. It
+// requires RETAIN or USE_DEFAULT_SERVANT.
+func (p *POA) ReferenceToServant(ref *ObjectRef) (interface{}, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 
-	return ref
+	if p.servantRetention != RetainServants && p.requestProcessing != UseDefaultServant {
+		return nil, ErrWrongPolicy
+	}
+	if ref == nil {
+		return nil, ErrWrongAdapter
+	}
+	id, err := p.keyID(ref.GetObjectKey())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.servantRetention == RetainServants {
+		if servant, active := p.oidToServantMap[string(id)]; active {
+			return servant, nil
+		}
+	}
+	if p.requestProcessing == UseDefaultServant && p.defaultServant != nil {
+		return p.defaultServant, nil
+	}
+	return nil, ErrObjectNotActive
+}
+
+// IDToReference creates a reference for an active object. It requires RETAIN.
+func (p *POA) IDToReference(id ObjectID) (*ObjectRef, error) {
+	p.mutex.RLock()
+	retain := p.servantRetention == RetainServants
+	servant, active := p.oidToServantMap[string(id)]
+	p.mutex.RUnlock()
+
+	if !retain {
+		return nil, ErrWrongPolicy
+	}
+	if !active {
+		return nil, ErrObjectNotActive
+	}
+	return p.CreateReferenceWithId(id, p.orb.repositoryIDFor(servant)), nil
 }
 
 // CreateReferenceWithId creates an object reference with a specific object ID
@@ -758,7 +1108,9 @@ func (p *POA) CreateReferenceWithId(id ObjectID, repositoryID string) *ObjectRef
 	return p.CreateReference(repositoryID, id)
 }
 
-// ServantToReference creates an object reference for a servant
+// ServantToReference creates an object reference for a servant. It has the
+// policy requirements of ServantToID and activates the servant implicitly in
+// the same cases.
 func (p *POA) ServantToReference(servant interface{}) (*ObjectRef, error) {
 	// First, try to get the object ID for the servant
 	objectID, err := p.ServantToID(servant)
@@ -766,24 +1118,8 @@ func (p *POA) ServantToReference(servant interface{}) (*ObjectRef, error) {
 		return nil, err
 	}
 
-	// Get the repository ID for the servant type
-	repositoryID := ""
-
-	// Try to get it from Interface Repository if available
-	if p.orb.interfaceRepository != nil {
-		id, err := p.orb.interfaceRepository.GetRepositoryID(servant)
-		if err == nil {
-			repositoryID = id
-		}
-	}
-
-	// If still empty, generate a default one based on the type
-	if repositoryID == "" {
-		repositoryID = FormatRepositoryID(fmt.Sprintf("%T", servant), "1.0")
-	}
-
 	// Create the reference
-	return p.CreateReferenceWithId(objectID, repositoryID), nil
+	return p.CreateReferenceWithId(objectID, p.orb.repositoryIDFor(servant)), nil
 }
 
 // POAManager states
@@ -794,18 +1130,26 @@ const (
 	POAManagerInactive   = 3
 )
 
+// DefaultPOAManagerQueueLimit is the number of requests a POA manager holds
+// while in the HOLDING state before rejecting further requests with TRANSIENT
+const DefaultPOAManagerQueueLimit = 1024
+
 // POAManager manages the state of one or more POAs
 type POAManager struct {
-	state int
-	poas  []*POA
-	mutex sync.RWMutex
+	state      int
+	poas       []*POA
+	mutex      sync.RWMutex
+	queue      []func(Exception) // Requests held while in the HOLDING state
+	queueLimit int
+	releasing  bool // Activate is dispatching the held requests
 }
 
 // NewPOAManager creates a new POA manager
 func (o *ORB) NewPOAManager() *POAManager {
 	manager := &POAManager{
-		state: POAManagerHolding,
-		poas:  make([]*POA, 0),
+		state:      POAManagerHolding,
+		poas:       make([]*POA, 0),
+		queueLimit: DefaultPOAManagerQueueLimit,
 	}
 
 	o.poaManagers = append(o.poaManagers, manager)
@@ -834,43 +1178,104 @@ func (m *POAManager) removePOA(poa *POA) {
 	m.poas = newPoas
 }
 
-// Activate activates all POAs managed by this manager
-func (m *POAManager) Activate() {
+// Activate activates all POAs managed by this manager and releases any
+// requests held while the manager was in the HOLDING state
+func (m *POAManager) Activate() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.state == POAManagerInactive {
+		return ErrAdapterInactive
+	}
+
 	m.state = POAManagerActive
 	for _, poa := range m.poas {
 		poa.Activate()
 	}
+
+	// Held requests are dispatched in arrival order before returning, and the
+	// requests that arrive meanwhile queue up behind them. Those left when the
+	// manager leaves the ACTIVE state are held or rejected with the queue.
+	if m.releasing {
+		return nil
+	}
+	m.releasing = true
+	for len(m.queue) > 0 && m.state == POAManagerActive {
+		run := m.queue[0]
+		m.queue[0] = nil
+		m.queue = m.queue[1:]
+
+		m.mutex.Unlock()
+		run(nil)
+		m.mutex.Lock()
+	}
+	m.releasing = false
+
+	return nil
 }
 
-// Hold puts all POAs managed by this manager in holding state
-func (m *POAManager) Hold() {
+// Hold puts all POAs managed by this manager in holding state. Incoming
+// requests are queued until the manager is activated, up to the queue limit.
+func (m *POAManager) Hold() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.state == POAManagerInactive {
+		return ErrAdapterInactive
+	}
+
 	m.state = POAManagerHolding
+	return nil
 }
 
-// Discard puts all POAs managed by this manager in discarding state
-func (m *POAManager) Discard() {
+// Discard puts all POAs managed by this manager in discarding state. Incoming
+// and held requests are rejected with TRANSIENT.
+func (m *POAManager) Discard() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.state == POAManagerInactive {
+		return ErrAdapterInactive
+	}
+
 	m.state = POAManagerDiscarding
+	m.rejectQueue(TRANSIENT(MinorRequestDiscarded, CompletionStatusNo))
+	return nil
+}
+
+// Deactivate deactivates all POAs managed by this manager. Incoming and held
+// requests are rejected with OBJ_ADAPTER; the manager cannot be reactivated.
+func (m *POAManager) Deactivate(etherializeObjects bool, waitForCompletion bool) error {
+	return m.DeactivateContext(context.Background(), etherializeObjects, waitForCompletion)
 }
 
-// Deactivate deactivates all POAs managed by this manager
-func (m *POAManager) Deactivate(etherializeObjects bool, waitForCompletion bool) {
+// DeactivateContext is Deactivate for callers that have a context, with the
+// waiting rules of POA.DestroyContext
+func (m *POAManager) DeactivateContext(ctx context.Context, etherializeObjects bool, waitForCompletion bool) error {
 	m.mutex.Lock()
+	if waitForCompletion && len(m.poas) > 0 {
+		// All POAs of a manager belong to the same ORB
+		if err := m.poas[0].checkWaitAllowed(ctx); err != nil {
+			m.mutex.Unlock()
+			return err
+		}
+	}
+	if m.state == POAManagerInactive {
+		m.mutex.Unlock()
+		return ErrAdapterInactive
+	}
 	m.state = POAManagerInactive
+	m.rejectQueue(OBJ_ADAPTER(MinorManagerInactive, CompletionStatusNo))
 	poas := m.poas // Make a copy to avoid holding the lock during deactivation
 	m.mutex.Unlock()
 
 	for _, poa := range poas {
-		poa.Deactivate(etherializeObjects, waitForCompletion)
+		if err := poa.DeactivateContext(ctx, etherializeObjects, waitForCompletion); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // GetState returns the current state of the POA manager
@@ -881,10 +1286,88 @@ func (m *POAManager) GetState() int {
 	return m.state
 }
 
+// SetQueueLimit sets how many requests are held while in the HOLDING state.
+// A limit of zero rejects every request with TRANSIENT while holding.
+func (m *POAManager) SetQueueLimit(limit int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.queueLimit = limit
+}
+
+// QueueLimit returns how many requests are held while in the HOLDING state
+func (m *POAManager) QueueLimit() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.queueLimit
+}
+
+// QueuedRequests returns the number of requests currently held
+func (m *POAManager) QueuedRequests() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.queue)
+}
+
+// admit processes a request according to the manager state. run is called
+// with a nil exception when the request may be dispatched, or with the
+// exception to report when it is rejected. While HOLDING, run is deferred
+// until the state changes.
+func (m *POAManager) admit(run func(Exception)) {
+	m.mutex.Lock()
+
+	var ex Exception
+	switch m.state {
+	case POAManagerActive:
+		if m.releasing {
+			// Dispatched after the requests held before activation
+			m.queue = append(m.queue, run)
+			m.mutex.Unlock()
+			return
+		}
+	case POAManagerHolding:
+		if len(m.queue) < m.queueLimit {
+			m.queue = append(m.queue, run)
+			m.mutex.Unlock()
+			return
+		}
+		ex = TRANSIENT(MinorRequestDiscarded, CompletionStatusNo)
+	case POAManagerDiscarding:
+		ex = TRANSIENT(MinorRequestDiscarded, CompletionStatusNo)
+	default:
+		ex = OBJ_ADAPTER(MinorManagerInactive, CompletionStatusNo)
+	}
+
+	m.mutex.Unlock()
+	run(ex)
+}
+
+// takeQueue removes and returns the held requests. The caller must hold the lock.
+func (m *POAManager) takeQueue() []func(Exception) {
+	queued := m.queue
+	m.queue = nil
+	return queued
+}
+
+// rejectQueue rejects all held requests with ex. The caller must hold the lock.
+func (m *POAManager) rejectQueue(ex Exception) {
+	queued := m.takeQueue()
+	if len(queued) == 0 {
+		return
+	}
+	go func() {
+		for _, run := range queued {
+			run(ex)
+		}
+	}()
+}
+
 // Implementation of a basic ServantActivator
 type BasicServantActivator struct {
 	IncarnateFunc   func(ObjectID, *POA) (interface{}, error)
-	EtherealizeFUnc func(ObjectID, *POA, interface{}, bool) error
+	EtherealizeFUnc func(ObjectID, *POA, interface{}, bool, bool) error
 }
 
 func (a *BasicServantActivator) Incarnate(objectID ObjectID, adapter *POA) (interface{}, error) {
@@ -894,13 +1377,25 @@ func (a *BasicServantActivator) Incarnate(objectID ObjectID, adapter *POA) (inte
 	return nil, ErrNoServant
 }
 
-func (a *BasicServantActivator) Etherealize(objectID ObjectID, adapter *POA, servant interface{}, cleanup bool) error {
+func (a *BasicServantActivator) Etherealize(objectID ObjectID, adapter *POA, servant interface{}, cleanupInProgress bool, remainingActivations bool) error {
 	if a.EtherealizeFUnc != nil {
-		return a.EtherealizeFUnc(objectID, adapter, servant, cleanup)
+		return a.EtherealizeFUnc(objectID, adapter, servant, cleanupInProgress, remainingActivations)
 	}
 	return nil
 }
 
+// BasicAdapterActivator implements AdapterActivator with a function
+type BasicAdapterActivator struct {
+	UnknownAdapterFunc func(*POA, string) bool
+}
+
+func (a *BasicAdapterActivator) UnknownAdapter(parent *POA, name string) bool {
+	if a.UnknownAdapterFunc != nil {
+		return a.UnknownAdapterFunc(parent, name)
+	}
+	return false
+}
+
 // Implementation of a basic ServantLocator
 type BasicServantLocator struct {
 	PreinvokeFunc  func(ObjectID, *POA, string) (interface{}, interface{}, error)
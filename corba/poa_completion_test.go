@@ -0,0 +1,194 @@
+package corba_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// etherealization records one call to Etherealize
+type etherealization struct {
+	oid       string
+	cleanup   bool
+	remaining bool
+}
+
+// activatorPOA creates a RETAIN POA whose servant activator incarnates
+// concurrency servants and reports etherealizations
+func activatorPOA(t *testing.T, orb *corba.ORB, policies ...corba.POAPolicy) (*corba.POA, chan *concurrencyServant, chan etherealization) {
+	t.Helper()
+
+	poa, err := orb.GetRootPOA().CreatePOA("Completion", nil, append([]corba.POAPolicy{
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	}, policies...))
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+
+	incarnated := make(chan *concurrencyServant, 4)
+	etherealized := make(chan etherealization, 4)
+	poa.SetServantManager(&corba.BasicServantActivator{
+		IncarnateFunc: func(oid corba.ObjectID, poa *corba.POA) (interface{}, error) {
+			servant := newConcurrencyServant()
+			incarnated <- servant
+			return servant, nil
+		},
+		EtherealizeFUnc: func(oid corba.ObjectID, poa *corba.POA, servant interface{}, cleanup, remaining bool) error {
+			etherealized <- etherealization{string(oid), cleanup, remaining}
+			return nil
+		},
+	})
+	return poa, incarnated, etherealized
+}
+
+// startBlockedRequest invokes "block" over a connection of its own and
+// returns once the servant is dispatching it
+func startBlockedRequest(t *testing.T, ep corba.Endpoint, ref *corba.ObjectRef, incarnated chan *concurrencyServant) (*concurrencyServant, chan error) {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- invokeRef(t, corba.Init().CreateClient(), ep, ref, "block") }()
+	servant := <-incarnated
+	<-servant.started
+	return servant, done
+}
+
+func TestDeactivateObjectWaitsForRequests(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "completion-object")
+	poa, incarnated, etherealized := activatorPOA(t, orb)
+	ref := poa.CreateReferenceWithId(corba.ObjectID("acct"), "IDL:Bank/Account:1.0")
+
+	servant, blocked := startBlockedRequest(t, ep, ref, incarnated)
+	if err := poa.DeactivateObject(corba.ObjectID("acct")); err != nil {
+		t.Fatalf("DeactivateObject: %v", err)
+	}
+
+	// The object stays active for the running request, but takes no new ones
+	select {
+	case e := <-etherealized:
+		t.Fatalf("etherealized %v with a request in flight", e)
+	default:
+	}
+	wantSystemException(t, invokeRef(t, client, ep, ref, "ping"), "TRANSIENT")
+
+	close(servant.gate)
+	if err := <-blocked; err != nil {
+		t.Fatalf("blocked request: %v", err)
+	}
+	if e := <-etherealized; e != (etherealization{"acct", false, false}) {
+		t.Errorf("Etherealize%+v, want the object without cleanup in progress", e)
+	}
+
+	// Once deactivated, the object is incarnated afresh
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke after deactivation: %v", err)
+	}
+	<-incarnated
+}
+
+func TestDestroyWaitsForCompletion(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, "completion-destroy")
+	poa, incarnated, etherealized := activatorPOA(t, orb)
+	ref := poa.CreateReferenceWithId(corba.ObjectID("acct"), "IDL:Bank/Account:1.0")
+
+	servant, blocked := startBlockedRequest(t, ep, ref, incarnated)
+	destroyed := make(chan error, 1)
+	go func() { destroyed <- poa.Destroy(true, true) }()
+
+	select {
+	case err := <-destroyed:
+		t.Fatalf("Destroy returned with a request in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(servant.gate)
+	if err := <-blocked; err != nil {
+		t.Fatalf("blocked request: %v", err)
+	}
+	if err := <-destroyed; err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	select {
+	case e := <-etherealized:
+		if e != (etherealization{"acct", true, false}) {
+			t.Errorf("Etherealize%+v, want the object with cleanup in progress", e)
+		}
+	default:
+		t.Error("Destroy returned before etherealizing the active object")
+	}
+}
+
+func TestEtherealizeRemainingActivations(t *testing.T) {
+	orb := corba.Init()
+	poa, _, etherealized := activatorPOA(t, orb,
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+		corba.NewIdUniquenessPolicy(corba.MultipleID))
+
+	servant := newConcurrencyServant()
+	for _, id := range []string{"first", "second"} {
+		if err := poa.ActivateObjectWithID(corba.ObjectID(id), servant); err != nil {
+			t.Fatalf("ActivateObjectWithID: %v", err)
+		}
+	}
+	if err := poa.Destroy(true, false); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+
+	// Only the last activation of the servant reports none remaining
+	first, second := <-etherealized, <-etherealized
+	if !first.remaining || second.remaining {
+		t.Errorf("remainingActivations = %v then %v, want true then false", first.remaining, second.remaining)
+	}
+}
+
+// waitingServant waits for completion of its own POA from inside a dispatch
+type waitingServant struct {
+	poa *corba.POA
+}
+
+func (s *waitingServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.DispatchContext(context.Background(), methodName, args)
+}
+
+func (s *waitingServant) DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error) {
+	switch methodName {
+	case "deactivate":
+		return nil, s.poa.GetPOAManager().DeactivateContext(ctx, false, true)
+	case "deactivate-poa":
+		// Goroutines started by the servant carry the request context along
+		errs := make(chan error, 1)
+		go func() { errs <- s.poa.DeactivateContext(ctx, false, true) }()
+		return nil, <-errs
+	default:
+		return nil, s.poa.DestroyContext(ctx, false, true)
+	}
+}
+
+func TestWaitForCompletionInsideDispatch(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "completion-inside")
+
+	poa, err := orb.GetRootPOA().CreatePOA("Waiting", orb.NewPOAManager(), nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	poa.GetPOAManager().Activate()
+	oid, err := poa.ActivateObject(&waitingServant{poa: poa})
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref := poa.CreateReferenceWithId(oid, "IDL:Test/Waiting:1.0")
+
+	wantSystemException(t, invokeRef(t, client, ep, ref, "destroy"), "BAD_INV_ORDER")
+	wantSystemException(t, invokeRef(t, client, ep, ref, "deactivate"), "BAD_INV_ORDER")
+	wantSystemException(t, invokeRef(t, client, ep, ref, "deactivate-poa"), "BAD_INV_ORDER")
+
+	// Outside a dispatch, waiting is allowed
+	if err := poa.Destroy(false, true); err != nil {
+		t.Errorf("Destroy: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import "context"
+
+// ErrNoContext is raised when POA Current is requested outside the dispatch
+// of a POA request
+var ErrNoContext = NewCORBAUserException("NoContext", "IDL:omg.org/PortableServer/Current/NoContext:1.0")
+
+// ContextDispatcher is implemented by servants that want the context of the
+// request being dispatched. The context carries the POA Current, which
+// identifies the target POA, ObjectID and servant. Servants implementing it
+// are invoked through DispatchContext instead of Dispatch.
+type ContextDispatcher interface {
+	DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error)
+}
+
+// POACurrent is the PortableServer::Current of a request: the POA and
+// ObjectID the request is dispatched for, and the servant handling it. It
+// lets a default servant or a servant located by a ServantLocator serve many
+// objects by finding out which one each request targets.
+type POACurrent struct {
+	poa      *POA
+	objectID ObjectID
+	servant  interface{}
+}
+
+// poaCurrentKey is the context key of the POA Current
+type poaCurrentKey struct{}
+
+// withPOACurrent returns a context carrying the POA Current of a request
+func withPOACurrent(ctx context.Context, current *POACurrent) context.Context {
+	return context.WithValue(ctx, poaCurrentKey{}, current)
+}
+
+// POACurrentFromContext returns the POA Current of the request a context was
+// created for. It fails with ErrNoContext outside the dispatch of a POA request.
+func POACurrentFromContext(ctx context.Context) (*POACurrent, error) {
+	if ctx == nil {
+		return nil, ErrNoContext
+	}
+	if current, ok := ctx.Value(poaCurrentKey{}).(*POACurrent); ok {
+		return current, nil
+	}
+	return nil, ErrNoContext
+}
+
+// GetPOA returns the POA the request is dispatched through
+func (c *POACurrent) GetPOA() *POA {
+	return c.poa
+}
+
+// GetObjectID returns the ObjectID of the target object
+func (c *POACurrent) GetObjectID() ObjectID {
+	return c.objectID
+}
+
+// GetServant returns the servant handling the request
+func (c *POACurrent) GetServant() interface{} {
+	return c.servant
+}
+
+// GetReference returns a reference to the target object, equivalent to the
+// one the client invoked
+func (c *POACurrent) GetReference() *ObjectRef {
+	return c.poa.CreateReferenceWithId(c.objectID, c.poa.orb.repositoryIDFor(c.servant))
+}
@@ -0,0 +1,73 @@
+package corba_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// currentServant records the POA Current of every request it dispatches
+type currentServant struct {
+	currents chan *corba.POACurrent
+}
+
+func (s *currentServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.DispatchContext(context.Background(), methodName, args)
+}
+
+func (s *currentServant) DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error) {
+	current, err := corba.POACurrentFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.currents <- current
+	return nil, nil
+}
+
+func TestPOACurrentIdentifiesTarget(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "poa-current")
+
+	accounts, err := orb.GetRootPOA().CreatePOA("Accounts", nil, []corba.POAPolicy{
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+		corba.NewIdUniquenessPolicy(corba.MultipleID),
+		corba.NewRequestProcessingPolicy(corba.UseDefaultServant),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	servant := &currentServant{currents: make(chan *corba.POACurrent, 1)}
+	if err := accounts.SetDefaultServant(servant); err != nil {
+		t.Fatalf("SetDefaultServant: %v", err)
+	}
+
+	// One default servant tells the objects apart by their ObjectID
+	for _, id := range []string{"acct-1", "acct-2"} {
+		ref := accounts.CreateReferenceWithId(corba.ObjectID(id), "IDL:Bank/Account:1.0")
+		if err := invokeRef(t, client, ep, ref, "balance"); err != nil {
+			t.Fatalf("Invoke %s: %v", id, err)
+		}
+
+		current := <-servant.currents
+		if got := string(current.GetObjectID()); got != id {
+			t.Errorf("GetObjectID = %q, want %q", got, id)
+		}
+		if current.GetPOA() != accounts {
+			t.Errorf("GetPOA = %v, want the Accounts POA", current.GetPOA())
+		}
+		if current.GetServant() != servant {
+			t.Errorf("GetServant = %v, want the default servant", current.GetServant())
+		}
+		if !bytes.Equal(current.GetReference().GetObjectKey(), ref.GetObjectKey()) {
+			t.Errorf("GetReference has object key %q, want %q", current.GetReference().GetObjectKey(), ref.GetObjectKey())
+		}
+	}
+}
+
+func TestPOACurrentOutsideDispatch(t *testing.T) {
+	if _, err := corba.POACurrentFromContext(context.Background()); err != corba.ErrNoContext {
+		t.Errorf("POACurrentFromContext = %v, want NoContext", err)
+	}
+}
@@ -0,0 +1,463 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// dispatcher is implemented by every servant the server can invoke
+type dispatcher interface {
+	Dispatch(methodName string, args []interface{}) (interface{}, error)
+}
+
+// findPOAByPath walks the POA tree from the root POA along the given names.
+// With activate set, missing POAs are created by their parent's adapter activator.
+func (o *ORB) findPOAByPath(path []string, activate bool) (*POA, error) {
+	o.mu.RLock()
+	current := o.rootPOA
+	o.mu.RUnlock()
+
+	if current == nil {
+		return nil, ErrAdapterNonExistent
+	}
+
+	for _, name := range path {
+		child, err := current.FindPOA(name, activate)
+		if err != nil {
+			return nil, err
+		}
+		current = child
+	}
+
+	return current, nil
+}
+
+// awaitsActivation reports whether the POA of a structured object key is
+// missing from a parent with an adapter activator, which may still create it
+func (o *ORB) awaitsActivation(key ObjectKey) bool {
+	o.mu.RLock()
+	current := o.rootPOA
+	o.mu.RUnlock()
+
+	if current == nil {
+		return false
+	}
+	for _, name := range key.POAPath {
+		child, err := current.FindPOA(name, false)
+		if err != nil {
+			return current.GetAdapterActivator() != nil
+		}
+		current = child
+	}
+	return false
+}
+
+// findPOAForKey returns the POA that created a structured object key. References
+// to a TRANSIENT POA become invalid once that POA is gone, even if a POA with
+// the same name has been created since.
+func (o *ORB) findPOAForKey(key ObjectKey, activate bool) (*POA, error) {
+	poa, err := o.findPOAByPath(key.POAPath, activate)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Persistent != (poa.lifespan == PersistentLifespan) ||
+		(!key.Persistent && key.POAInstance != poa.instanceID) {
+		return nil, ErrAdapterNonExistent
+	}
+
+	return poa, nil
+}
+
+// resolvePOAServant finds the servant of a POA that handles a request for a
+// structured object key, along with the POA Current of the request. The
+// returned function must be called once the operation has completed, so that
+// a ServantLocator can run its postinvoke.
+func resolvePOAServant(poa *POA, key ObjectKey, operation string) (interface{}, *POACurrent, func(), Exception) {
+	servant, postinvoke, ex := poa.preinvoke(key.ObjectID, operation)
+	if ex != nil {
+		return nil, nil, nil, ex
+	}
+
+	if !isDispatchable(servant) {
+		postinvoke()
+		return nil, nil, nil, OBJ_ADAPTER(MinorServantNotDispatchable, CompletionStatusNo)
+	}
+
+	current := &POACurrent{poa: poa, objectID: key.ObjectID, servant: servant}
+	return servant, current, postinvoke, nil
+}
+
+// isDispatchable reports whether the server can invoke operations on a servant
+func isDispatchable(servant interface{}) bool {
+	switch servant.(type) {
+	case ContextDispatcher, dispatcher, DynamicImplementation:
+		return true
+	}
+	return false
+}
+
+// comparableServant reports whether servant can key the active object map.
+// Servants whose dynamic type Go cannot compare, such as value types holding
+// slices or maps, have no identity and are refused.
+func comparableServant(servant interface{}) bool {
+	v := reflect.ValueOf(servant)
+	return !v.IsValid() || v.Comparable()
+}
+
+// sameServant reports whether two servants are the same servant
+func sameServant(a, b interface{}) bool {
+	return comparableServant(a) && comparableServant(b) && a == b
+}
+
+// dispatchServant invokes an operation on a servant, passing the request
+// context to servants that implement ContextDispatcher. Dynamic servants
+// receive the operation as a ServerRequest.
+func dispatchServant(ctx context.Context, servant interface{}, operation string, args []interface{}) (interface{}, error) {
+	switch invoker := servant.(type) {
+	case ContextDispatcher:
+		return invoker.DispatchContext(ctx, operation, args)
+	case dispatcher:
+		return invoker.Dispatch(operation, args)
+	case DynamicImplementation:
+		return (&DynamicServantAdapter{Servant: invoker}).Dispatch(operation, args)
+	}
+	return nil, OBJ_ADAPTER(MinorServantNotDispatchable, CompletionStatusNo)
+}
+
+// preinvoke locates the servant for a request and counts the request as in
+// flight until the returned function is called. Requests for an object that is
+// being deactivated are rejected with TRANSIENT.
+func (p *POA) preinvoke(id ObjectID, operation string) (interface{}, func(), Exception) {
+	servant, postinvoke, ex := p.findServant(id, operation)
+	if ex != nil {
+		return nil, nil, ex
+	}
+
+	oidStr := string(id)
+
+	p.mutex.Lock()
+	deactivated := p.deactivating[oidStr]
+	if !deactivated && p.servantRetention == RetainServants && !sameServant(servant, p.defaultServant) {
+		// The object may have been deactivated since its servant was found
+		active, exists := p.oidToServantMap[oidStr]
+		deactivated = !exists || !sameServant(active, servant)
+	}
+	if deactivated {
+		p.mutex.Unlock()
+		postinvoke()
+		return nil, nil, TRANSIENT(MinorObjectDeactivating, CompletionStatusNo)
+	}
+	p.inFlight[oidStr]++
+	p.active++
+	p.mutex.Unlock()
+
+	return servant, func() {
+		postinvoke()
+		p.invocationDone(oidStr)
+	}, nil
+}
+
+// invocationDone ends a request counted by preinvoke. The last request for an
+// object being deactivated removes the object.
+func (p *POA) invocationDone(oidStr string) {
+	p.mutex.Lock()
+	var etherealize func()
+	p.inFlight[oidStr]--
+	if p.inFlight[oidStr] == 0 {
+		delete(p.inFlight, oidStr)
+		if p.deactivating[oidStr] {
+			etherealize = p.removeObject(oidStr, false)
+		}
+	}
+	p.mutex.Unlock()
+
+	if etherealize != nil {
+		etherealize()
+	}
+
+	// The request only counts as completed once its object is etherealized
+	p.mutex.Lock()
+	p.active--
+	if p.active == 0 && p.drained != nil {
+		close(p.drained)
+		p.drained = nil
+	}
+	p.mutex.Unlock()
+}
+
+// drainedLocked returns a channel closed once no request is being dispatched
+// on the POA. The lock must be held.
+func (p *POA) drainedLocked() <-chan struct{} {
+	if p.active == 0 {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+	if p.drained == nil {
+		p.drained = make(chan struct{})
+	}
+	return p.drained
+}
+
+// afterCompletion runs finish once the requests being dispatched have
+// completed: right away if there are none, otherwise before returning when
+// waitForCompletion is set, or in the background when it is not.
+func (p *POA) afterCompletion(ctx context.Context, drained <-chan struct{}, waitForCompletion bool, finish func()) error {
+	select {
+	case <-drained:
+		finish()
+		return nil
+	default:
+	}
+
+	if !waitForCompletion {
+		go func() {
+			<-drained
+			finish()
+		}()
+		return nil
+	}
+
+	select {
+	case <-drained:
+		finish()
+		return nil
+	case <-ctx.Done():
+		// Finish anyway once the requests complete
+		go func() {
+			<-drained
+			finish()
+		}()
+		return ctx.Err()
+	}
+}
+
+// checkWaitAllowed fails with BAD_INV_ORDER if the caller is dispatching a
+// request of the POA's ORB, since waiting for completion would deadlock
+func (p *POA) checkWaitAllowed(ctx context.Context) error {
+	if p.orb.inDispatch(ctx) {
+		return BAD_INV_ORDER(MinorWouldDeadlock, CompletionStatusNo)
+	}
+	return nil
+}
+
+// findServant locates the servant for an ObjectID according to the POA's
+// policies: the active object map first when servants are retained, then the
+// default servant or the servant manager, depending on the request processing
+// policy.
+func (p *POA) findServant(id ObjectID, operation string) (interface{}, func(), Exception) {
+	noop := func() {}
+	oidStr := string(id)
+
+	p.mutex.RLock()
+	retain := p.servantRetention == RetainServants
+	servant, active := p.oidToServantMap[oidStr]
+	defaultServant := p.defaultServant
+	manager := p.servantManager
+	p.mutex.RUnlock()
+
+	if retain && active {
+		return servant, p.servantInUse(manager, id, servant, false), nil
+	}
+
+	switch p.requestProcessing {
+	case UseDefaultServant:
+		if defaultServant == nil {
+			return nil, nil, OBJ_ADAPTER(MinorNoDefaultServant, CompletionStatusNo)
+		}
+		return defaultServant, noop, nil
+
+	case UseServantManager:
+		if manager == nil {
+			return nil, nil, OBJ_ADAPTER(MinorNoServantManager, CompletionStatusNo)
+		}
+
+		if retain {
+			activator, ok := manager.(ServantActivator)
+			if !ok {
+				return nil, nil, OBJ_ADAPTER(MinorNoServantManager, CompletionStatusNo)
+			}
+			servant, err := p.incarnate(activator, id)
+			if err != nil {
+				return nil, nil, servantManagerException(err)
+			}
+			return servant, p.servantInUse(manager, id, servant, true), nil
+		}
+
+		locator, ok := manager.(ServantLocator)
+		if !ok {
+			return nil, nil, OBJ_ADAPTER(MinorNoServantManager, CompletionStatusNo)
+		}
+		servant, cookie, err := locator.Preinvoke(id, p, operation)
+		if err != nil {
+			return nil, nil, servantManagerException(err)
+		}
+		if servant == nil {
+			return nil, nil, OBJECT_NOT_EXIST(MinorNoServantForObject, CompletionStatusNo)
+		}
+		return servant, func() {
+			locator.Postinvoke(id, p, operation, servant, cookie)
+		}, nil
+
+	default:
+		return nil, nil, OBJECT_NOT_EXIST(MinorObjectNotActive, CompletionStatusNo)
+	}
+}
+
+// servantUsageTracker is implemented by servant managers that follow the
+// requests dispatched to the servants in the active object map, such as Evictor
+type servantUsageTracker interface {
+	servantInUse(id ObjectID, adapter *POA, servant interface{}, incarnated bool) func()
+}
+
+// servantInUse tells the POA's servant manager, if it tracks servant usage,
+// that a request is dispatched to a retained servant. The returned function
+// must be called when the request has completed.
+func (p *POA) servantInUse(manager ServantManager, id ObjectID, servant interface{}, incarnated bool) func() {
+	if tracker, ok := manager.(servantUsageTracker); ok && p.requestProcessing == UseServantManager {
+		return tracker.servantInUse(id, p, servant, incarnated)
+	}
+	return func() {}
+}
+
+// locate reports whether a request for the ObjectID could be dispatched,
+// without incarnating a servant
+func (p *POA) locate(id ObjectID) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.servantRetention == RetainServants {
+		if _, active := p.oidToServantMap[string(id)]; active {
+			return true
+		}
+	}
+
+	switch p.requestProcessing {
+	case UseDefaultServant:
+		return p.defaultServant != nil
+	case UseServantManager:
+		return p.servantManager != nil
+	default:
+		return false
+	}
+}
+
+// servantManagerException converts an error returned by a servant manager into
+// the exception reported to the client. System exceptions and ForwardRequest
+// are passed through; any other error means the manager has no servant for
+// the object.
+func servantManagerException(err error) Exception {
+	switch ex := err.(type) {
+	case *SystemException:
+		return ex
+	case *ForwardRequest:
+		return ex
+	}
+	return OBJECT_NOT_EXIST(MinorNoServantForObject, CompletionStatusNo)
+}
+
+// SetMaxConcurrentRequests limits how many requests for this POA are
+// dispatched at the same time. Zero removes the limit. The ORB-wide limit
+// set with ORB.SetMaxConcurrentRequests applies in addition.
+func (p *POA) SetMaxConcurrentRequests(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid number of concurrent requests: %d", n)
+	}
+
+	p.runMu.Lock()
+	p.maxConcurrent = n
+	p.runMu.Unlock()
+	return nil
+}
+
+// MaxConcurrentRequests returns the per-POA dispatch limit, or zero if there is none
+func (p *POA) MaxConcurrentRequests() int {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	return p.maxConcurrent
+}
+
+// dispatchLimitLocked returns how many requests may be dispatched on the POA
+// at the same time under its thread policy and concurrency limit, or zero if
+// there is no bound. The run queue lock must be held.
+func (p *POA) dispatchLimitLocked() int {
+	if p.threadModel == SingleThreadModel {
+		return 1
+	}
+	return p.maxConcurrent
+}
+
+// schedule runs a request on the ORB's worker pool once the POA's thread
+// policy and concurrency limit allow it. Requests waiting for the POA stay in
+// its run queue and do not hold a worker, so that a busy POA cannot starve the
+// others.
+func (p *POA) schedule(job func()) {
+	p.runMu.Lock()
+	limit := p.dispatchLimitLocked()
+	if limit > 0 && p.running >= limit {
+		p.runQueue = append(p.runQueue, job)
+		p.runMu.Unlock()
+		return
+	}
+	p.running++
+	p.runMu.Unlock()
+
+	p.orb.workers.submit(func() { p.runQueued(job) })
+}
+
+// runQueued runs a request, then the requests queued on the POA meanwhile for
+// as long as the POA's limit allows, on the current worker
+func (p *POA) runQueued(job func()) {
+	// A job that panics must not keep its slot, or the POA stays throttled
+	defer func() {
+		if job != nil {
+			p.releaseSlot()
+		}
+	}()
+
+	for job != nil {
+		job()
+		job = p.nextQueued()
+	}
+}
+
+// nextQueued returns the queued request to run in the slot of a finished one,
+// or nil after releasing the slot
+func (p *POA) nextQueued() func() {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	limit := p.dispatchLimitLocked()
+	if len(p.runQueue) > 0 && (limit == 0 || p.running <= limit) {
+		return p.popQueuedLocked()
+	}
+	p.running--
+	return nil
+}
+
+// releaseSlot frees the slot of a request that did not finish, handing it to
+// a queued request on another worker if there is one
+func (p *POA) releaseSlot() {
+	p.runMu.Lock()
+	if len(p.runQueue) == 0 {
+		p.running--
+		p.runMu.Unlock()
+		return
+	}
+	job := p.popQueuedLocked()
+	p.runMu.Unlock()
+
+	p.orb.workers.submit(func() { p.runQueued(job) })
+}
+
+// popQueuedLocked removes the oldest queued request. The caller must hold runMu.
+func (p *POA) popQueuedLocked() func() {
+	job := p.runQueue[0]
+	p.runQueue[0] = nil
+	p.runQueue = p.runQueue[1:]
+	return job
+}
@@ -0,0 +1,204 @@
+package corba_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+func TestObjectKeyRoundTrip(t *testing.T) {
+	keys := []corba.ObjectKey{
+		{POAPath: nil, POAInstance: 42, ObjectID: corba.ObjectID("root-object")},
+		{POAPath: []string{"Bank", "Accounts"}, Persistent: true, ObjectID: corba.ObjectID("acct/1")},
+		{POAPath: []string{"Empty"}, POAInstance: 7, ObjectID: corba.ObjectID{}},
+	}
+
+	for _, want := range keys {
+		data := want.Encode()
+		if !corba.IsPOAObjectKey(data) {
+			t.Fatalf("%v: encoded key not recognised", want)
+		}
+		got, err := corba.DecodeObjectKey(data)
+		if err != nil {
+			t.Fatalf("%v: decode: %v", want, err)
+		}
+		if got.String() != want.String() || got.Persistent != want.Persistent ||
+			got.POAInstance != want.POAInstance || !bytes.Equal(got.ObjectID, want.ObjectID) {
+			t.Errorf("decoded %+v, want %+v", got, want)
+		}
+	}
+
+	if corba.IsPOAObjectKey([]byte("NameService")) {
+		t.Error("plain name recognised as a POA object key")
+	}
+	if _, err := corba.DecodeObjectKey(keys[1].Encode()[:10]); err == nil {
+		t.Error("truncated key decoded without error")
+	}
+}
+
+// poaTestServer starts a server on an in-memory endpoint and returns a client
+// able to invoke POA references through it
+func poaTestServer(t *testing.T, orb *corba.ORB, name string) (*corba.Client, corba.Endpoint) {
+	t.Helper()
+
+	ep := corba.NewMemEndpoint(name)
+	server, err := orb.CreateServerOn(ep)
+	if err != nil {
+		t.Fatalf("CreateServerOn: %v", err)
+	}
+	if err := server.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	t.Cleanup(func() { server.Shutdown() })
+
+	return orb.CreateClient(), ep
+}
+
+// invokeRef invokes an operation on a POA reference through the test server
+func invokeRef(t *testing.T, client *corba.Client, ep corba.Endpoint, ref *corba.ObjectRef, operation string) error {
+	t.Helper()
+
+	target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+	_, err = target.Invoke(operation)
+	return err
+}
+
+// wantSystemException checks that err is the named CORBA system exception
+func wantSystemException(t *testing.T, err error, name string) {
+	t.Helper()
+
+	ex, ok := err.(corba.Exception)
+	if !ok || !corba.IsSystemException(err) || ex.Name() != name {
+		t.Fatalf("got error %v, want %s", err, name)
+	}
+}
+
+func TestPOADispatchActiveObjectMap(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "poa-aom")
+
+	child, err := orb.GetRootPOA().CreatePOA("Child", nil, []corba.POAPolicy{
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+
+	servant := &recordingServant{calls: make(chan string, 1)}
+	if err := child.ActivateObjectWithID(corba.ObjectID("obj1"), servant); err != nil {
+		t.Fatalf("ActivateObjectWithID: %v", err)
+	}
+
+	ref := child.CreateReferenceWithId(corba.ObjectID("obj1"), "IDL:Test/Echo:1.0")
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if got := <-servant.calls; got != "ping" {
+		t.Errorf("servant received %q, want %q", got, "ping")
+	}
+
+	// Unknown ObjectIDs are not served by an AOM-only POA
+	ref = child.CreateReferenceWithId(corba.ObjectID("missing"), "IDL:Test/Echo:1.0")
+	wantSystemException(t, invokeRef(t, client, ep, ref, "ping"), "OBJECT_NOT_EXIST")
+
+	// References to a destroyed transient POA stay invalid after it is recreated
+	ref = child.CreateReferenceWithId(corba.ObjectID("obj1"), "IDL:Test/Echo:1.0")
+	child.Destroy(false, false)
+	child, err = orb.GetRootPOA().CreatePOA("Child", nil, []corba.POAPolicy{
+		corba.NewIdAssignmentPolicy(corba.UserAssignedID),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	if err := child.ActivateObjectWithID(corba.ObjectID("obj1"), servant); err != nil {
+		t.Fatalf("ActivateObjectWithID: %v", err)
+	}
+	wantSystemException(t, invokeRef(t, client, ep, ref, "ping"), "OBJECT_NOT_EXIST")
+}
+
+func TestPOADispatchServantManagers(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "poa-managers")
+	root := orb.GetRootPOA()
+
+	// ServantActivator: incarnated once, then served from the active object map
+	activated, err := root.CreatePOA("Activated", nil, []corba.POAPolicy{
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	incarnations := 0
+	activatedServant := &recordingServant{calls: make(chan string, 2)}
+	activated.SetServantManager(&corba.BasicServantActivator{
+		IncarnateFunc: func(oid corba.ObjectID, poa *corba.POA) (interface{}, error) {
+			incarnations++
+			return activatedServant, nil
+		},
+	})
+	ref := activated.CreateReference("IDL:Test/Echo:1.0", nil)
+	for i := 0; i < 2; i++ {
+		if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+			t.Fatalf("Invoke: %v", err)
+		}
+		<-activatedServant.calls
+	}
+	if incarnations != 1 {
+		t.Errorf("servant incarnated %d times, want 1", incarnations)
+	}
+
+	// ServantLocator: preinvoke and postinvoke around every request
+	located, err := root.CreatePOA("Located", nil, []corba.POAPolicy{
+		corba.NewServantRetentionPolicy(corba.NonRetainServants),
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	locatedServant := &recordingServant{calls: make(chan string, 1)}
+	postinvoked := make(chan string, 1)
+	located.SetServantManager(&corba.BasicServantLocator{
+		PreinvokeFunc: func(oid corba.ObjectID, poa *corba.POA, op string) (interface{}, interface{}, error) {
+			if string(oid) != "known" {
+				return nil, nil, corba.ErrNoServant
+			}
+			return locatedServant, "cookie", nil
+		},
+		PostinvokeFunc: func(oid corba.ObjectID, poa *corba.POA, op string, servant interface{}, cookie interface{}) error {
+			postinvoked <- cookie.(string)
+			return nil
+		},
+	})
+	ref = located.CreateReferenceWithId(corba.ObjectID("known"), "IDL:Test/Echo:1.0")
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-locatedServant.calls
+	if cookie := <-postinvoked; cookie != "cookie" {
+		t.Errorf("postinvoke got cookie %q", cookie)
+	}
+	ref = located.CreateReferenceWithId(corba.ObjectID("unknown"), "IDL:Test/Echo:1.0")
+	wantSystemException(t, invokeRef(t, client, ep, ref, "ping"), "OBJECT_NOT_EXIST")
+
+	// Default servant: serves every ObjectID
+	defaulted, err := root.CreatePOA("Defaulted", nil, []corba.POAPolicy{
+		corba.NewIdUniquenessPolicy(corba.MultipleID),
+		corba.NewRequestProcessingPolicy(corba.UseDefaultServant),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	ref = defaulted.CreateReferenceWithId(corba.ObjectID("any"), "IDL:Test/Echo:1.0")
+	wantSystemException(t, invokeRef(t, client, ep, ref, "ping"), "OBJ_ADAPTER")
+
+	defaultServant := &recordingServant{calls: make(chan string, 1)}
+	defaulted.SetDefaultServant(defaultServant)
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	<-defaultServant.calls
+}
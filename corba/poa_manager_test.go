@@ -0,0 +1,164 @@
+package corba_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// managedPOA creates a POA with its own manager and an active echo object
+func managedPOA(t *testing.T, orb *corba.ORB, name string) (*corba.POAManager, *corba.ObjectRef, *recordingServant) {
+	t.Helper()
+
+	manager := orb.NewPOAManager()
+	poa, err := orb.GetRootPOA().CreatePOA(name, manager, nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	servant := &recordingServant{calls: make(chan string, 4)}
+	oid, err := poa.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	return manager, poa.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0"), servant
+}
+
+// invokeAsync invokes ref on its own client connection and reports the result on a channel
+func invokeAsync(t *testing.T, orb *corba.ORB, ep corba.Endpoint, ref *corba.ObjectRef) <-chan error {
+	result := make(chan error, 1)
+	client := orb.CreateClient()
+	go func() {
+		target, err := client.GetObjectAt(string(ref.GetObjectKey()), ep)
+		if err == nil {
+			_, err = target.Invoke("ping")
+		}
+		result <- err
+	}()
+	return result
+}
+
+func TestPOAManagerHoldAndActivate(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, "manager-hold")
+	manager, ref, servant := managedPOA(t, orb, "Held")
+
+	// New managers start in the HOLDING state
+	if manager.GetState() != corba.POAManagerHolding {
+		t.Fatalf("new manager state = %d, want HOLDING", manager.GetState())
+	}
+
+	result := invokeAsync(t, orb, ep, ref)
+	deadline := time.Now().Add(time.Second)
+	for manager.QueuedRequests() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("request was not queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case <-servant.calls:
+		t.Fatal("request dispatched while holding")
+	default:
+	}
+
+	if err := manager.Activate(); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("held request failed: %v", err)
+	}
+	<-servant.calls
+}
+
+func TestPOAManagerQueueLimit(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, "manager-limit")
+	manager, ref, _ := managedPOA(t, orb, "Limited")
+	manager.SetQueueLimit(0)
+
+	wantSystemException(t, <-invokeAsync(t, orb, ep, ref), "TRANSIENT")
+}
+
+func TestPOAManagerDiscard(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, "manager-discard")
+	manager, ref, _ := managedPOA(t, orb, "Discarded")
+
+	// Held requests are rejected when the manager starts discarding
+	held := invokeAsync(t, orb, ep, ref)
+	for manager.QueuedRequests() != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := manager.Discard(); err != nil {
+		t.Fatalf("Discard: %v", err)
+	}
+	wantSystemException(t, <-held, "TRANSIENT")
+	wantSystemException(t, <-invokeAsync(t, orb, ep, ref), "TRANSIENT")
+}
+
+func TestPOAManagerDeactivate(t *testing.T) {
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, "manager-deactivate")
+	manager, ref, _ := managedPOA(t, orb, "Deactivated")
+
+	held := invokeAsync(t, orb, ep, ref)
+	for manager.QueuedRequests() != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := manager.Deactivate(false, false); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	wantSystemException(t, <-held, "OBJ_ADAPTER")
+	wantSystemException(t, <-invokeAsync(t, orb, ep, ref), "OBJ_ADAPTER")
+
+	// An inactive manager cannot change state again
+	if err := manager.Activate(); err != corba.ErrAdapterInactive {
+		t.Errorf("Activate after Deactivate = %v, want ErrAdapterInactive", err)
+	}
+}
+
+func TestPOAManagerActivateKeepsArrivalOrder(t *testing.T) {
+	orb := corba.Init()
+	server, conn, _, _ := shutdownFixture(t, orb, t.Name())
+	t.Cleanup(func() { server.Shutdown() })
+
+	manager := orb.NewPOAManager()
+	poa, err := orb.GetRootPOA().CreatePOA("Ordered", manager, []corba.POAPolicy{
+		corba.NewThreadPolicy(corba.SingleThreadModel),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	servant := &recordingServant{calls: make(chan string, 3)}
+	oid, err := poa.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	key := poa.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0").GetObjectKey()
+
+	sendRawRequest(t, conn, 1, key, "first")
+	sendRawRequest(t, conn, 2, key, "second")
+	for manager.QueuedRequests() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Held requests are in flight
+	if n := server.InFlightRequests(); n != 2 {
+		t.Errorf("in-flight requests while holding = %d, want 2", n)
+	}
+
+	// Requests arriving after activation do not overtake the held ones
+	if err := manager.Activate(); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	sendRawRequest(t, conn, 3, key, "third")
+	for i := 0; i < 3; i++ {
+		readRawReply(t, conn)
+	}
+	for _, want := range []string{"first", "second", "third"} {
+		if got := <-servant.calls; got != want {
+			t.Fatalf("dispatched %s, want %s", got, want)
+		}
+	}
+}
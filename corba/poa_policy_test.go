@@ -0,0 +1,308 @@
+package corba_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// policyCombination is one point of the POA policy matrix
+type policyCombination struct {
+	userID     bool
+	multipleID bool
+	implicit   bool
+	retain     bool
+	processing int
+}
+
+func (c policyCombination) String() string {
+	pick := func(b bool, yes, no string) string {
+		if b {
+			return yes
+		}
+		return no
+	}
+	processing := []string{"AOM_ONLY", "DEFAULT_SERVANT", "SERVANT_MANAGER"}[c.processing]
+	return fmt.Sprintf("%s/%s/%s/%s/%s",
+		pick(c.userID, "USER_ID", "SYSTEM_ID"),
+		pick(c.multipleID, "MULTIPLE_ID", "UNIQUE_ID"),
+		pick(c.implicit, "IMPLICIT", "NO_IMPLICIT"),
+		pick(c.retain, "RETAIN", "NON_RETAIN"),
+		processing)
+}
+
+func (c policyCombination) policies() []corba.POAPolicy {
+	value := func(b bool, yes, no int) int {
+		if b {
+			return yes
+		}
+		return no
+	}
+	return []corba.POAPolicy{
+		corba.NewIdAssignmentPolicy(value(c.userID, corba.UserAssignedID, corba.SystemAssignedID)),
+		corba.NewIdUniquenessPolicy(value(c.multipleID, corba.MultipleID, corba.UniqueID)),
+		corba.NewImplicitActivationPolicy(value(c.implicit, corba.ImplicitActivationEnabled, corba.ImplicitActivationDisabled)),
+		corba.NewServantRetentionPolicy(value(c.retain, corba.RetainServants, corba.NonRetainServants)),
+		corba.NewRequestProcessingPolicy(c.processing),
+	}
+}
+
+// valid reports whether the specification allows the combination
+func (c policyCombination) valid() bool {
+	switch {
+	case c.processing == corba.UseActiveObjectMapOnly && !c.retain:
+		return false
+	case c.processing == corba.UseDefaultServant && !c.multipleID:
+		return false
+	case c.implicit && (c.userID || !c.retain):
+		return false
+	}
+	return true
+}
+
+// allPolicyCombinations enumerates every combination of the ID assignment, ID
+// uniqueness, implicit activation, servant retention and request processing policies
+func allPolicyCombinations() []policyCombination {
+	var combinations []policyCombination
+	for _, userID := range []bool{false, true} {
+		for _, multipleID := range []bool{false, true} {
+			for _, implicit := range []bool{false, true} {
+				for _, retain := range []bool{false, true} {
+					for _, processing := range []int{corba.UseActiveObjectMapOnly, corba.UseDefaultServant, corba.UseServantManager} {
+						combinations = append(combinations, policyCombination{userID, multipleID, implicit, retain, processing})
+					}
+				}
+			}
+		}
+	}
+	return combinations
+}
+
+// wantPOAError checks that err is the expected POA exception, or nil
+func wantPOAError(t *testing.T, op string, err, want error) {
+	t.Helper()
+	if err != want {
+		t.Errorf("%s = %v, want %v", op, err, want)
+	}
+}
+
+func TestPOAPolicyMatrix(t *testing.T) {
+	combinations := allPolicyCombinations()
+	if len(combinations) != 48 {
+		t.Fatalf("matrix has %d combinations, want 48", len(combinations))
+	}
+
+	for _, c := range combinations {
+		t.Run(c.String(), func(t *testing.T) {
+			poa, err := corba.Init().GetRootPOA().CreatePOA("Matrix", nil, c.policies())
+			if !c.valid() {
+				wantPOAError(t, "CreatePOA", err, corba.ErrInvalidPolicy)
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreatePOA: %v", err)
+			}
+
+			testActivation(t, poa, c)
+			testServantToID(t, poa, c)
+			testServantSources(t, poa, c)
+		})
+	}
+}
+
+// testActivation covers ActivateObject, ActivateObjectWithID and DeactivateObject
+func testActivation(t *testing.T, poa *corba.POA, c policyCombination) {
+	t.Helper()
+
+	// ActivateObject needs SYSTEM_ID and RETAIN
+	servant := &recordingServant{}
+	oid, err := poa.ActivateObject(servant)
+	if c.userID || !c.retain {
+		wantPOAError(t, "ActivateObject", err, corba.ErrWrongPolicy)
+	} else {
+		wantPOAError(t, "ActivateObject", err, nil)
+		_, err = poa.ActivateObject(servant)
+		if c.multipleID {
+			wantPOAError(t, "ActivateObject of an active servant", err, nil)
+		} else {
+			wantPOAError(t, "ActivateObject of an active servant", err, corba.ErrServantAlreadyActive)
+		}
+		if got, err := poa.IDToServant(oid); err != nil || got != servant {
+			t.Errorf("IDToServant = %v, %v", got, err)
+		}
+	}
+
+	// ActivateObjectWithID needs USER_ID and RETAIN
+	other := &recordingServant{}
+	err = poa.ActivateObjectWithID(corba.ObjectID("user-1"), other)
+	if !c.userID || !c.retain {
+		wantPOAError(t, "ActivateObjectWithID", err, corba.ErrWrongPolicy)
+	} else {
+		wantPOAError(t, "ActivateObjectWithID", err, nil)
+		err = poa.ActivateObjectWithID(corba.ObjectID("user-1"), &recordingServant{})
+		wantPOAError(t, "ActivateObjectWithID of an active ID", err, corba.ErrObjectAlreadyActive)
+		err = poa.ActivateObjectWithID(corba.ObjectID("user-2"), other)
+		if c.multipleID {
+			wantPOAError(t, "ActivateObjectWithID of an active servant", err, nil)
+		} else {
+			wantPOAError(t, "ActivateObjectWithID of an active servant", err, corba.ErrServantAlreadyActive)
+		}
+	}
+
+	// DeactivateObject needs RETAIN
+	err = poa.DeactivateObject(corba.ObjectID("user-1"))
+	switch {
+	case !c.retain:
+		wantPOAError(t, "DeactivateObject", err, corba.ErrWrongPolicy)
+	case c.userID:
+		wantPOAError(t, "DeactivateObject", err, nil)
+	default:
+		wantPOAError(t, "DeactivateObject", err, corba.ErrObjectNotActive)
+	}
+}
+
+// testServantToID covers implicit activation and the lookup of active servants
+func testServantToID(t *testing.T, poa *corba.POA, c policyCombination) {
+	t.Helper()
+
+	servant := &recordingServant{}
+	oid, err := poa.ServantToID(servant)
+	allowed := c.processing == corba.UseDefaultServant || (c.retain && (!c.multipleID || c.implicit))
+	switch {
+	case !allowed:
+		wantPOAError(t, "ServantToID", err, corba.ErrWrongPolicy)
+		return
+	case !c.implicit:
+		wantPOAError(t, "ServantToID of an inactive servant", err, corba.ErrServantNotActive)
+		return
+	}
+
+	// The servant was activated implicitly
+	if err != nil || len(oid) == 0 {
+		t.Fatalf("ServantToID with implicit activation = %q, %v", oid, err)
+	}
+	again, err := poa.ServantToID(servant)
+	if err != nil {
+		t.Fatalf("ServantToID of an active servant: %v", err)
+	}
+	if c.multipleID == bytes.Equal(again, oid) {
+		t.Errorf("second ServantToID returned %q after %q", again, oid)
+	}
+	if _, err := poa.ServantToReference(servant); err != nil {
+		t.Errorf("ServantToReference: %v", err)
+	}
+}
+
+// testServantSources covers servant managers, default servants and IDToServant
+func testServantSources(t *testing.T, poa *corba.POA, c policyCombination) {
+	t.Helper()
+
+	incarnated := false
+	activator := &corba.BasicServantActivator{
+		IncarnateFunc: func(id corba.ObjectID, adapter *corba.POA) (interface{}, error) {
+			incarnated = true
+			return &recordingServant{}, nil
+		},
+	}
+	err := poa.SetServantManager(activator)
+	switch {
+	case c.processing != corba.UseServantManager:
+		wantPOAError(t, "SetServantManager", err, corba.ErrWrongPolicy)
+		_, err = poa.GetServantManager()
+		wantPOAError(t, "GetServantManager", err, corba.ErrWrongPolicy)
+	case c.retain:
+		wantPOAError(t, "SetServantManager(activator)", err, nil)
+	default:
+		wantSystemException(t, err, "OBJ_ADAPTER")
+		wantPOAError(t, "SetServantManager(locator)", poa.SetServantManager(&corba.BasicServantLocator{}), nil)
+	}
+
+	defaultServant := &recordingServant{}
+	err = poa.SetDefaultServant(defaultServant)
+	if c.processing != corba.UseDefaultServant {
+		wantPOAError(t, "SetDefaultServant", err, corba.ErrWrongPolicy)
+	} else {
+		wantPOAError(t, "SetDefaultServant", err, nil)
+	}
+
+	// IDToServant needs RETAIN or USE_DEFAULT_SERVANT
+	servant, err := poa.IDToServant(corba.ObjectID("unknown"))
+	switch {
+	case c.processing == corba.UseDefaultServant:
+		if err != nil || servant != defaultServant {
+			t.Errorf("IDToServant = %v, %v, want the default servant", servant, err)
+		}
+	case !c.retain:
+		wantPOAError(t, "IDToServant", err, corba.ErrWrongPolicy)
+	default:
+		// Servant activators are not asked to incarnate the object
+		wantPOAError(t, "IDToServant", err, corba.ErrObjectNotActive)
+		if incarnated {
+			t.Error("IDToServant incarnated the object")
+		}
+	}
+}
+
+func TestPOAExceptionsAreUserExceptions(t *testing.T) {
+	for _, err := range []error{corba.ErrWrongPolicy, corba.ErrServantAlreadyActive, corba.ErrObjectAlreadyActive, corba.ErrServantNotActive} {
+		ex, ok := err.(*corba.UserException)
+		if !ok {
+			t.Fatalf("%v is not a user exception", err)
+		}
+		if want := "IDL:omg.org/PortableServer/POA/" + ex.Name() + ":1.0"; ex.ID() != want {
+			t.Errorf("repository ID = %q, want %q", ex.ID(), want)
+		}
+	}
+}
+
+// valueServant is a servant activated by value
+type valueServant struct {
+	name string
+}
+
+func (s valueServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.name, nil
+}
+
+// sliceServant is a value servant Go cannot compare
+type sliceServant struct {
+	names []string
+}
+
+func (s sliceServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.names, nil
+}
+
+func TestPOAValueServants(t *testing.T) {
+	poa := corba.Init().GetRootPOA()
+
+	// Distinct comparable values are distinct servants
+	a, b := valueServant{name: "a"}, valueServant{name: "b"}
+	idA, err := poa.ActivateObject(a)
+	if err != nil {
+		t.Fatalf("ActivateObject(a): %v", err)
+	}
+	idB, err := poa.ActivateObject(b)
+	if err != nil {
+		t.Fatalf("ActivateObject(b): %v", err)
+	}
+	if got, err := poa.ServantToID(b); err != nil || !bytes.Equal(got, idB) {
+		t.Errorf("ServantToID(b) = %q, %v, want %q", got, err, idB)
+	}
+	if _, err := poa.ActivateObject(valueServant{name: "a"}); err != corba.ErrServantAlreadyActive {
+		t.Errorf("ActivateObject(a) again = %v, want ServantAlreadyActive", err)
+	}
+	if err := poa.DeactivateObject(idA); err != nil {
+		t.Fatalf("DeactivateObject: %v", err)
+	}
+
+	// Values that cannot be compared are refused rather than confused
+	for _, servant := range []sliceServant{{names: []string{"c"}}, {names: []string{"d"}}} {
+		_, err := poa.ActivateObject(servant)
+		wantMinorCode(t, err, "BAD_PARAM", corba.MinorUncomparableServant)
+		_, err = poa.ServantToID(servant)
+		wantMinorCode(t, err, "BAD_PARAM", corba.MinorUncomparableServant)
+	}
+}
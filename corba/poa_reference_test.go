@@ -0,0 +1,108 @@
+package corba_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+func TestPOAReferenceTranslation(t *testing.T) {
+	orb := corba.Init()
+	poaTestServer(t, orb, "poa-reference")
+	root := orb.GetRootPOA()
+
+	accounts, err := root.CreatePOA("Accounts", nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	servant := &recordingServant{}
+	oid, err := accounts.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref, err := accounts.ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+
+	// The reference survives a round trip through its stringified IOR
+	str, err := orb.ObjectToString(ref)
+	if err != nil {
+		t.Fatalf("ObjectToString: %v", err)
+	}
+	parsed, err := orb.StringToObject(str)
+	if err != nil {
+		t.Fatalf("StringToObject: %v", err)
+	}
+
+	for _, r := range []*corba.ObjectRef{ref, parsed} {
+		if id, err := accounts.ReferenceToID(r); err != nil || !bytes.Equal(id, oid) {
+			t.Errorf("ReferenceToID = %q, %v, want %q", id, err, oid)
+		}
+		if got, err := accounts.ReferenceToServant(r); err != nil || got != servant {
+			t.Errorf("ReferenceToServant = %v, %v, want the active servant", got, err)
+		}
+	}
+
+	byID, err := accounts.IDToReference(oid)
+	if err != nil {
+		t.Fatalf("IDToReference: %v", err)
+	}
+	if !bytes.Equal(byID.GetObjectKey(), ref.GetObjectKey()) {
+		t.Errorf("IDToReference has object key %q, want %q", byID.GetObjectKey(), ref.GetObjectKey())
+	}
+
+	// Objects that are not active
+	inactive := accounts.CreateReferenceWithId(corba.ObjectID("closed"), "IDL:Bank/Account:1.0")
+	if _, err := accounts.ReferenceToServant(inactive); err != corba.ErrObjectNotActive {
+		t.Errorf("ReferenceToServant of an inactive object = %v, want ObjectNotActive", err)
+	}
+	if _, err := accounts.IDToReference(corba.ObjectID("closed")); err != corba.ErrObjectNotActive {
+		t.Errorf("IDToReference of an inactive object = %v, want ObjectNotActive", err)
+	}
+}
+
+func TestPOAReferenceTranslationWrongAdapter(t *testing.T) {
+	orb := corba.Init()
+	root := orb.GetRootPOA()
+
+	accounts, err := root.CreatePOA("Accounts", nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	branches, err := root.CreatePOA("Branches", nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	branchRef := branches.CreateReference("IDL:Bank/Branch:1.0", nil)
+
+	plainRef, err := orb.ObjectToReference(&recordingServant{})
+	if err != nil {
+		t.Fatalf("ObjectToReference: %v", err)
+	}
+
+	// A transient POA recreated under the same name is a different adapter
+	staleRef := accounts.CreateReference("IDL:Bank/Account:1.0", nil)
+	if err := accounts.Destroy(false, false); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	accounts, err = root.CreatePOA("Accounts", nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+
+	for name, ref := range map[string]*corba.ObjectRef{
+		"sibling POA":       branchRef,
+		"non-POA reference": plainRef,
+		"destroyed POA":     staleRef,
+		"nil reference":     nil,
+	} {
+		if _, err := accounts.ReferenceToID(ref); err != corba.ErrWrongAdapter {
+			t.Errorf("ReferenceToID of %s = %v, want WrongAdapter", name, err)
+		}
+		if _, err := accounts.ReferenceToServant(ref); err != corba.ErrWrongAdapter {
+			t.Errorf("ReferenceToServant of %s = %v, want WrongAdapter", name, err)
+		}
+	}
+}
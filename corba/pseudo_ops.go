@@ -0,0 +1,290 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/ifabos/go-corba/giop"
+)
+
+// Operations of CORBA::Object that the ORB answers for every servant
+const (
+	opIsA          = "_is_a"
+	opNonExistent  = "_non_existent"
+	opInterface    = "_interface"
+	opRepositoryID = "_repository_id"
+)
+
+// Repository IDs of the interfaces involved in the standard object operations
+const (
+	ObjectTypeID       = "IDL:omg.org/CORBA/Object:1.0"
+	InterfaceDefTypeID = "IDL:omg.org/CORBA/InterfaceDef:1.0"
+)
+
+// PrimaryInterfaceProvider is implemented by servants that know the repository
+// ID of the most derived interface they implement. The ORB reports it for
+// _repository_id and _is_a, and advertises it in references to the servant,
+// unless the servant is registered with the Interface Repository.
+type PrimaryInterfaceProvider interface {
+	PrimaryInterface() string
+}
+
+// encodedResult is the CDR-encoded result of an operation answered by the ORB
+// itself. It is sent as the reply body.
+type encodedResult []byte
+
+// isObjectOperation reports whether an operation is one of the standard
+// object operations answered by the ORB
+func isObjectOperation(operation string) bool {
+	switch operation {
+	case opIsA, opNonExistent, opInterface, opRepositoryID:
+		return true
+	}
+	return false
+}
+
+// objectOperation answers a standard object operation on behalf of a servant.
+// Arguments are decoded in the byte order of the request, and results are
+// CDR-encoded in big endian byte order.
+func (s *Server) objectOperation(servant interface{}, operation string, body []byte, order binary.ByteOrder) (interface{}, error) {
+	switch operation {
+	case opIsA:
+		id, err := giop.NewCDRUnmarshaller(body, order).ReadString()
+		if err != nil {
+			return nil, MARSHAL(MinorMalformedRequest, CompletionStatusNo)
+		}
+		return encodeBoolean(s.orb.servantIsA(servant, id)), nil
+
+	case opNonExistent:
+		// The servant was found, so the object exists
+		return encodeBoolean(false), nil
+
+	case opRepositoryID:
+		m := giop.NewCDRMarshaller(binary.BigEndian)
+		m.WriteString(s.orb.repositoryIDFor(servant))
+		return encodedResult(m.Bytes()), nil
+
+	case opInterface:
+		id := s.orb.repositoryIDFor(servant)
+		if s.orb.interfaceRepository == nil {
+			return nil, INTF_REPOS(MinorIRNotAvailable, CompletionStatusNo)
+		}
+		if _, err := s.orb.interfaceRepository.LookupInterface(id); err != nil {
+			return nil, INTF_REPOS(MinorNoIREntry, CompletionStatusNo)
+		}
+		ref := s.orb.createReference(InterfaceDefTypeID, interfaceDefKey(id), false)
+		return encodedResult(ref.ior.Encode()), nil
+	}
+
+	return nil, BAD_OPERATION(MinorOperationUnknown, CompletionStatusNo)
+}
+
+// encodeBoolean encodes a boolean result
+func encodeBoolean(value bool) encodedResult {
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	m.WriteBool(value)
+	return encodedResult(m.Bytes())
+}
+
+// repositoryIDFor returns the repository ID of the most derived interface of
+// a servant: the one registered with the Interface Repository, the one the
+// servant reports, or one derived from its Go type
+func (orb *ORB) repositoryIDFor(servant interface{}) string {
+	// Try to get it from Interface Repository if available
+	if orb.interfaceRepository != nil {
+		id, err := orb.interfaceRepository.GetRepositoryID(servant)
+		if err == nil && id != "" {
+			return id
+		}
+	}
+
+	if p, ok := servant.(PrimaryInterfaceProvider); ok {
+		if id := p.PrimaryInterface(); id != "" {
+			return id
+		}
+	}
+
+	// Otherwise generate a default one based on the type
+	return FormatRepositoryID(fmt.Sprintf("%T", servant), "1.0")
+}
+
+// servantIsA reports whether a servant implements an interface. Every object
+// is a CORBA::Object; base interfaces of the servant's own interface are found
+// in the Interface Repository.
+func (orb *ORB) servantIsA(servant interface{}, id string) bool {
+	if id == ObjectTypeID {
+		return true
+	}
+
+	primary := orb.repositoryIDFor(servant)
+	if primary == id {
+		return true
+	}
+	if orb.interfaceRepository == nil {
+		return false
+	}
+
+	visited := make(map[string]bool)
+	var inherits func(derived string) bool
+	inherits = func(derived string) bool {
+		if visited[derived] {
+			return false
+		}
+		visited[derived] = true
+
+		def, err := orb.interfaceRepository.LookupInterface(derived)
+		if err != nil {
+			return false
+		}
+		for _, base := range def.BaseInterfaces() {
+			if base.Id() == id || inherits(base.Id()) {
+				return true
+			}
+		}
+		return false
+	}
+	return inherits(primary)
+}
+
+// interfaceDefKeyPrefix starts the object keys of references returned by _interface
+const interfaceDefKeyPrefix = InterfaceRepositoryName + "/"
+
+// interfaceDefKey returns the object key of the InterfaceDef for a repository ID
+func interfaceDefKey(id string) []byte {
+	return ObjectKeyFromString(interfaceDefKeyPrefix + id)
+}
+
+// interfaceDefServant returns the servant for an InterfaceDef reference
+// returned by _interface, or nil if the key denotes no interface known to the
+// Interface Repository
+func (orb *ORB) interfaceDefServant(objectKey []byte) *InterfaceDefServant {
+	id, ok := strings.CutPrefix(ObjectKeyToString(objectKey), interfaceDefKeyPrefix)
+	if !ok || orb.interfaceRepository == nil {
+		return nil
+	}
+
+	def, err := orb.interfaceRepository.LookupInterface(id)
+	if err != nil {
+		return nil
+	}
+	return &InterfaceDefServant{def: def, ir: orb.interfaceRepository}
+}
+
+// InterfaceDefServant serves an interface definition of the Interface
+// Repository, as returned by the _interface operation
+type InterfaceDefServant struct {
+	def InterfaceDef
+	ir  InterfaceRepository
+}
+
+// PrimaryInterface returns the repository ID of CORBA::InterfaceDef
+func (s *InterfaceDefServant) PrimaryInterface() string {
+	return InterfaceDefTypeID
+}
+
+// Dispatch handles incoming CORBA method calls to the interface definition
+func (s *InterfaceDefServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	switch methodName {
+	case "_get_id":
+		return s.def.Id(), nil
+	case "_get_name":
+		return s.def.Name(), nil
+	case "describe_interface":
+		return NewInterfaceRepositoryServant(s.ir).Dispatch(methodName, []interface{}{s.def.Id()})
+	}
+	return nil, BAD_OPERATION(MinorOperationUnknown, CompletionStatusNo)
+}
+
+// IsA reports whether the referenced object supports the interface with the
+// given repository ID. References whose type ID matches answer without
+// contacting the object; otherwise the object is asked with _is_a.
+func (ref *ObjectRef) IsA(repoID string) (bool, error) {
+	if ref == nil || ref.client == nil {
+		return false, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+	if repoID == ref.GetTypeID() || repoID == ObjectTypeID {
+		return true, nil
+	}
+
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	m.WriteString(repoID)
+	result, err := ref.invoke(invocation{
+		operation: opIsA,
+		args:      []interface{}{repoID},
+		body:      m.Bytes(),
+		decode:    decodeBoolean,
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// NonExistent reports whether the referenced object is known not to exist
+// any more. Failures to reach the object are returned as errors.
+func (ref *ObjectRef) NonExistent() (bool, error) {
+	if ref == nil || ref.client == nil {
+		return false, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	result, err := ref.invoke(invocation{operation: opNonExistent, decode: decodeBoolean})
+	if err != nil {
+		// Servers that do not answer _non_existent themselves raise OBJECT_NOT_EXIST
+		if ex, ok := err.(Exception); ok && ex.Name() == "OBJECT_NOT_EXIST" {
+			return true, nil
+		}
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// RepositoryID returns the repository ID of the most derived interface of the
+// referenced object, as reported by the object itself
+func (ref *ObjectRef) RepositoryID() (string, error) {
+	if ref == nil || ref.client == nil {
+		return "", OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	result, err := ref.invoke(invocation{
+		operation: opRepositoryID,
+		decode: func(body []byte, order binary.ByteOrder) (interface{}, error) {
+			return giop.NewCDRUnmarshaller(body, order).ReadString()
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// GetInterface returns a reference to the InterfaceDef describing the
+// referenced object in the Interface Repository of its server. Servers
+// without an entry for the object's interface raise INTF_REPOS.
+func (ref *ObjectRef) GetInterface() (*ObjectRef, error) {
+	if ref == nil || ref.client == nil {
+		return nil, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo)
+	}
+
+	result, err := ref.invoke(invocation{
+		operation: opInterface,
+		decode: func(body []byte, order binary.ByteOrder) (interface{}, error) {
+			return DecodeIOR(body)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	def := &ObjectRef{client: ref.client}
+	if err := def.SetIOR(result.(*IOR)); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// decodeBoolean decodes a boolean result
+func decodeBoolean(body []byte, order binary.ByteOrder) (interface{}, error) {
+	return giop.NewCDRUnmarshaller(body, order).ReadBool()
+}
@@ -0,0 +1,120 @@
+package corba_test
+
+import (
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// accountServant reports its interface like generated servants do
+type accountServant struct {
+	recordingServant
+}
+
+func (s *accountServant) PrimaryInterface() string {
+	return "IDL:Bank/Account:1.0"
+}
+
+// defineAccountInterfaces registers Bank::Account, derived from Bank::Named,
+// with the ORB's Interface Repository
+func defineAccountInterfaces(t *testing.T, orb *corba.ORB) {
+	t.Helper()
+
+	ir, err := orb.GetInterfaceRepository()
+	if err != nil {
+		t.Fatalf("GetInterfaceRepository: %v", err)
+	}
+	named, err := ir.GetRepository().CreateInterface("IDL:Bank/Named:1.0", "Named")
+	if err != nil {
+		t.Fatalf("CreateInterface: %v", err)
+	}
+	account, err := ir.GetRepository().CreateInterface("IDL:Bank/Account:1.0", "Account")
+	if err != nil {
+		t.Fatalf("CreateInterface: %v", err)
+	}
+	account.SetBaseInterfaces([]corba.InterfaceDef{named})
+}
+
+func TestObjectOperations(t *testing.T) {
+	orb := corba.Init()
+	poaTestServer(t, orb, "object-operations")
+	defineAccountInterfaces(t, orb)
+	poa := orb.GetRootPOA()
+
+	servant := &accountServant{recordingServant{calls: make(chan string, 1)}}
+	oid, err := poa.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref, err := poa.ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	if got := ref.GetTypeID(); got != "IDL:Bank/Account:1.0" {
+		t.Errorf("reference type ID = %q, want the servant's primary interface", got)
+	}
+
+	// A reference typed as the base interface
+	named := poa.CreateReferenceWithId(oid, "IDL:Bank/Named:1.0")
+
+	for _, tc := range []struct {
+		ref  *corba.ObjectRef
+		id   string
+		want bool
+	}{
+		{named, "IDL:Bank/Account:1.0", true},
+		{ref, "IDL:Bank/Named:1.0", true},
+		{ref, corba.ObjectTypeID, true},
+		{ref, "IDL:Bank/Branch:1.0", false},
+	} {
+		if got, err := tc.ref.IsA(tc.id); err != nil || got != tc.want {
+			t.Errorf("%s.IsA(%s) = %v, %v, want %v", tc.ref.GetTypeID(), tc.id, got, err, tc.want)
+		}
+	}
+
+	if id, err := named.RepositoryID(); err != nil || id != "IDL:Bank/Account:1.0" {
+		t.Errorf("RepositoryID = %q, %v, want the most derived interface", id, err)
+	}
+
+	def, err := named.GetInterface()
+	if err != nil {
+		t.Fatalf("GetInterface: %v", err)
+	}
+	if id, err := def.RepositoryID(); err != nil || id != corba.InterfaceDefTypeID {
+		t.Errorf("InterfaceDef RepositoryID = %q, %v, want %q", id, err, corba.InterfaceDefTypeID)
+	}
+
+	// The ORB answered every operation without the servant
+	select {
+	case op := <-servant.calls:
+		t.Errorf("servant dispatched %q", op)
+	default:
+	}
+
+	if gone, err := named.NonExistent(); err != nil || gone {
+		t.Errorf("NonExistent of an active object = %v, %v", gone, err)
+	}
+	if err := poa.DeactivateObject(oid); err != nil {
+		t.Fatalf("DeactivateObject: %v", err)
+	}
+	if gone, err := named.NonExistent(); err != nil || !gone {
+		t.Errorf("NonExistent of a deactivated object = %v, %v", gone, err)
+	}
+}
+
+func TestGetInterfaceWithoutRepositoryEntry(t *testing.T) {
+	orb := corba.Init()
+	poaTestServer(t, orb, "object-operations-unknown")
+
+	servant := &recordingServant{}
+	if _, err := orb.GetRootPOA().ActivateObject(servant); err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+
+	_, err = ref.GetInterface()
+	wantSystemException(t, err, "INTF_REPOS")
+}
@@ -19,6 +19,7 @@ type SecureIIOP struct {
 	tlsConfig *tls.Config
 	host      string
 	port      int
+	transport *TLSTransport
 }
 
 // NewSecureIIOP creates a new secure IIOP connection
@@ -27,19 +28,28 @@ func NewSecureIIOP(host string, port int, tlsConfig *tls.Config) *SecureIIOP {
 		tlsConfig: tlsConfig,
 		host:      host,
 		port:      port,
+		transport: NewTLSTransport(tlsConfig),
 	}
 }
 
+// Transport returns the TLS transport used for the connection
+func (s *SecureIIOP) Transport() *TLSTransport {
+	return s.transport
+}
+
+// Endpoint returns the TLS endpoint of the connection
+func (s *SecureIIOP) Endpoint() Endpoint {
+	return NewTLSEndpoint(s.host, s.port)
+}
+
 // Connect establishes a secure connection to a CORBA server
 func (s *SecureIIOP) Connect() (net.Conn, error) {
-	address := fmt.Sprintf("%s:%d", s.host, s.port)
-	return tls.Dial("tcp", address, s.tlsConfig)
+	return s.transport.Connect(s.Endpoint())
 }
 
 // Listen starts a secure IIOP server listener
 func (s *SecureIIOP) Listen() (net.Listener, error) {
-	address := fmt.Sprintf("%s:%d", s.host, s.port)
-	return tls.Listen("tcp", address, s.tlsConfig)
+	return s.transport.Listen(s.Endpoint())
 }
 
 // SecureServer represents a CORBA server with security features
@@ -50,11 +60,6 @@ type SecureServer struct {
 
 // NewSecureServer creates a new secure server
 func (orb *ORB) NewSecureServer(host string, port int, certFile, keyFile string) (*SecureServer, error) {
-	server, err := orb.CreateServer(host, port)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create base server: %w", err)
-	}
-
 	// Load certificate and key
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
@@ -67,22 +72,21 @@ func (orb *ORB) NewSecureServer(host string, port int, certFile, keyFile string)
 		MinVersion:   tls.VersionTLS12,
 	}
 
-	secureIIOP := NewSecureIIOP(host, port, tlsConfig)
-
-	return &SecureServer{
-		Server:     server,
-		secureIIOP: secureIIOP,
-	}, nil
+	return orb.NewSecureServerWithConfig(host, port, tlsConfig)
 }
 
 // NewSecureServerWithConfig creates a new secure server with a custom TLS configuration
 func (orb *ORB) NewSecureServerWithConfig(host string, port int, tlsConfig *tls.Config) (*SecureServer, error) {
+	secureIIOP := NewSecureIIOP(host, port, tlsConfig)
+
 	server, err := orb.CreateServer(host, port)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create base server: %w", err)
 	}
 
-	secureIIOP := NewSecureIIOP(host, port, tlsConfig)
+	// The server listens through the TLS transport instead of plain TCP
+	server.endpoint = secureIIOP.Endpoint()
+	server.transport = secureIIOP.Transport()
 
 	return &SecureServer{
 		Server:     server,
@@ -92,45 +96,9 @@ func (orb *ORB) NewSecureServerWithConfig(host string, port int, tlsConfig *tls.
 
 // Start starts the secure server
 func (s *SecureServer) Start() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.running {
-		return fmt.Errorf("server already running")
-	}
-
-	// Start secure IIOP listener
-	listener, err := s.secureIIOP.Listen()
-	if err != nil {
+	if err := s.Run(); err != nil {
 		return fmt.Errorf("failed to start secure IIOP listener: %w", err)
 	}
-
-	s.listener = listener
-	s.running = true
-
-	fmt.Printf("Secure CORBA server listening on %s (TLS/SSL enabled)\n", listener.Addr())
-
-	// Handle incoming connections
-	go func() {
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				// Check if server was stopped
-				s.mu.RLock()
-				running := s.running
-				s.mu.RUnlock()
-				if !running {
-					return
-				}
-				fmt.Printf("Error accepting secure connection: %v\n", err)
-				continue
-			}
-
-			// Handle connection in a new goroutine
-			go s.handleConnection(conn)
-		}
-	}()
-
 	return nil
 }
 
@@ -170,9 +138,12 @@ func (orb *ORB) NewSecureClient(trustFile string) (*SecureClient, error) {
 	// Create security manager
 	securityManager := NewSecurityManager()
 
+	secureIIOP := NewSecureIIOP("", 0, tlsConfig)
+	client.transport = secureIIOP.Transport()
+
 	return &SecureClient{
 		Client:          client,
-		secureIIOP:      NewSecureIIOP("", 0, tlsConfig),
+		secureIIOP:      secureIIOP,
 		securityManager: securityManager,
 	}, nil
 }
@@ -182,9 +153,12 @@ func (orb *ORB) NewSecureClientWithConfig(tlsConfig *tls.Config) (*SecureClient,
 	client := orb.CreateClient()
 	securityManager := NewSecurityManager()
 
+	secureIIOP := NewSecureIIOP("", 0, tlsConfig)
+	client.transport = secureIIOP.Transport()
+
 	return &SecureClient{
 		Client:          client,
-		secureIIOP:      NewSecureIIOP("", 0, tlsConfig),
+		secureIIOP:      secureIIOP,
 		securityManager: securityManager,
 		securityContext: nil,
 	}, nil
@@ -197,20 +171,9 @@ func (c *SecureClient) Connect(host string, port int) error {
 	c.secureIIOP.port = port
 
 	// Establish secure connection
-	conn, err := c.secureIIOP.Connect()
-	if err != nil {
+	if err := c.ConnectEndpoint(c.secureIIOP.Endpoint()); err != nil {
 		return fmt.Errorf("failed to connect securely: %w", err)
 	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.connections == nil {
-		c.connections = make(map[string]net.Conn)
-	}
-
-	address := fmt.Sprintf("%s:%d", host, port)
-	c.connections[address] = conn
 	return nil
 }
 
@@ -85,7 +85,8 @@ const (
 	DelegationComposite
 )
 
-// SecurityException represents a security-related CORBA system exception
+// SecurityException represents a security-related CORBA system exception.
+// It is sent to clients as the NO_PERMISSION system exception.
 type SecurityException struct {
 	*SystemException
 	Reason string
@@ -109,32 +110,32 @@ func (e *SecurityException) Error() string {
 var (
 	// SecurityInvalidCredentials indicates invalid credentials
 	SecurityInvalidCredentials = func(reason string) Exception {
-		return NewSecurityException(reason, 1, CompletionStatusNo)
+		return NewSecurityException(reason, MinorInvalidCredentials, CompletionStatusNo)
 	}
 
 	// SecurityInvalidToken indicates invalid security token
 	SecurityInvalidToken = func(reason string) Exception {
-		return NewSecurityException(reason, 2, CompletionStatusNo)
+		return NewSecurityException(reason, MinorInvalidToken, CompletionStatusNo)
 	}
 
 	// SecurityAuthenticationFailed indicates authentication failure
 	SecurityAuthenticationFailed = func(reason string) Exception {
-		return NewSecurityException(reason, 3, CompletionStatusNo)
+		return NewSecurityException(reason, MinorSecurityAuthenticationFailed, CompletionStatusNo)
 	}
 
 	// SecurityAccessDenied indicates access denied
 	SecurityAccessDenied = func(reason string, op string) Exception {
-		return NewSecurityException(fmt.Sprintf("%s: operation %s", reason, op), 4, CompletionStatusNo)
+		return NewSecurityException(fmt.Sprintf("%s: operation %s", reason, op), MinorAccessDenied, CompletionStatusNo)
 	}
 
 	// SecurityInvalidPolicy indicates invalid security policy
 	SecurityInvalidPolicy = func(reason string) Exception {
-		return NewSecurityException(reason, 5, CompletionStatusNo)
+		return NewSecurityException(reason, MinorInvalidSecurityPolicy, CompletionStatusNo)
 	}
 
 	// SecurityContextExpired indicates security context expired
 	SecurityContextExpired = func() Exception {
-		return NewSecurityException("Security context expired", 6, CompletionStatusNo)
+		return NewSecurityException("Security context expired", MinorSecurityContextExpired, CompletionStatusNo)
 	}
 )
 
@@ -237,13 +238,6 @@ type SecurityPolicy interface {
 	Evaluate(principal Principal, action string, target string) bool
 }
 
-// Initialize standard system exceptions for security
-func init() {
-	// Register security exceptions with the exception system
-	// This will allow them to be properly identified when received over the wire
-	RegisterException("IDL:omg.org/Security/InvalidCredentials:1.0", &SecurityException{})
-}
-
 // BasicPrincipal provides a simple implementation of Principal
 type BasicPrincipal struct {
 	PrincipalName        string
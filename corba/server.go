@@ -1,7 +1,9 @@
 package corba
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -20,24 +22,60 @@ type ServerBinding struct {
 
 // Server represents a CORBA server
 type Server struct {
-	orb      *ORB
-	bindings []ServerBinding
-	running  bool
-	mu       sync.RWMutex
-	listener net.Listener
-	host     string
-	port     int
+	orb            *ORB
+	bindings       []ServerBinding
+	running        bool
+	mu             sync.RWMutex
+	listener       net.Listener
+	endpoint       Endpoint
+	transport      Transport
+	ephemeral      bool                     // Listens on a port chosen by the system
+	implRepository *ImplRepository          // Set when the server hosts an implementation repository
+	conns          map[*serverConn]struct{} // Open connections
+	inFlight       int                      // Requests being dispatched
+	stopping       bool                     // Set while the server shuts down
+	drained        chan struct{}            // Closed when the last in-flight request completes during shutdown
 }
 
+// closeConnectionTimeout bounds how long shutdown waits to deliver a GIOP
+// CloseConnection message to a client
+const closeConnectionTimeout = time.Second
+
 // CreateServer creates a new server at the specified host and port
 func (o *ORB) CreateServer(host string, port int) (*Server, error) {
-	return &Server{
-		orb:      o,
-		bindings: make([]ServerBinding, 0),
-		running:  false,
-		host:     host,
-		port:     port,
-	}, nil
+	return o.CreateServerOn(NewTCPEndpoint(host, port))
+}
+
+// CreateServerOn creates a new server listening on an endpoint of any registered transport
+func (o *ORB) CreateServerOn(ep Endpoint) (*Server, error) {
+	transport, err := o.GetTransport(ep.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		orb:       o,
+		bindings:  make([]ServerBinding, 0),
+		running:   false,
+		endpoint:  ep,
+		transport: transport,
+		ephemeral: (ep.Protocol == TransportTCP || ep.Protocol == TransportTLS) && ep.Port == 0,
+		conns:     make(map[*serverConn]struct{}),
+	}
+
+	o.mu.Lock()
+	o.servers = append(o.servers, server)
+	o.mu.Unlock()
+
+	return server, nil
+}
+
+// Endpoint returns the endpoint the server listens on. Once the server runs, a
+// port of zero has been replaced by the port actually bound.
+func (s *Server) Endpoint() Endpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.endpoint
 }
 
 // RegisterServant registers a servant object with a name
@@ -82,31 +120,101 @@ func (s *Server) Run() error {
 		return fmt.Errorf("server is already running")
 	}
 	s.running = true
+	s.stopping = false
 	s.mu.Unlock()
 
-	// Start the IIOP listener
-	return s.startIIOPListener()
+	// Start the transport listener
+	if err := s.startListener(); err != nil {
+		return err
+	}
+
+	// Persistent POAs can be reached now, so tell the implementation repository
+	if err := s.orb.registerPersistentPOAs(); err != nil {
+		s.Shutdown()
+		return err
+	}
+	return nil
 }
 
-// Shutdown stops the server
+// Shutdown stops the server, waiting for in-flight requests to complete
 func (s *Server) Shutdown() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.ShutdownContext(context.Background())
+}
+
+// ShutdownContext stops the server gracefully. It stops accepting connections,
+// sends a GIOP CloseConnection to clients without outstanding requests, and
+// waits for in-flight requests to complete before closing their connections.
+// Requests that arrive in the meantime are rejected with TRANSIENT. If ctx
+// expires first, the remaining connections are closed and ctx.Err() is returned.
+// Called with the context of a request dispatched by the ORB, it fails with
+// BAD_INV_ORDER.
+func (s *Server) ShutdownContext(ctx context.Context) error {
+	if s.orb.inDispatch(ctx) {
+		return BAD_INV_ORDER(MinorWouldDeadlock, CompletionStatusNo)
+	}
 
+	s.mu.Lock()
 	if !s.running {
+		s.mu.Unlock()
 		return fmt.Errorf("server is not running")
 	}
+	s.running = false
+	s.stopping = true
 
+	var listenerErr error
 	if s.listener != nil {
-		if err := s.listener.Close(); err != nil {
-			return fmt.Errorf("error closing listener: %w", err)
+		listenerErr = s.listener.Close()
+	}
+
+	var idle []*serverConn
+	for conn := range s.conns {
+		if conn.pending == 0 {
+			idle = append(idle, conn)
+			delete(s.conns, conn)
 		}
 	}
 
-	s.running = false
+	var drained chan struct{}
+	if s.inFlight > 0 {
+		s.drained = make(chan struct{})
+		drained = s.drained
+	}
+	s.mu.Unlock()
+
+	// Clients that are not reading may take a while to accept the message
+	for _, conn := range idle {
+		go conn.closeGracefully()
+	}
+
+	if drained != nil {
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			s.mu.Lock()
+			remaining := s.conns
+			s.conns = make(map[*serverConn]struct{})
+			s.mu.Unlock()
+
+			for conn := range remaining {
+				conn.Close()
+			}
+			return ctx.Err()
+		}
+	}
+
+	if listenerErr != nil {
+		return fmt.Errorf("error closing listener: %w", listenerErr)
+	}
 	return nil
 }
 
+// InFlightRequests returns the number of requests currently being dispatched
+func (s *Server) InFlightRequests() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.inFlight
+}
+
 // Stop is an alias for Shutdown
 func (s *Server) Stop() error {
 	return s.Shutdown()
@@ -119,21 +227,30 @@ func (s *Server) IsRunning() bool {
 	return s.running
 }
 
-// startIIOPListener starts the IIOP listener
-func (s *Server) startIIOPListener() error {
-	var err error
-	address := fmt.Sprintf("%s:%d", s.host, s.port)
-	s.listener, err = net.Listen("tcp", address)
+// startListener starts the listener of the server's transport
+func (s *Server) startListener() error {
+	listener, err := s.transport.Listen(s.endpoint)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", address, err)
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+		return fmt.Errorf("failed to listen on %s: %w", s.endpoint, err)
 	}
 
-	fmt.Printf("CORBA server listening on %s\n", s.listener.Addr())
+	s.mu.Lock()
+	s.listener = listener
+	// Ephemeral ports are only known once the listener is bound
+	if tcpAddr, ok := listener.Addr().(*net.TCPAddr); ok {
+		s.endpoint.Port = tcpAddr.Port
+	}
+	s.mu.Unlock()
+
+	fmt.Printf("CORBA server listening on %s\n", s.endpoint)
 
 	// Handle incoming connections
 	go func() {
 		for {
-			conn, err := s.listener.Accept()
+			conn, err := listener.Accept()
 			if err != nil {
 				// Check if server was stopped
 				s.mu.RLock()
@@ -147,16 +264,146 @@ func (s *Server) startIIOPListener() error {
 			}
 
 			// Handle connection in a new goroutine
-			go s.handleConnection(conn)
+			if tracked := s.trackConn(conn); tracked != nil {
+				go s.handleConnection(tracked)
+			}
 		}
 	}()
 
 	return nil
 }
 
+// serverConn is a server-side connection. Replies may be written by several
+// goroutines, for instance when held requests are released, so writes are
+// serialized by a per-connection lock.
+type serverConn struct {
+	net.Conn
+	writeMu sync.Mutex
+	pending int                 // Requests in flight on this connection, guarded by the server lock
+	oneway  map[uint32]struct{} // Requests that expect no reply, guarded by writeMu
+}
+
+// closeGracefully sends a GIOP CloseConnection and closes the connection
+func (c *serverConn) closeGracefully() {
+	closeMsg := &giop.Message{
+		Header: giop.NewMessageHeader(giop.MsgCloseConn, 0),
+		Body:   nil,
+	}
+
+	if data, err := giop.MarshalGIOPMessage(closeMsg); err == nil {
+		// Best effort, the client may not be reading
+		c.SetWriteDeadline(time.Now().Add(closeConnectionTimeout))
+		c.writeMessage(data)
+	}
+	c.Close()
+}
+
+// trackConn registers an accepted connection, or closes it if the server is shutting down
+func (s *Server) trackConn(netConn net.Conn) *serverConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping {
+		netConn.Close()
+		return nil
+	}
+
+	conn := &serverConn{Conn: netConn}
+	s.conns[conn] = struct{}{}
+	return conn
+}
+
+// untrackConn forgets a connection once its reader has stopped
+func (s *Server) untrackConn(conn *serverConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, conn)
+}
+
+// beginRequest records a request as in flight. It returns false if the server
+// is shutting down and the request must not be dispatched.
+func (s *Server) beginRequest(conn *serverConn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping {
+		return false
+	}
+	conn.pending++
+	s.inFlight++
+	return true
+}
+
+// endRequest records the completion of a request. During shutdown the
+// connection is closed once its last request has completed.
+func (s *Server) endRequest(conn *serverConn) {
+	s.mu.Lock()
+	conn.pending--
+	s.inFlight--
+
+	closeConn := false
+	if s.stopping {
+		if _, open := s.conns[conn]; open && conn.pending == 0 {
+			delete(s.conns, conn)
+			closeConn = true
+		}
+		if s.inFlight == 0 && s.drained != nil {
+			close(s.drained)
+			s.drained = nil
+		}
+	}
+	s.mu.Unlock()
+
+	if closeConn {
+		go conn.closeGracefully()
+	}
+}
+
+// isStopping reports whether the server is shutting down
+func (s *Server) isStopping() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stopping
+}
+
+// writeMessage writes a complete GIOP message to the connection
+func (c *serverConn) writeMessage(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.Write(data)
+	return err
+}
+
+// expectNoReply records that a request is oneway, so that its reply is not sent
+func (c *serverConn) expectNoReply(requestID uint32) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.oneway == nil {
+		c.oneway = make(map[uint32]struct{})
+	}
+	c.oneway[requestID] = struct{}{}
+}
+
+// writeReply writes the reply to a request, unless the request is oneway
+func (c *serverConn) writeReply(requestID uint32, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, ok := c.oneway[requestID]; ok {
+		delete(c.oneway, requestID)
+		return nil
+	}
+	_, err := c.Write(data)
+	return err
+}
+
 // handleConnection processes incoming IIOP requests
-func (s *Server) handleConnection(conn net.Conn) {
+func (s *Server) handleConnection(conn *serverConn) {
 	defer conn.Close()
+	defer s.untrackConn(conn)
 
 	for {
 		// Set a read deadline to avoid hanging forever
@@ -165,8 +412,8 @@ func (s *Server) handleConnection(conn net.Conn) {
 		// Read GIOP header (12 bytes)
 		headerBuf := make([]byte, 12)
 		if _, err := io.ReadFull(conn, headerBuf); err != nil {
-			if err == io.EOF {
-				// Client disconnected
+			if err == io.EOF || errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe) || s.isStopping() {
+				// Client disconnected or the server closed the connection
 				return
 			}
 			fmt.Printf("Error reading GIOP header: %v\n", err)
@@ -204,6 +451,9 @@ func (s *Server) handleConnection(conn net.Conn) {
 				fmt.Println("Invalid request message format")
 				continue
 			}
+			if !requestHeader.ResponseExpected {
+				conn.expectNoReply(requestHeader.RequestID)
+			}
 			// Process the request
 			s.handleGIOPRequest(conn, requestHeader)
 
@@ -230,8 +480,111 @@ func (s *Server) handleConnection(conn net.Conn) {
 	}
 }
 
-// handleGIOPRequest processes a GIOP request message
-func (s *Server) handleGIOPRequest(conn net.Conn, request *giop.RequestHeader) {
+// handleGIOPRequest processes a GIOP request message. Requests for POA objects
+// are subject to the state of the POA's manager, which may hold or reject them.
+// Admitted requests are dispatched on the ORB's worker pool, so that requests
+// multiplexed on one connection run concurrently.
+func (s *Server) handleGIOPRequest(conn *serverConn, request *giop.RequestHeader) {
+	if imr := s.implRepositoryFor(request.ObjectKey); imr != nil {
+		s.submitIMRRequest(conn, request.RequestID, func() { imr.serveRequest(s, conn, request) })
+		return
+	}
+
+	// Requests that arrive while the server shuts down are rejected with
+	// TRANSIENT so that the client can retry elsewhere. The others are in
+	// flight until their reply is sent.
+	if !s.beginRequest(conn) {
+		s.sendExceptionReply(conn, request.RequestID, TRANSIENT(MinorServerShuttingDown, CompletionStatusNo))
+		return
+	}
+
+	target := s.requestTarget(request.ObjectKey, false)
+	if target.ex == nil {
+		s.admitRequest(conn, request, target)
+		return
+	}
+
+	// Requests for a POA no adapter activator can create, or with a
+	// malformed key, are answered at once
+	if !s.orb.awaitsActivation(target.key) {
+		defer s.endRequest(conn)
+		s.dispatchRequest(conn, request, target)
+		return
+	}
+
+	// The POA may still have to be created by an adapter activator. That is
+	// done on the worker pool, off the connection's reader, and requests for
+	// a POA being created wait until the activator has finished.
+	s.orb.workers.submit(func() {
+		s.admitRequest(conn, request, s.requestTarget(request.ObjectKey, true))
+	})
+}
+
+// requestTarget is where a request is dispatched, as resolved once when the
+// request arrives
+type requestTarget struct {
+	key ObjectKey // Structured object key, for requests to POA objects
+	poa *POA      // POA the request is addressed to, or nil
+	ex  Exception // Why the POA of a POA-generated object key was not found
+}
+
+// requestTarget finds the POA addressed by a POA-generated object key. With
+// activate set, missing POAs are created by adapter activators. Other keys
+// have no POA.
+func (s *Server) requestTarget(objectKey []byte, activate bool) requestTarget {
+	if !IsPOAObjectKey(objectKey) {
+		return requestTarget{}
+	}
+	key, err := DecodeObjectKey(objectKey)
+	if err != nil {
+		return requestTarget{ex: OBJECT_NOT_EXIST(MinorMalformedObjectKey, CompletionStatusNo)}
+	}
+	poa, err := s.orb.findPOAForKey(key, activate)
+	if err != nil {
+		// Adapter activators that fail report a system exception
+		if sysEx, ok := err.(*SystemException); ok {
+			return requestTarget{key: key, ex: sysEx}
+		}
+		return requestTarget{key: key, ex: OBJECT_NOT_EXIST(MinorAdapterNotFound, CompletionStatusNo)}
+	}
+	return requestTarget{key: key, poa: poa}
+}
+
+// admitRequest passes a request to the manager of its target POA, which
+// dispatches, holds or rejects it. Requests for other objects are dispatched
+// directly.
+func (s *Server) admitRequest(conn *serverConn, request *giop.RequestHeader, target requestTarget) {
+	if target.poa == nil {
+		s.submitRequest(conn, request, target)
+		return
+	}
+
+	target.poa.manager.admit(func(ex Exception) {
+		if ex != nil {
+			s.sendExceptionReply(conn, request.RequestID, ex)
+			s.endRequest(conn)
+			return
+		}
+		s.submitRequest(conn, request, target)
+	})
+}
+
+// submitRequest queues an admitted request on the worker pool, through the run
+// queue of its POA if it has one
+func (s *Server) submitRequest(conn *serverConn, request *giop.RequestHeader, target requestTarget) {
+	job := func() {
+		defer s.endRequest(conn)
+		s.dispatchRequest(conn, request, target)
+	}
+	if target.poa == nil {
+		s.orb.workers.submit(job)
+		return
+	}
+	target.poa.schedule(job)
+}
+
+// dispatchRequest invokes the servant for a request and sends the reply
+func (s *Server) dispatchRequest(conn *serverConn, request *giop.RequestHeader, target requestTarget) {
 	// Convert object key to string
 	objectName := string(request.ObjectKey)
 
@@ -242,6 +595,11 @@ func (s *Server) handleGIOPRequest(conn net.Conn, request *giop.RequestHeader) {
 		RequestID:        request.RequestID,
 		ResponseExpected: request.ResponseExpected,
 		Arguments:        []interface{}{}, // Will be populated by unmarshalling if implemented
+		SyncScope:        SyncWithTarget,
+		slots:            make([]interface{}, s.orb.slotCount),
+	}
+	if !request.ResponseExpected {
+		reqInfo.SyncScope = SyncWithTransport
 	}
 
 	// Convert service contexts
@@ -252,93 +610,107 @@ func (s *Server) handleGIOPRequest(conn net.Conn, request *giop.RequestHeader) {
 		})
 	}
 
-	// Find the object in the ORB
-	obj, err := s.orb.ResolveObject(objectName)
-	if err != nil {
-		// Object not found, send a OBJECT_NOT_EXIST system exception
-		s.sendExceptionReply(conn, request.RequestID,
-			OBJECT_NOT_EXIST(1, CompletionStatusNo))
-		return
+	// Get server request interceptors
+	interceptors := s.orb.GetInterceptorRegistry().GetServerRequestInterceptors()
+
+	// Call server request interceptors - ReceiveRequestServiceContexts, before
+	// the servant is looked up
+	for _, interceptor := range interceptors {
+		if rsc, ok := interceptor.(ServiceContextsInterceptor); ok {
+			if err := rsc.ReceiveRequestServiceContexts(reqInfo); err != nil {
+				s.sendInterceptedException(conn, reqInfo, interceptors, err)
+				return
+			}
+		}
 	}
 
-	// Check if the object implements the Invoke method
-	invoker, ok := obj.(interface {
-		Dispatch(methodName string, args []interface{}) (interface{}, error)
-	})
-	if !ok {
-		// Object doesn't implement the Invoke method
-		s.sendExceptionReply(conn, request.RequestID,
-			OBJ_ADAPTER(1, CompletionStatusNo))
+	// Find the servant, through the POA for POA-generated keys
+	servant, current, postinvoke, ex := s.resolveServant(request.ObjectKey, request.Operation, target)
+	if ex != nil {
+		// An object known not to exist answers _non_existent
+		if request.Operation == opNonExistent && ex.Name() == "OBJECT_NOT_EXIST" {
+			s.sendSuccessReply(conn, request.RequestID, encodeBoolean(true))
+			return
+		}
+		// The interceptors see lookup failures as well. Servant managers
+		// redirect requests by raising ForwardRequest.
+		s.sendInterceptedException(conn, reqInfo, interceptors, ex)
 		return
 	}
 
 	// Store servant in request info
-	reqInfo.Servant = invoker
+	reqInfo.Servant = servant
+
+	// The context marks the dispatch, so that waits for the request's own
+	// completion can be refused. Servants dispatched through a POA can find
+	// out which object the request targets from it.
+	ctx := withDispatch(context.Background(), s.orb)
+	if current != nil {
+		ctx = withPOACurrent(ctx, current)
+	}
 
-	// Get server request interceptors
-	interceptors := s.orb.GetInterceptorRegistry().GetServerRequestInterceptors()
+	// Static servants receive the arguments their parameter types describe.
+	// Operations with a context clause receive the IDL Context that follows
+	// them in the body.
+	if _, dsi := servant.(DynamicImplementation); !dsi && !isObjectOperation(request.Operation) {
+		u := giop.NewCDRUnmarshaller(request.Body, bodyByteOrder(request.ByteOrder))
+		args, idlCtx, err := unmarshalRequestBody(servant, request.Operation, u)
+		if err != nil {
+			postinvoke()
+			s.sendInterceptedException(conn, reqInfo, interceptors, MARSHAL(MinorMalformedRequest, CompletionStatusNo))
+			return
+		}
+		if args != nil {
+			reqInfo.Arguments = args
+		}
+		if idlCtx != nil {
+			ctx = withIDLContext(ctx, idlCtx)
+		}
+	}
 
 	// Call server request interceptors - ReceiveRequest
 	for _, interceptor := range interceptors {
 		if err := interceptor.ReceiveRequest(reqInfo); err != nil {
-			if ex, ok := err.(Exception); ok {
-				// Call SendException on all interceptors
-				for _, i := range interceptors {
-					i.SendException(reqInfo, ex)
-				}
-				s.sendExceptionReply(conn, request.RequestID, ex)
-			} else {
-				// Convert generic error to CORBA system exception
-				sysEx := UNKNOWN(1, CompletionStatusNo)
-				// Call SendException on all interceptors
-				for _, i := range interceptors {
-					i.SendException(reqInfo, sysEx)
-				}
-				s.sendExceptionReply(conn, request.RequestID, sysEx)
-			}
+			postinvoke()
+			s.sendInterceptedException(conn, reqInfo, interceptors, err)
 			return
 		}
 	}
 
+	// The servant sees a copy of the slots set by the interceptors
+	ctx = WithPICurrent(ctx, &PICurrent{slots: append([]interface{}(nil), reqInfo.slots...)})
+
 	// Safely invoke the method and convert any errors to exceptions
 	result, ex := SafeInvoke(func() (interface{}, error) {
+		// The standard object operations are answered by the ORB
+		if isObjectOperation(request.Operation) {
+			return s.objectOperation(servant, request.Operation, request.Body, bodyByteOrder(request.ByteOrder))
+		}
+
+		// Dynamic servants decode the request body themselves
+		if dsi, ok := servant.(DynamicImplementation); ok {
+			return dispatchServerRequest(dsi, newWireServerRequest(ctx, request))
+		}
+
 		// In a real implementation, we would extract arguments from the request body
 		// For now, we just call the method without arguments or with arguments from reqInfo if modified by interceptors
-		return invoker.Dispatch(request.Operation, reqInfo.Arguments)
+		return dispatchServant(ctx, servant, request.Operation, reqInfo.Arguments)
 	})
+	postinvoke()
 
-	// Store result and exception in request info
+	// Store result in request info
 	reqInfo.Result = result
-	reqInfo.Exception = ex
 
 	if ex != nil {
 		// Method invocation failed with an exception
-		// Call server request interceptors - SendException
-		for _, interceptor := range interceptors {
-			interceptor.SendException(reqInfo, ex)
-		}
-		s.sendExceptionReply(conn, request.RequestID, ex)
+		s.sendInterceptedException(conn, reqInfo, interceptors, ex)
 		return
 	}
 
 	// Call server request interceptors - SendReply
 	for _, interceptor := range interceptors {
 		if err := interceptor.SendReply(reqInfo); err != nil {
-			if ex, ok := err.(Exception); ok {
-				// Call SendException on all interceptors
-				for _, i := range interceptors {
-					i.SendException(reqInfo, ex)
-				}
-				s.sendExceptionReply(conn, request.RequestID, ex)
-			} else {
-				// Convert generic error to CORBA system exception
-				sysEx := UNKNOWN(1, CompletionStatusNo)
-				// Call SendException on all interceptors
-				for _, i := range interceptors {
-					i.SendException(reqInfo, sysEx)
-				}
-				s.sendExceptionReply(conn, request.RequestID, sysEx)
-			}
+			s.sendInterceptedException(conn, reqInfo, interceptors, err)
 			return
 		}
 	}
@@ -347,25 +719,126 @@ func (s *Server) handleGIOPRequest(conn net.Conn, request *giop.RequestHeader) {
 	s.sendSuccessReply(conn, request.RequestID, reqInfo.Result)
 }
 
+// sendInterceptedException replies to a request with the exception raised by
+// its servant or an interceptor, after passing it to the SendException point
+// of the interceptors. Errors that are not CORBA exceptions are sent as
+// UNKNOWN, and a ForwardRequest is answered with a LOCATION_FORWARD reply.
+func (s *Server) sendInterceptedException(conn *serverConn, reqInfo *RequestInfo, interceptors []ServerRequestInterceptor, err error) {
+	var forward *ForwardRequest
+	if errors.As(err, &forward) {
+		s.sendInterceptedForward(conn, reqInfo, interceptors, forward)
+		return
+	}
+
+	ex := ThrowableToException(err)
+	reqInfo.Exception = ex
+	reqInfo.ReplyStatus = replyStatusOf(ex)
+
+	// Call server request interceptors - SendException
+	for _, interceptor := range interceptors {
+		interceptor.SendException(reqInfo, ex)
+	}
+	s.sendExceptionReply(conn, reqInfo.RequestID, ex)
+}
+
 // handleGIOPLocateRequest processes a GIOP locate request message
-func (s *Server) handleGIOPLocateRequest(conn net.Conn, request *giop.LocateRequestHeader) {
-	// Convert object key to string
-	objectName := string(request.ObjectKey)
+func (s *Server) handleGIOPLocateRequest(conn *serverConn, request *giop.LocateRequestHeader) {
+	if imr := s.implRepositoryFor(request.ObjectKey); imr != nil {
+		s.submitIMRRequest(conn, request.RequestID, func() { imr.serveLocate(s, conn, request) })
+		return
+	}
 
-	// Check if the object exists in the ORB
-	_, err := s.orb.ResolveObject(objectName)
-	if err != nil {
+	target := s.requestTarget(request.ObjectKey, false)
+	if target.ex == nil || !s.orb.awaitsActivation(target.key) {
+		s.replyLocate(conn, request, target)
+		return
+	}
+
+	// Locating the object involves an adapter activator, which runs on the
+	// worker pool
+	if !s.beginRequest(conn) {
+		data, _ := MarshalException(TRANSIENT(MinorServerShuttingDown, CompletionStatusNo))
+		s.sendLocateReply(conn, request.RequestID, giop.LocateStatusLOC_SYSTEM_EXCEPTION, data)
+		return
+	}
+	s.orb.workers.submit(func() {
+		defer s.endRequest(conn)
+		s.replyLocate(conn, request, s.requestTarget(request.ObjectKey, true))
+	})
+}
+
+// replyLocate answers a locate request with whether the object is here
+func (s *Server) replyLocate(conn *serverConn, request *giop.LocateRequestHeader, target requestTarget) {
+	if !s.locateObject(request.ObjectKey, target) {
 		// Object not found
-		s.sendLocateReply(conn, request.RequestID, giop.LocateStatusUnknownObject)
+		s.sendLocateReply(conn, request.RequestID, giop.LocateStatusUnknownObject, nil)
 		return
 	}
 
 	// Object exists
-	s.sendLocateReply(conn, request.RequestID, giop.LocateStatusObjectHere)
+	s.sendLocateReply(conn, request.RequestID, giop.LocateStatusObjectHere, nil)
+}
+
+// implRepositoryFor returns the implementation repository hosted by the server
+// if it answers requests for the object key, or nil
+func (s *Server) implRepositoryFor(objectKey []byte) *ImplRepository {
+	s.mu.RLock()
+	imr := s.implRepository
+	s.mu.RUnlock()
+
+	if imr == nil || !imr.handles(objectKey) {
+		return nil
+	}
+	return imr
+}
+
+// resolveServant finds the servant for an object key. Keys generated by a POA
+// are dispatched through the POA of the request's target and come with the
+// POA Current of the request; any other key names an object registered
+// directly with the ORB. The returned postinvoke function must be called once
+// the operation has completed.
+func (s *Server) resolveServant(objectKey []byte, operation string, target requestTarget) (interface{}, *POACurrent, func(), Exception) {
+	if target.ex != nil {
+		return nil, nil, nil, target.ex
+	}
+	if target.poa != nil {
+		return resolvePOAServant(target.poa, target.key, operation)
+	}
+
+	obj, err := s.orb.ResolveObject(string(objectKey))
+	if err != nil {
+		// References returned by _interface denote Interface Repository entries
+		if def := s.orb.interfaceDefServant(objectKey); def != nil {
+			return def, nil, func() {}, nil
+		}
+		// Object not found, send a OBJECT_NOT_EXIST system exception
+		return nil, nil, nil, OBJECT_NOT_EXIST(MinorUnknownObjectKey, CompletionStatusNo)
+	}
+
+	// Check if the object implements the Dispatch method
+	if !isDispatchable(obj) {
+		return nil, nil, nil, OBJ_ADAPTER(MinorServantNotDispatchable, CompletionStatusNo)
+	}
+
+	return obj, nil, func() {}, nil
+}
+
+// locateObject reports whether the server can dispatch requests for an object
+// key, whose POA, if any, has been resolved into target
+func (s *Server) locateObject(objectKey []byte, target requestTarget) bool {
+	if target.ex != nil {
+		return false
+	}
+	if target.poa != nil {
+		return target.poa.locate(target.key.ObjectID)
+	}
+
+	_, err := s.orb.ResolveObject(string(objectKey))
+	return err == nil
 }
 
 // sendSuccessReply sends a successful reply message
-func (s *Server) sendSuccessReply(conn net.Conn, requestID uint32, _ interface{}) {
+func (s *Server) sendSuccessReply(conn *serverConn, requestID uint32, result interface{}) {
 	// Create reply header
 	replyHeader := &giop.ReplyHeader{
 		ServiceContexts: make(giop.ServiceContextList, 0),
@@ -379,8 +852,11 @@ func (s *Server) sendSuccessReply(conn net.Conn, requestID uint32, _ interface{}
 		Body:   replyHeader,
 	}
 
-	// In a real implementation, we would marshal the result here
-	// For now, we'll leave it as a placeholder
+	// Results already encoded by the ORB are sent as they are. In a real
+	// implementation, we would marshal servant results here as well
+	if body, ok := result.(encodedResult); ok {
+		replyHeader.Body = body
+	}
 
 	// Marshal the message
 	data, err := giop.MarshalGIOPMessage(replyMsg)
@@ -390,13 +866,13 @@ func (s *Server) sendSuccessReply(conn net.Conn, requestID uint32, _ interface{}
 	}
 
 	// Send the reply
-	if _, err := conn.Write(data); err != nil {
+	if err := conn.writeReply(requestID, data); err != nil {
 		fmt.Printf("Error sending reply: %v\n", err)
 	}
 }
 
 // sendExceptionReply sends an exception reply
-func (s *Server) sendExceptionReply(conn net.Conn, requestID uint32, ex Exception) {
+func (s *Server) sendExceptionReply(conn *serverConn, requestID uint32, ex Exception) {
 	// Create reply header with appropriate reply status
 	var replyStatus uint32
 	if IsSystemException(ex) {
@@ -408,25 +884,21 @@ func (s *Server) sendExceptionReply(conn net.Conn, requestID uint32, ex Exceptio
 		replyStatus = giop.ReplyStatusSystemException
 	}
 
-	replyHeader := &giop.ReplyHeader{
-		ServiceContexts: make(giop.ServiceContextList, 0),
-		RequestID:       requestID,
-		ReplyStatus:     replyStatus,
-	}
-
-	// Marshal the exception
+	// Marshal the exception into the reply body
 	exData, err := MarshalException(ex)
 	if err != nil {
 		fmt.Printf("Error marshalling exception: %v\n", err)
-		// Fall back to a simple error message
-		exData = []byte(ex.Error())
+		// Fall back to a system exception, which always marshals
+		replyStatus = giop.ReplyStatusSystemException
+		exData, _ = MarshalException(MARSHAL(MinorUnmarshallableException, CompletionStatusMaybe))
 	}
 
-	// Add exception info to service context
-	replyHeader.ServiceContexts = append(replyHeader.ServiceContexts, giop.ServiceContext{
-		ID:   0x45584350, // "EXCP"
-		Data: exData,
-	})
+	replyHeader := &giop.ReplyHeader{
+		ServiceContexts: make(giop.ServiceContextList, 0),
+		RequestID:       requestID,
+		ReplyStatus:     replyStatus,
+		Body:            exData,
+	}
 
 	// Create a reply message
 	replyMsg := &giop.Message{
@@ -442,17 +914,70 @@ func (s *Server) sendExceptionReply(conn net.Conn, requestID uint32, ex Exceptio
 	}
 
 	// Send the reply
-	if _, err := conn.Write(data); err != nil {
+	if err := conn.writeReply(requestID, data); err != nil {
 		fmt.Printf("Error sending exception reply: %v\n", err)
 	}
 }
 
-// sendLocateReply sends a locate reply
-func (s *Server) sendLocateReply(conn net.Conn, requestID uint32, status uint32) {
+// sendInterceptedForward answers a request with a LOCATION_FORWARD reply to
+// the reference carried by a ForwardRequest, after passing it to the SendOther
+// point of the interceptors. An interceptor raising an exception there
+// replaces the reply: the remaining interceptors see the exception, or the
+// new forward reference if it raised another ForwardRequest.
+func (s *Server) sendInterceptedForward(conn *serverConn, reqInfo *RequestInfo, interceptors []ServerRequestInterceptor, forward *ForwardRequest) {
+	if forward.Forward.IsNil() {
+		s.sendInterceptedException(conn, reqInfo, interceptors, OBJECT_NOT_EXIST(MinorNilReference, CompletionStatusNo))
+		return
+	}
+	reqInfo.ReplyStatus = ReplyLocationForward
+	reqInfo.ForwardReference = forward.Forward
+
+	// Call server request interceptors - SendOther
+	for i, interceptor := range interceptors {
+		if other, ok := interceptor.(SendOtherInterceptor); ok {
+			if err := other.SendOther(reqInfo); err != nil {
+				s.sendInterceptedException(conn, reqInfo, interceptors[i+1:], err)
+				return
+			}
+		}
+	}
+	s.sendForwardReply(conn, reqInfo.RequestID, forward.Forward.toIOR())
+}
+
+// sendForwardReply sends a LOCATION_FORWARD reply carrying the reference the
+// client should retry the request at
+func (s *Server) sendForwardReply(conn *serverConn, requestID uint32, ior *IOR) {
+	replyMsg := &giop.Message{
+		Header: giop.NewMessageHeader(giop.MsgReply, 0), // Size will be set during marshalling
+		Body: &giop.ReplyHeader{
+			ServiceContexts: make(giop.ServiceContextList, 0),
+			RequestID:       requestID,
+			ReplyStatus:     giop.ReplyStatusLocationForward,
+			Body:            ior.Encode(),
+		},
+	}
+
+	// Marshal the message
+	data, err := giop.MarshalGIOPMessage(replyMsg)
+	if err != nil {
+		fmt.Printf("Error marshalling forward reply: %v\n", err)
+		return
+	}
+
+	// Send the reply
+	if err := conn.writeReply(requestID, data); err != nil {
+		fmt.Printf("Error sending forward reply: %v\n", err)
+	}
+}
+
+// sendLocateReply sends a locate reply. The body carries the forward IOR for
+// OBJECT_FORWARD and the exception for LOC_SYSTEM_EXCEPTION.
+func (s *Server) sendLocateReply(conn *serverConn, requestID uint32, status uint32, body []byte) {
 	// Create locate reply header
 	locateHeader := &giop.LocateReplyHeader{
 		RequestID: requestID,
 		Status:    status,
+		Body:      body,
 	}
 
 	// Create a locate reply message
@@ -469,7 +994,7 @@ func (s *Server) sendLocateReply(conn net.Conn, requestID uint32, status uint32)
 	}
 
 	// Send the reply
-	if _, err := conn.Write(data); err != nil {
+	if err := conn.writeMessage(data); err != nil {
 		fmt.Printf("Error sending locate reply: %v\n", err)
 	}
 }
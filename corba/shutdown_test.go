@@ -0,0 +1,261 @@
+package corba_test
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// shutdownFixture serves a concurrencyServant on an in-memory endpoint and
+// returns the server, a raw connection to it and the servant's object key
+func shutdownFixture(t *testing.T, orb *corba.ORB, name string) (*corba.Server, net.Conn, []byte, *concurrencyServant) {
+	t.Helper()
+
+	ep := corba.NewMemEndpoint(name)
+	server, err := orb.CreateServerOn(ep)
+	if err != nil {
+		t.Fatalf("CreateServerOn: %v", err)
+	}
+	if err := server.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	servant := newConcurrencyServant()
+	oid, err := orb.GetRootPOA().ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	key := orb.GetRootPOA().CreateReferenceWithId(oid, "IDL:Test/Echo:1.0").GetObjectKey()
+
+	conn, err := corba.NewMemTransport().Connect(ep)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return server, conn, key, servant
+}
+
+// shutdownAsync runs ShutdownContext in the background
+func shutdownAsync(server *corba.Server, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- server.ShutdownContext(ctx) }()
+	return done
+}
+
+func TestShutdownClosesIdleConnections(t *testing.T) {
+	server, conn, _, _ := shutdownFixture(t, corba.Init(), "shutdown-idle")
+
+	done := shutdownAsync(server, context.Background())
+	if msg := readRawMessage(t, conn); msg.Header.MsgType != giop.MsgCloseConn {
+		t.Fatalf("idle client received message type %d, want CloseConnection", msg.Header.MsgType)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ShutdownContext: %v", err)
+	}
+	if server.IsRunning() {
+		t.Error("server still running after shutdown")
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	server, conn, key, servant := shutdownFixture(t, corba.Init(), "shutdown-drain")
+
+	sendRawRequest(t, conn, 1, key, "block")
+	<-servant.started
+
+	done := shutdownAsync(server, context.Background())
+	for server.IsRunning() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// New requests on the busy connection are turned away
+	sendRawRequest(t, conn, 2, key, "ping")
+	if reply := readRawReply(t, conn); reply.RequestID != 2 || reply.ReplyStatus != giop.ReplyStatusSystemException {
+		t.Fatalf("request during shutdown got reply %d with status %d", reply.RequestID, reply.ReplyStatus)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown returned before the in-flight request completed: %v", err)
+	default:
+	}
+
+	// The in-flight request completes, then the connection is closed
+	close(servant.gate)
+	if reply := readRawReply(t, conn); reply.RequestID != 1 || reply.ReplyStatus != giop.ReplyStatusNoException {
+		t.Fatalf("in-flight request got reply %d with status %d", reply.RequestID, reply.ReplyStatus)
+	}
+	if msg := readRawMessage(t, conn); msg.Header.MsgType != giop.MsgCloseConn {
+		t.Fatalf("received message type %d, want CloseConnection", msg.Header.MsgType)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ShutdownContext: %v", err)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	server, conn, key, servant := shutdownFixture(t, corba.Init(), "shutdown-deadline")
+	defer close(servant.gate)
+
+	sendRawRequest(t, conn, 1, key, "block")
+	<-servant.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := server.ShutdownContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("ShutdownContext = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestORBShutdownDeadlineKeepsPOAs(t *testing.T) {
+	orb := corba.Init()
+	_, conn, key, servant := shutdownFixture(t, orb, "orb-shutdown-deadline")
+
+	root := orb.GetRootPOA()
+	sendRawRequest(t, conn, 1, key, "block")
+	<-servant.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := orb.ShutdownContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("ShutdownContext = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The request that did not drain still has its POA
+	if orb.GetRootPOA() != root || !orb.IsInitialized() {
+		t.Fatal("POAs destroyed before the requests drained")
+	}
+	close(servant.gate)
+
+	if err := orb.ShutdownContext(context.Background()); err != nil {
+		t.Fatalf("second ShutdownContext: %v", err)
+	}
+	if orb.IsInitialized() {
+		t.Error("ORB still initialized after shutdown")
+	}
+}
+
+func TestORBShutdownEtherealizesAndReleasesRun(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, "orb-shutdown")
+
+	poa, err := orb.GetRootPOA().CreatePOA("Activated", nil, []corba.POAPolicy{
+		corba.NewRequestProcessingPolicy(corba.UseServantManager),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	etherealized := make(chan string, 1)
+	poa.SetServantManager(&corba.BasicServantActivator{
+		IncarnateFunc: func(oid corba.ObjectID, poa *corba.POA) (interface{}, error) {
+			return &recordingServant{calls: make(chan string, 1)}, nil
+		},
+		EtherealizeFUnc: func(oid corba.ObjectID, poa *corba.POA, servant interface{}, cleanup, remaining bool) error {
+			etherealized <- string(oid)
+			return nil
+		},
+	})
+	ref := poa.CreateReferenceWithId(corba.ObjectID("lazy"), "IDL:Test/Echo:1.0")
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+
+	running := make(chan struct{})
+	go func() {
+		orb.Run()
+		close(running)
+	}()
+
+	orb.Shutdown(true)
+	if oid := <-etherealized; oid != "lazy" {
+		t.Errorf("etherealized %q, want %q", oid, "lazy")
+	}
+	select {
+	case <-running:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after shutdown")
+	}
+	if orb.IsInitialized() || orb.WorkPending() {
+		t.Error("ORB still initialized or has pending work after shutdown")
+	}
+
+	if err := orb.Destroy(); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	if err := orb.Destroy(); err == nil {
+		t.Error("second Destroy succeeded")
+	}
+}
+
+// shutdownServant shuts its ORB down from within a request
+type shutdownServant struct {
+	orb  *corba.ORB
+	errs chan error
+}
+
+func (s *shutdownServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return s.DispatchContext(context.Background(), methodName, args)
+}
+
+func (s *shutdownServant) DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error) {
+	s.errs <- s.orb.ShutdownContext(ctx)
+	return nil, nil
+}
+
+func TestShutdownFromServantWouldDeadlock(t *testing.T) {
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	servant := &shutdownServant{orb: orb, errs: make(chan error, 1)}
+	ref, err := orb.GetRootPOA().ServantToReference(servant)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	if err := invokeRef(t, client, ep, ref, "stop"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	wantMinorCode(t, <-servant.errs, "BAD_INV_ORDER", corba.MinorWouldDeadlock)
+
+	// Servants registered without a POA are refused as well
+	if err := orb.RegisterObject("stopper", servant); err != nil {
+		t.Fatalf("RegisterObject: %v", err)
+	}
+	target, err := client.GetObjectAt("stopper", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+	if _, err := target.Invoke("stop"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	wantMinorCode(t, <-servant.errs, "BAD_INV_ORDER", corba.MinorWouldDeadlock)
+}
+
+func TestDestroyStopsWorkers(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	orb := corba.Init()
+	client, ep := poaTestServer(t, orb, t.Name())
+	ref, err := orb.GetRootPOA().ServantToReference(newConcurrencyServant())
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+	if err := invokeRef(t, client, ep, ref, "ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if err := orb.Destroy(); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+
+	// The connection goroutines wind down shortly after the workers
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before+4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before+4 {
+		t.Errorf("%d goroutines left after Destroy, %d before the ORB was created", n, before)
+	}
+}
@@ -0,0 +1,547 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Transport protocol names
+const (
+	TransportTCP  = "iiop"   // Plain IIOP over TCP
+	TransportTLS  = "ssliop" // IIOP over TLS
+	TransportUnix = "uiop"   // IIOP over Unix domain sockets
+	TransportMem  = "mem"    // In-process pipe transport
+)
+
+// Endpoint identifies an address that a transport can listen on or connect to
+type Endpoint struct {
+	Protocol string // One of the Transport* protocol names
+	Host     string // Host name or address (iiop, ssliop)
+	Port     int    // Port number (iiop, ssliop)
+	Path     string // Socket path (uiop) or pipe name (mem)
+}
+
+// NewTCPEndpoint creates an endpoint for plain IIOP over TCP
+func NewTCPEndpoint(host string, port int) Endpoint {
+	return Endpoint{Protocol: TransportTCP, Host: host, Port: port}
+}
+
+// NewTLSEndpoint creates an endpoint for IIOP over TLS
+func NewTLSEndpoint(host string, port int) Endpoint {
+	return Endpoint{Protocol: TransportTLS, Host: host, Port: port}
+}
+
+// NewUnixEndpoint creates an endpoint for IIOP over a Unix domain socket
+func NewUnixEndpoint(path string) Endpoint {
+	return Endpoint{Protocol: TransportUnix, Path: path}
+}
+
+// NewMemEndpoint creates an endpoint for the in-memory pipe transport
+func NewMemEndpoint(name string) Endpoint {
+	return Endpoint{Protocol: TransportMem, Path: name}
+}
+
+// Address returns the address used to dial or listen on the endpoint
+func (e Endpoint) Address() string {
+	switch e.Protocol {
+	case TransportUnix, TransportMem:
+		return e.Path
+	default:
+		return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	}
+}
+
+// String returns the URL form of the endpoint, e.g. "iiop://host:port" or "uiop:///tmp/orb.sock"
+func (e Endpoint) String() string {
+	return e.Protocol + "://" + e.Address()
+}
+
+// IsZero reports whether the endpoint is unset
+func (e Endpoint) IsZero() bool {
+	return e == Endpoint{}
+}
+
+// ParseEndpoint parses the URL form produced by Endpoint.String
+func ParseEndpoint(s string) (Endpoint, error) {
+	protocol, address, ok := strings.Cut(s, "://")
+	if !ok {
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: missing protocol", s)
+	}
+
+	switch protocol {
+	case TransportUnix, TransportMem:
+		if address == "" {
+			return Endpoint{}, fmt.Errorf("invalid endpoint %q: missing path", s)
+		}
+		return Endpoint{Protocol: protocol, Path: address}, nil
+	case TransportTCP, TransportTLS:
+		host, portStr, err := net.SplitHostPort(address)
+		if err != nil {
+			return Endpoint{}, fmt.Errorf("invalid endpoint %q: %w", s, err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil || port < 0 || port > 65535 {
+			return Endpoint{}, fmt.Errorf("invalid endpoint %q: bad port", s)
+		}
+		return Endpoint{Protocol: protocol, Host: host, Port: port}, nil
+	default:
+		return Endpoint{}, fmt.Errorf("invalid endpoint %q: unknown protocol %s", s, protocol)
+	}
+}
+
+// Connector establishes client-side connections to an endpoint
+type Connector interface {
+	Connect(ep Endpoint) (net.Conn, error)
+}
+
+// Acceptor creates server-side listeners for an endpoint
+type Acceptor interface {
+	Listen(ep Endpoint) (net.Listener, error)
+}
+
+// Transport is a pluggable GIOP transport. Besides connecting and listening it
+// knows how to describe its endpoints in an IOR profile and how to recognise
+// profiles it can connect to.
+type Transport interface {
+	Connector
+	Acceptor
+
+	// Protocol returns the protocol name used in endpoints
+	Protocol() string
+
+	// CreateProfile builds the IOR profile advertising ep for the given object key
+	CreateProfile(ep Endpoint, objectKey []byte) TaggedProfile
+
+	// ParseProfile extracts the endpoint and object key from a profile, if the
+	// profile describes an endpoint of this transport
+	ParseProfile(profile TaggedProfile) (Endpoint, []byte, bool)
+}
+
+// TCPTransport implements plain IIOP over TCP
+type TCPTransport struct{}
+
+// NewTCPTransport creates a new TCP transport
+func NewTCPTransport() *TCPTransport {
+	return &TCPTransport{}
+}
+
+// Protocol returns the protocol name of the transport
+func (t *TCPTransport) Protocol() string {
+	return TransportTCP
+}
+
+// Connect dials the endpoint over TCP
+func (t *TCPTransport) Connect(ep Endpoint) (net.Conn, error) {
+	return net.Dial("tcp", ep.Address())
+}
+
+// Listen starts a TCP listener on the endpoint
+func (t *TCPTransport) Listen(ep Endpoint) (net.Listener, error) {
+	return net.Listen("tcp", ep.Address())
+}
+
+// CreateProfile builds a TAG_INTERNET_IOP profile for the endpoint
+func (t *TCPTransport) CreateProfile(ep Endpoint, objectKey []byte) TaggedProfile {
+	return createIIOPProfile(IIOP_1_2, ep.Host, uint16(ep.Port), objectKey, nil)
+}
+
+// ParseProfile recognises TAG_INTERNET_IOP profiles with a usable clear-text port
+func (t *TCPTransport) ParseProfile(profile TaggedProfile) (Endpoint, []byte, bool) {
+	if profile.Tag != TAG_INTERNET_IOP {
+		return Endpoint{}, nil, false
+	}
+	body, err := DecodeIIOPProfile(profile.Profile)
+	if err != nil {
+		return Endpoint{}, nil, false
+	}
+	// A zero port with an SSL component means the object is only reachable over TLS
+	if body.Port == 0 {
+		return Endpoint{}, nil, false
+	}
+	return NewTCPEndpoint(body.Host, int(body.Port)), body.ObjectKey, true
+}
+
+// TLSTransport implements IIOP over TLS
+type TLSTransport struct {
+	config *tls.Config
+}
+
+// NewTLSTransport creates a new TLS transport with the given configuration
+func NewTLSTransport(config *tls.Config) *TLSTransport {
+	return &TLSTransport{config: config}
+}
+
+// Protocol returns the protocol name of the transport
+func (t *TLSTransport) Protocol() string {
+	return TransportTLS
+}
+
+// Config returns the TLS configuration of the transport
+func (t *TLSTransport) Config() *tls.Config {
+	return t.config
+}
+
+// Connect dials the endpoint over TLS
+func (t *TLSTransport) Connect(ep Endpoint) (net.Conn, error) {
+	return tls.Dial("tcp", ep.Address(), t.config)
+}
+
+// Listen starts a TLS listener on the endpoint
+func (t *TLSTransport) Listen(ep Endpoint) (net.Listener, error) {
+	return tls.Listen("tcp", ep.Address(), t.config)
+}
+
+// CreateProfile builds a TAG_INTERNET_IOP profile whose clear-text port is zero and
+// whose TAG_SSL_SEC_TRANS component carries the TLS port
+func (t *TLSTransport) CreateProfile(ep Endpoint, objectKey []byte) TaggedProfile {
+	ssl := &SSLData{
+		TargetSupports: SSL_OPTION_INTEGRITY | SSL_OPTION_CONFIDENTIAL | SSL_OPTION_SERVER_AUTH,
+		TargetRequires: SSL_OPTION_INTEGRITY | SSL_OPTION_CONFIDENTIAL,
+		Port:           uint16(ep.Port),
+	}
+	if t.config != nil && t.config.ClientAuth >= tls.VerifyClientCertIfGiven {
+		ssl.TargetSupports |= SSL_OPTION_CLIENT_AUTH
+		if t.config.ClientAuth == tls.RequireAndVerifyClientCert {
+			ssl.TargetRequires |= SSL_OPTION_CLIENT_AUTH
+		}
+	}
+
+	components := []TaggedComponent{CreateTaggedComponent(TAG_SSL_SEC_TRANS, ssl)}
+	return createIIOPProfile(IIOP_1_2, ep.Host, 0, objectKey, components)
+}
+
+// ParseProfile recognises TAG_INTERNET_IOP profiles carrying a TAG_SSL_SEC_TRANS component
+func (t *TLSTransport) ParseProfile(profile TaggedProfile) (Endpoint, []byte, bool) {
+	if profile.Tag != TAG_INTERNET_IOP {
+		return Endpoint{}, nil, false
+	}
+	body, err := DecodeIIOPProfile(profile.Profile)
+	if err != nil {
+		return Endpoint{}, nil, false
+	}
+	ssl, err := body.GetSSLData()
+	if err != nil || ssl.Port == 0 {
+		return Endpoint{}, nil, false
+	}
+	return NewTLSEndpoint(body.Host, int(ssl.Port)), body.ObjectKey, true
+}
+
+// UnixTransport implements IIOP over Unix domain sockets
+type UnixTransport struct{}
+
+// NewUnixTransport creates a new Unix domain socket transport
+func NewUnixTransport() *UnixTransport {
+	return &UnixTransport{}
+}
+
+// Protocol returns the protocol name of the transport
+func (t *UnixTransport) Protocol() string {
+	return TransportUnix
+}
+
+// Connect dials the socket path of the endpoint
+func (t *UnixTransport) Connect(ep Endpoint) (net.Conn, error) {
+	return net.Dial("unix", ep.Path)
+}
+
+// Listen listens on the socket path of the endpoint, removing a stale socket file first
+func (t *UnixTransport) Listen(ep Endpoint) (net.Listener, error) {
+	if info, err := os.Stat(ep.Path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", ep.Path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is already in use", ep.Path)
+		}
+		os.Remove(ep.Path)
+	}
+
+	listener, err := net.Listen("unix", ep.Path)
+	if err != nil {
+		return nil, err
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(true)
+	return listener, nil
+}
+
+// CreateProfile builds a TAG_UIOP profile for the endpoint
+func (t *UnixTransport) CreateProfile(ep Endpoint, objectKey []byte) TaggedProfile {
+	return createPathProfile(TAG_UIOP, IIOP_1_2, ep.Path, objectKey)
+}
+
+// ParseProfile recognises TAG_UIOP profiles
+func (t *UnixTransport) ParseProfile(profile TaggedProfile) (Endpoint, []byte, bool) {
+	if profile.Tag != TAG_UIOP {
+		return Endpoint{}, nil, false
+	}
+	path, objectKey, err := decodePathProfile(profile.Profile)
+	if err != nil {
+		return Endpoint{}, nil, false
+	}
+	return NewUnixEndpoint(path), objectKey, true
+}
+
+// MemTransport implements an in-process transport based on net.Pipe. Listeners
+// are registered by name for the lifetime of the process, so a server and a
+// client in the same process can talk without opening any ports.
+type MemTransport struct{}
+
+// NewMemTransport creates a new in-memory transport
+func NewMemTransport() *MemTransport {
+	return &MemTransport{}
+}
+
+// Protocol returns the protocol name of the transport
+func (t *MemTransport) Protocol() string {
+	return TransportMem
+}
+
+// Connect creates a pipe to the named in-memory listener
+func (t *MemTransport) Connect(ep Endpoint) (net.Conn, error) {
+	memListenersMu.RLock()
+	listener, exists := memListeners[ep.Path]
+	memListenersMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no in-memory listener named %s", ep.Path)
+	}
+
+	client, server := net.Pipe()
+	select {
+	case listener.conns <- server:
+		return client, nil
+	case <-listener.done:
+		client.Close()
+		server.Close()
+		return nil, fmt.Errorf("in-memory listener %s is closed", ep.Path)
+	}
+}
+
+// Listen registers a named in-memory listener
+func (t *MemTransport) Listen(ep Endpoint) (net.Listener, error) {
+	memListenersMu.Lock()
+	defer memListenersMu.Unlock()
+
+	if _, exists := memListeners[ep.Path]; exists {
+		return nil, fmt.Errorf("in-memory listener %s already exists", ep.Path)
+	}
+
+	listener := &memListener{
+		name:  ep.Path,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	memListeners[ep.Path] = listener
+	return listener, nil
+}
+
+// CreateProfile builds a TAG_MEM_IOP profile for the endpoint
+func (t *MemTransport) CreateProfile(ep Endpoint, objectKey []byte) TaggedProfile {
+	return createPathProfile(TAG_MEM_IOP, IIOP_1_2, ep.Path, objectKey)
+}
+
+// ParseProfile recognises TAG_MEM_IOP profiles
+func (t *MemTransport) ParseProfile(profile TaggedProfile) (Endpoint, []byte, bool) {
+	if profile.Tag != TAG_MEM_IOP {
+		return Endpoint{}, nil, false
+	}
+	name, objectKey, err := decodePathProfile(profile.Profile)
+	if err != nil {
+		return Endpoint{}, nil, false
+	}
+	return NewMemEndpoint(name), objectKey, true
+}
+
+// Registry of in-memory listeners shared by all ORBs in the process
+var (
+	memListeners   = make(map[string]*memListener)
+	memListenersMu sync.RWMutex
+)
+
+// memListener is the net.Listener returned by MemTransport.Listen
+type memListener struct {
+	name      string
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for the next in-memory connection
+func (l *memListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close unregisters the listener
+func (l *memListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+
+		memListenersMu.Lock()
+		if memListeners[l.name] == l {
+			delete(memListeners, l.name)
+		}
+		memListenersMu.Unlock()
+	})
+	return nil
+}
+
+// Addr returns the listener's address
+func (l *memListener) Addr() net.Addr {
+	return memAddr(l.name)
+}
+
+// memAddr is the net.Addr of an in-memory listener
+type memAddr string
+
+// Network returns the network name
+func (a memAddr) Network() string {
+	return TransportMem
+}
+
+// String returns the listener name
+func (a memAddr) String() string {
+	return string(a)
+}
+
+// createPathProfile creates a profile for transports addressed by a path or name
+// rather than host and port. The layout mirrors the IIOP profile: version,
+// rendezvous point, object key and an empty component list.
+func createPathProfile(tag uint32, version IIOPVersion, path string, objectKey []byte) TaggedProfile {
+	buf := make([]byte, 0, 2+4+len(path)+4+len(objectKey)+4)
+
+	// Version
+	buf = append(buf, version.Major, version.Minor)
+
+	// Rendezvous point
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(path)))
+	buf = append(buf, path...)
+
+	// Object key
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(objectKey)))
+	buf = append(buf, objectKey...)
+
+	// Components (none)
+	buf = binary.BigEndian.AppendUint32(buf, 0)
+
+	return TaggedProfile{
+		Tag:     tag,
+		Profile: buf,
+	}
+}
+
+// decodePathProfile decodes a profile created by createPathProfile
+func decodePathProfile(profile []byte) (string, []byte, error) {
+	if len(profile) < 10 { // Need at least version (2), path length (4), key length (4)
+		return "", nil, fmt.Errorf("profile data too short")
+	}
+	pos := 2
+
+	pathLen := int(binary.BigEndian.Uint32(profile[pos : pos+4]))
+	pos += 4
+	if pos+pathLen+4 > len(profile) {
+		return "", nil, fmt.Errorf("invalid rendezvous point length")
+	}
+	path := string(profile[pos : pos+pathLen])
+	pos += pathLen
+
+	keyLen := int(binary.BigEndian.Uint32(profile[pos : pos+4]))
+	pos += 4
+	if pos+keyLen > len(profile) {
+		return "", nil, fmt.Errorf("invalid object key length")
+	}
+	objectKey := make([]byte, keyLen)
+	copy(objectKey, profile[pos:pos+keyLen])
+
+	return path, objectKey, nil
+}
+
+// RegisterTransport registers a transport with the ORB, replacing any transport
+// registered for the same protocol
+func (orb *ORB) RegisterTransport(transport Transport) {
+	orb.mu.Lock()
+	defer orb.mu.Unlock()
+
+	protocol := transport.Protocol()
+	if _, exists := orb.transports[protocol]; !exists {
+		orb.transportOrder = append(orb.transportOrder, protocol)
+	}
+	orb.transports[protocol] = transport
+}
+
+// GetTransport returns the transport registered for a protocol
+func (orb *ORB) GetTransport(protocol string) (Transport, error) {
+	orb.mu.RLock()
+	defer orb.mu.RUnlock()
+
+	transport, exists := orb.transports[protocol]
+	if !exists {
+		return nil, fmt.Errorf("no transport registered for protocol %s", protocol)
+	}
+	return transport, nil
+}
+
+// EndpointFromIOR selects the first profile of an IOR that a registered
+// transport can connect to, and returns its endpoint and object key
+func (orb *ORB) EndpointFromIOR(ior *IOR) (Endpoint, []byte, error) {
+	orb.mu.RLock()
+	transports := make([]Transport, 0, len(orb.transportOrder))
+	for _, protocol := range orb.transportOrder {
+		transports = append(transports, orb.transports[protocol])
+	}
+	orb.mu.RUnlock()
+
+	return selectProfile(ior, transports)
+}
+
+// selectProfile returns the endpoint and object key of the first profile that
+// one of the transports recognises
+func selectProfile(ior *IOR, transports []Transport) (Endpoint, []byte, error) {
+	for _, profile := range ior.Profiles {
+		for _, transport := range transports {
+			if ep, objectKey, ok := transport.ParseProfile(profile); ok {
+				return ep, objectKey, nil
+			}
+		}
+	}
+
+	return Endpoint{}, nil, fmt.Errorf("no usable profile found in IOR")
+}
+
+// profileTransport returns a transport able to build and parse profiles for a
+// protocol when no ORB is at hand. Profile handling needs no configuration, so
+// a TLS transport without a tls.Config is sufficient.
+func profileTransport(protocol string) Transport {
+	switch protocol {
+	case TransportTLS:
+		return NewTLSTransport(nil)
+	case TransportUnix:
+		return NewUnixTransport()
+	case TransportMem:
+		return NewMemTransport()
+	default:
+		return NewTCPTransport()
+	}
+}
+
+// defaultProfileTransports returns transports for parsing the profiles of
+// every built-in protocol when no ORB is at hand
+func defaultProfileTransports() []Transport {
+	return []Transport{NewTCPTransport(), NewTLSTransport(nil), NewUnixTransport(), NewMemTransport()}
+}
+
+// registerDefaultTransports registers the transports that need no configuration
+func (orb *ORB) registerDefaultTransports() {
+	orb.RegisterTransport(NewTCPTransport())
+	orb.RegisterTransport(NewUnixTransport())
+	orb.RegisterTransport(NewMemTransport())
+}
@@ -0,0 +1,133 @@
+package corba_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+// recordingServant records the operations dispatched to it
+type recordingServant struct {
+	calls chan string
+}
+
+func (s *recordingServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	s.calls <- methodName
+	return nil, nil
+}
+
+// roundTrip serves a servant on ep and invokes it through a reference parsed from its IOR
+func roundTrip(t *testing.T, ep corba.Endpoint) {
+	t.Helper()
+
+	orb := corba.Init()
+	server, err := orb.CreateServerOn(ep)
+	if err != nil {
+		t.Fatalf("CreateServerOn: %v", err)
+	}
+	servant := &recordingServant{calls: make(chan string, 1)}
+	if err := server.RegisterServant("Echo", servant); err != nil {
+		t.Fatalf("RegisterServant: %v", err)
+	}
+	if err := server.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer server.Shutdown()
+
+	client := orb.CreateClient()
+	ref, err := client.GetObjectAt("Echo", ep)
+	if err != nil {
+		t.Fatalf("GetObjectAt: %v", err)
+	}
+
+	// Go through the stringified form to exercise the transport's profile
+	ior, err := orb.ObjectToString(ref)
+	if err != nil {
+		t.Fatalf("ObjectToString: %v", err)
+	}
+	ref, err = orb.StringToObject(ior)
+	if err != nil {
+		t.Fatalf("StringToObject: %v", err)
+	}
+	if ref.Endpoint() != ep {
+		t.Fatalf("endpoint from IOR = %v, want %v", ref.Endpoint(), ep)
+	}
+
+	if _, err := ref.Invoke("ping"); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if got := <-servant.calls; got != "ping" {
+		t.Errorf("servant received %q, want %q", got, "ping")
+	}
+}
+
+func TestMemTransportRoundTrip(t *testing.T) {
+	roundTrip(t, corba.NewMemEndpoint("transport-test"))
+}
+
+func TestUnixTransportRoundTrip(t *testing.T) {
+	roundTrip(t, corba.NewUnixEndpoint(filepath.Join(t.TempDir(), "orb.sock")))
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []corba.Endpoint{
+		corba.NewTCPEndpoint("localhost", 2809),
+		corba.NewTCPEndpoint("::1", 2809),
+		corba.NewTLSEndpoint("example.com", 443),
+		corba.NewUnixEndpoint("/tmp/orb.sock"),
+		corba.NewMemEndpoint("inproc"),
+	}
+
+	for _, want := range tests {
+		got, err := corba.ParseEndpoint(want.String())
+		if err != nil {
+			t.Errorf("ParseEndpoint(%q): %v", want.String(), err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseEndpoint(%q) = %v, want %v", want.String(), got, want)
+		}
+	}
+
+	for _, bad := range []string{"localhost:2809", "iiop://localhost", "uiop://", "foo://bar"} {
+		if _, err := corba.ParseEndpoint(bad); err == nil {
+			t.Errorf("ParseEndpoint(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestTransportProfiles(t *testing.T) {
+	key := []byte("POA/Echo")
+	tests := []struct {
+		transport corba.Transport
+		endpoint  corba.Endpoint
+	}{
+		{corba.NewTCPTransport(), corba.NewTCPEndpoint("localhost", 2809)},
+		{corba.NewTLSTransport(nil), corba.NewTLSEndpoint("localhost", 2810)},
+		{corba.NewUnixTransport(), corba.NewUnixEndpoint("/tmp/orb.sock")},
+		{corba.NewMemTransport(), corba.NewMemEndpoint("inproc")},
+	}
+
+	for _, tt := range tests {
+		profile := tt.transport.CreateProfile(tt.endpoint, key)
+		ep, objectKey, ok := tt.transport.ParseProfile(profile)
+		if !ok {
+			t.Errorf("%s: profile not recognised", tt.transport.Protocol())
+			continue
+		}
+		if ep != tt.endpoint || string(objectKey) != string(key) {
+			t.Errorf("%s: parsed %v %q, want %v %q", tt.transport.Protocol(), ep, objectKey, tt.endpoint, key)
+		}
+
+		// No other transport should claim the profile
+		for _, other := range tests {
+			if other.transport == tt.transport {
+				continue
+			}
+			if _, _, ok := other.transport.ParseProfile(profile); ok {
+				t.Errorf("%s profile recognised by %s", tt.transport.Protocol(), other.transport.Protocol())
+			}
+		}
+	}
+}
@@ -0,0 +1,729 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Value type modifiers
+const (
+	VM_NONE int16 = iota
+	VM_CUSTOM
+	VM_ABSTRACT
+	VM_TRUNCATABLE
+)
+
+// Visibility of value type members
+const (
+	PRIVATE_MEMBER int16 = 0
+	PUBLIC_MEMBER  int16 = 1
+)
+
+// stringTypeCode represents a bounded string or wide string type
+type stringTypeCode struct {
+	basicTypeCode
+	bound int
+}
+
+// boundedStringTypeCode returns the TypeCode of strings of a kind with a
+// bound, which is the basic TypeCode of the kind if the bound is 0
+func boundedStringTypeCode(kind TCKind, bound int) (TypeCodeImpl, error) {
+	if bound < 0 {
+		return nil, fmt.Errorf("%w: negative string bound %d", ErrInvalidTypeCode, bound)
+	}
+	basic, err := GetBasicTypeCode(kind)
+	if err != nil || bound == 0 {
+		return basic, err
+	}
+
+	stc := &stringTypeCode{basicTypeCode: *basic.(*basicTypeCode), bound: bound}
+	stc.id = ""
+	stc.name = fmt.Sprintf("%s<%d>", basic.Name(), bound)
+	return stc, nil
+}
+
+// Param gets a parameter value by index
+func (s *stringTypeCode) Param(index int) (interface{}, error) {
+	if index == 0 {
+		return s.bound, nil
+	}
+	return nil, fmt.Errorf("parameter index %d out of range", index)
+}
+
+// ParameterCount returns the number of parameters
+func (s *stringTypeCode) ParameterCount() int {
+	return 1 // bound
+}
+
+// Length returns the bound for strings, sequences, arrays
+func (s *stringTypeCode) Length() int {
+	return s.bound
+}
+
+// aliasTypeCode represents a typedef of another type or, with the kind
+// TC_VALUE_BOX, a value box holding another type
+type aliasTypeCode struct {
+	basicTypeCode
+	contentType TypeCode
+}
+
+// newAliasTypeCode creates an alias or value box TypeCode
+func newAliasTypeCode(id string, name string, contentType TypeCode, kind TCKind) *aliasTypeCode {
+	dk := DK_ALIAS
+	if kind == TC_VALUE_BOX {
+		dk = DK_VALUE_BOX
+	}
+
+	return &aliasTypeCode{
+		basicTypeCode: basicTypeCode{
+			typeCodeBase: typeCodeBase{
+				id:   id,
+				name: name,
+				kind: dk,
+			},
+			tcKind: kind,
+		},
+		contentType: contentType,
+	}
+}
+
+// Param gets a parameter value by index
+func (a *aliasTypeCode) Param(index int) (interface{}, error) {
+	switch index {
+	case 0:
+		return a.id, nil
+	case 1:
+		return a.name, nil
+	case 2:
+		return a.contentType, nil
+	}
+	return nil, fmt.Errorf("parameter index %d out of range", index)
+}
+
+// ParameterCount returns the number of parameters
+func (a *aliasTypeCode) ParameterCount() int {
+	return 3 // id, name and original type
+}
+
+// ContentType returns the original type of an alias or the boxed type of a
+// value box
+func (a *aliasTypeCode) ContentType() (TypeCode, error) {
+	return a.contentType, nil
+}
+
+// objectRefTypeCode represents references to objects of an interface
+type objectRefTypeCode struct {
+	basicTypeCode
+}
+
+// newObjectRefTypeCode creates an object reference TypeCode
+func newObjectRefTypeCode(id string, name string) *objectRefTypeCode {
+	return &objectRefTypeCode{
+		basicTypeCode: basicTypeCode{
+			typeCodeBase: typeCodeBase{
+				id:   id,
+				name: name,
+				kind: DK_INTERFACE,
+			},
+			tcKind: TC_OBJREF,
+			goType: reflect.TypeOf((*ObjectRef)(nil)),
+		},
+	}
+}
+
+// Param gets a parameter value by index
+func (o *objectRefTypeCode) Param(index int) (interface{}, error) {
+	switch index {
+	case 0:
+		return o.id, nil
+	case 1:
+		return o.name, nil
+	}
+	return nil, fmt.Errorf("parameter index %d out of range", index)
+}
+
+// ParameterCount returns the number of parameters
+func (o *objectRefTypeCode) ParameterCount() int {
+	return 2 // id and name
+}
+
+// valueTypeCode represents a value type. Its members are the state members
+// declared by the value type itself; those of its concrete base type are
+// described by the base type's TypeCode.
+type valueTypeCode struct {
+	structTypeCode
+	modifier     int16
+	concreteBase TypeCode
+	visibility   []int16
+}
+
+// newValueTypeCode creates a value type TypeCode without members
+func newValueTypeCode(id string, name string, modifier int16, concreteBase TypeCode) *valueTypeCode {
+	vtc := &valueTypeCode{
+		structTypeCode: *newStructTypeCode(id, name, TC_VALUE),
+		modifier:       modifier,
+		concreteBase:   concreteBase,
+	}
+	vtc.kind = DK_VALUE
+	return vtc
+}
+
+// AddMember adds a state member with a visibility, PUBLIC_MEMBER or
+// PRIVATE_MEMBER, to the value type
+func (v *valueTypeCode) AddMember(name string, typeCode TypeCode, visibility int16) {
+	v.structTypeCode.AddMember(name, typeCode)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.visibility = append(v.visibility, visibility)
+}
+
+// TypeModifier returns the value modifier: VM_NONE, VM_CUSTOM, VM_ABSTRACT
+// or VM_TRUNCATABLE
+func (v *valueTypeCode) TypeModifier() int16 {
+	return v.modifier
+}
+
+// ConcreteBaseType returns the TypeCode of the concrete base value type, or
+// nil if there is none
+func (v *valueTypeCode) ConcreteBaseType() TypeCode {
+	return v.concreteBase
+}
+
+// MemberVisibility returns the visibility of a state member
+func (v *valueTypeCode) MemberVisibility(index int) (int16, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if index < 0 || index >= len(v.visibility) {
+		return 0, fmt.Errorf("member index %d out of range", index)
+	}
+	return v.visibility[index], nil
+}
+
+// valueTypeInfo is implemented by the TypeCodes of value types
+type valueTypeInfo interface {
+	TypeModifier() int16
+	ConcreteBaseType() TypeCode
+	MemberVisibility(index int) (int16, error)
+}
+
+// recursiveTypeCode stands for a type that is being defined, within its own
+// definition. It behaves as the TypeCode registered under its repository ID.
+type recursiveTypeCode struct {
+	id string
+}
+
+// errUnresolvedRecursion is returned by the operations of a recursive
+// TypeCode whose type has not been registered
+var errUnresolvedRecursion = errors.New("recursive TypeCode refers to an unregistered type")
+
+// resolve returns the TypeCode the recursive TypeCode stands for, or nil if
+// the type has not been registered
+func (r *recursiveTypeCode) resolve() TypeCodeImpl {
+	tc, err := GetTypeCode(r.id)
+	if err != nil {
+		return nil
+	}
+	return tc
+}
+
+// Kind returns the definition kind of the type
+func (r *recursiveTypeCode) Kind() DefinitionKind {
+	if tc := r.resolve(); tc != nil {
+		return tc.Kind()
+	}
+	return DK_NONE
+}
+
+// Id returns the repository ID of the type
+func (r *recursiveTypeCode) Id() string {
+	return r.id
+}
+
+// Name returns the name of the type
+func (r *recursiveTypeCode) Name() string {
+	if tc := r.resolve(); tc != nil {
+		return tc.Name()
+	}
+	return ""
+}
+
+// String returns a string representation of the type
+func (r *recursiveTypeCode) String() string {
+	return fmt.Sprintf("recursive %s", r.id)
+}
+
+// TCKind returns the TCKind of the type
+func (r *recursiveTypeCode) TCKind() TCKind {
+	if tc := r.resolve(); tc != nil {
+		return tc.TCKind()
+	}
+	return TC_NULL
+}
+
+// Param gets a parameter value by index
+func (r *recursiveTypeCode) Param(index int) (interface{}, error) {
+	if tc := r.resolve(); tc != nil {
+		return tc.Param(index)
+	}
+	return nil, errUnresolvedRecursion
+}
+
+// ParameterCount returns the number of parameters
+func (r *recursiveTypeCode) ParameterCount() int {
+	if tc := r.resolve(); tc != nil {
+		return tc.ParameterCount()
+	}
+	return 0
+}
+
+// ContentType returns the content TypeCode
+func (r *recursiveTypeCode) ContentType() (TypeCode, error) {
+	if tc := r.resolve(); tc != nil {
+		return tc.ContentType()
+	}
+	return nil, errUnresolvedRecursion
+}
+
+// MemberCount returns the number of members
+func (r *recursiveTypeCode) MemberCount() int {
+	if tc := r.resolve(); tc != nil {
+		return tc.MemberCount()
+	}
+	return 0
+}
+
+// MemberName returns the name of a member
+func (r *recursiveTypeCode) MemberName(index int) (string, error) {
+	if tc := r.resolve(); tc != nil {
+		return tc.MemberName(index)
+	}
+	return "", errUnresolvedRecursion
+}
+
+// MemberType returns the type of a member
+func (r *recursiveTypeCode) MemberType(index int) (TypeCode, error) {
+	if tc := r.resolve(); tc != nil {
+		return tc.MemberType(index)
+	}
+	return nil, errUnresolvedRecursion
+}
+
+// MemberLabel returns the label of a union member
+func (r *recursiveTypeCode) MemberLabel(index int) (interface{}, error) {
+	if tc := r.resolve(); tc != nil {
+		return tc.MemberLabel(index)
+	}
+	return nil, errUnresolvedRecursion
+}
+
+// DiscriminatorType returns the discriminator type of a union
+func (r *recursiveTypeCode) DiscriminatorType() (TypeCode, error) {
+	if tc := r.resolve(); tc != nil {
+		return tc.DiscriminatorType()
+	}
+	return nil, errUnresolvedRecursion
+}
+
+// DefaultIndex returns the default case index for a union
+func (r *recursiveTypeCode) DefaultIndex() int {
+	if tc := r.resolve(); tc != nil {
+		return tc.DefaultIndex()
+	}
+	return -1
+}
+
+// Length returns the bound for strings, sequences, arrays
+func (r *recursiveTypeCode) Length() int {
+	if tc := r.resolve(); tc != nil {
+		return tc.Length()
+	}
+	return 0
+}
+
+// resolveTypeCode returns the TypeCode a recursive TypeCode stands for, or
+// the TypeCode itself if it is not recursive or not resolved yet
+func resolveTypeCode(tc TypeCode) TypeCode {
+	if r, ok := tc.(*recursiveTypeCode); ok {
+		if resolved := r.resolve(); resolved != nil {
+			return resolved
+		}
+	}
+	return tc
+}
+
+// unaliasTypeCode returns the original type of an alias, looking through
+// any number of aliases
+func unaliasTypeCode(tc TypeCode) TypeCode {
+	tc = resolveTypeCode(tc)
+	for {
+		impl, ok := tc.(TypeCodeImpl)
+		if !ok || impl.TCKind() != TC_ALIAS {
+			return tc
+		}
+		original, err := impl.ContentType()
+		if err != nil || original == nil {
+			return tc
+		}
+		tc = resolveTypeCode(original)
+	}
+}
+
+// hasRepositoryID reports whether TypeCodes of a kind are identified by a
+// repository ID
+func hasRepositoryID(kind TCKind) bool {
+	switch kind {
+	case TC_OBJREF, TC_STRUCT, TC_UNION, TC_ENUM, TC_ALIAS, TC_EXCEPT, TC_VALUE,
+		TC_VALUE_BOX, TC_NATIVE, TC_ABSTRACT_INTERFACE, TC_LOCAL_INTERFACE:
+		return true
+	}
+	return false
+}
+
+// typeCodePair is a pair of TypeCodes under comparison
+type typeCodePair struct {
+	a, b TypeCode
+}
+
+// compareTypeCodes reports whether two TypeCodes are equal or, if equivalent
+// is set, equivalent. Equal TypeCodes have the same parameters, names
+// included. Equivalent TypeCodes describe the same type: aliases are looked
+// through, names and member names are ignored, and types with repository IDs
+// on both sides are the same if their IDs are. Pairs already being compared
+// are taken to match, which ends the comparison of recursive types.
+func compareTypeCodes(a, b TypeCode, equivalent bool, seen map[typeCodePair]bool) bool {
+	a, b = resolveTypeCode(a), resolveTypeCode(b)
+	if equivalent {
+		a, b = unaliasTypeCode(a), unaliasTypeCode(b)
+	}
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a == b {
+		return true
+	}
+
+	ai, aok := a.(TypeCodeImpl)
+	bi, bok := b.(TypeCodeImpl)
+	if !aok || !bok {
+		return a.Kind() == b.Kind() && a.Id() == b.Id() && a.Name() == b.Name()
+	}
+	kind := ai.TCKind()
+	if kind != bi.TCKind() {
+		return false
+	}
+
+	pair := typeCodePair{a, b}
+	if seen[pair] {
+		return true
+	}
+	seen[pair] = true
+
+	if hasRepositoryID(kind) {
+		if equivalent && a.Id() != "" && b.Id() != "" {
+			return a.Id() == b.Id()
+		}
+		if !equivalent && (a.Id() != b.Id() || a.Name() != b.Name()) {
+			return false
+		}
+	}
+
+	switch kind {
+	case TC_STRING, TC_WSTRING:
+		return ai.Length() == bi.Length()
+	case TC_FIXED:
+		af, aok := a.(fixedTypeInfo)
+		bf, bok := b.(fixedTypeInfo)
+		return aok && bok && af.FixedDigits() == bf.FixedDigits() && af.FixedScale() == bf.FixedScale()
+	case TC_SEQUENCE, TC_ARRAY, TC_ALIAS, TC_VALUE_BOX:
+		if ai.Length() != bi.Length() {
+			return false
+		}
+		ac, aerr := ai.ContentType()
+		bc, berr := bi.ContentType()
+		return aerr == nil && berr == nil && compareTypeCodes(ac, bc, equivalent, seen)
+	case TC_STRUCT, TC_EXCEPT, TC_UNION, TC_ENUM, TC_VALUE:
+		return compareMembers(ai, bi, equivalent, seen)
+	}
+	return true
+}
+
+// compareMembers compares the members of two struct, exception, union, enum
+// or value type TypeCodes of the same kind
+func compareMembers(a, b TypeCodeImpl, equivalent bool, seen map[typeCodePair]bool) bool {
+	kind := a.TCKind()
+	if a.MemberCount() != b.MemberCount() {
+		return false
+	}
+
+	if kind == TC_UNION {
+		ad, _ := a.DiscriminatorType()
+		bd, _ := b.DiscriminatorType()
+		if a.DefaultIndex() != b.DefaultIndex() || !compareTypeCodes(ad, bd, equivalent, seen) {
+			return false
+		}
+	}
+	av, avalue := a.(valueTypeInfo)
+	bv, bvalue := b.(valueTypeInfo)
+	if kind == TC_VALUE {
+		if !avalue || !bvalue || av.TypeModifier() != bv.TypeModifier() {
+			return false
+		}
+		ab, bb := av.ConcreteBaseType(), bv.ConcreteBaseType()
+		if (ab == nil) != (bb == nil) || ab != nil && !compareTypeCodes(ab, bb, equivalent, seen) {
+			return false
+		}
+	}
+
+	for i := 0; i < a.MemberCount(); i++ {
+		if !equivalent {
+			an, _ := a.MemberName(i)
+			bn, _ := b.MemberName(i)
+			if an != bn {
+				return false
+			}
+		}
+		if kind == TC_ENUM {
+			continue
+		}
+		if kind == TC_UNION && i != a.DefaultIndex() {
+			al, _ := a.MemberLabel(i)
+			bl, _ := b.MemberLabel(i)
+			if labelKey(al) != labelKey(bl) {
+				return false
+			}
+		}
+		if kind == TC_VALUE {
+			avis, _ := av.MemberVisibility(i)
+			bvis, _ := bv.MemberVisibility(i)
+			if avis != bvis {
+				return false
+			}
+		}
+		at, aerr := a.MemberType(i)
+		bt, berr := b.MemberType(i)
+		if aerr != nil || berr != nil || !compareTypeCodes(at, bt, equivalent, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// labelKey returns a union label in a form that compares equal for labels of
+// the same value, whatever their Go integer type
+func labelKey(label interface{}) interface{} {
+	rv := reflect.ValueOf(label)
+	switch {
+	case !rv.IsValid():
+		return nil
+	case rv.CanInt():
+		return rv.Int()
+	case rv.CanUint():
+		return int64(rv.Uint())
+	}
+	return label
+}
+
+// equalTypeCodes reports whether two TypeCodes are equal
+func equalTypeCodes(a, b TypeCode) bool {
+	return compareTypeCodes(a, b, false, make(map[typeCodePair]bool))
+}
+
+// equivalentTypeCodes reports whether two TypeCodes are equivalent
+func equivalentTypeCodes(a, b TypeCode) bool {
+	return compareTypeCodes(a, b, true, make(map[typeCodePair]bool))
+}
+
+// compactTypeCode returns a copy of a TypeCode with the names and member
+// names of named types removed. Repository IDs are kept. Copies already made
+// are reused, so that recursive types stay recursive.
+func compactTypeCode(tc TypeCode, copies map[TypeCode]TypeCode) TypeCode {
+	if compact, ok := copies[tc]; ok {
+		return compact
+	}
+
+	switch t := tc.(type) {
+	case *valueTypeCode:
+		compact := newValueTypeCode(t.id, "", t.modifier, nil)
+		copies[tc] = compact
+		if t.concreteBase != nil {
+			compact.concreteBase = compactTypeCode(t.concreteBase, copies)
+		}
+		for i := 0; i < t.MemberCount(); i++ {
+			memberType, _ := t.MemberType(i)
+			visibility, _ := t.MemberVisibility(i)
+			compact.AddMember("", compactTypeCode(memberType, copies), visibility)
+		}
+		return compact
+
+	case *structTypeCode:
+		compact := newStructTypeCode(t.id, "", t.tcKind)
+		copies[tc] = compact
+		for i := 0; i < t.MemberCount(); i++ {
+			memberType, _ := t.MemberType(i)
+			compact.AddMember("", compactTypeCode(memberType, copies))
+		}
+		return compact
+
+	case *unionTypeCode:
+		compact := newUnionTypeCode(t.id, "", compactTypeCode(t.discriminatorType, copies))
+		copies[tc] = compact
+		for i := 0; i < t.MemberCount(); i++ {
+			label, _ := t.MemberLabel(i)
+			memberType, _ := t.MemberType(i)
+			compact.AddMember("", label, compactTypeCode(memberType, copies))
+		}
+		compact.defaultIndex = t.DefaultIndex()
+		return compact
+
+	case *enumTypeCode:
+		compact := newEnumTypeCode(t.id, "")
+		for i := 0; i < t.MemberCount(); i++ {
+			compact.AddMember("")
+		}
+		copies[tc] = compact
+		return compact
+
+	case *arrayTypeCode:
+		compact := newArrayTypeCode(t.id, t.name, nil, t.bound)
+		copies[tc] = compact
+		compact.elementType = compactTypeCode(t.elementType, copies)
+		return compact
+
+	case *sequenceTypeCode:
+		compact := newSequenceTypeCode(t.id, t.name, nil, t.bound)
+		copies[tc] = compact
+		compact.elementType = compactTypeCode(t.elementType, copies)
+		return compact
+
+	case *aliasTypeCode:
+		compact := newAliasTypeCode(t.id, "", nil, t.tcKind)
+		copies[tc] = compact
+		compact.contentType = compactTypeCode(t.contentType, copies)
+		return compact
+
+	case *objectRefTypeCode:
+		compact := newObjectRefTypeCode(t.id, "")
+		copies[tc] = compact
+		return compact
+	}
+	return tc
+}
+
+// getCompactTypeCode returns a TypeCode without names and member names
+func getCompactTypeCode(tc TypeCode) TypeCode {
+	return compactTypeCode(tc, make(map[TypeCode]TypeCode))
+}
+
+// Equal, Equivalent and GetCompactTypeCode of each kind of TypeCode
+
+// Equal reports whether another TypeCode has the same parameters
+func (b *basicTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(b, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (b *basicTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(b, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (b *basicTypeCode) GetCompactTypeCode() TypeCode { return b }
+
+// Equal reports whether another TypeCode has the same parameters
+func (s *stringTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(s, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (s *stringTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(s, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (s *stringTypeCode) GetCompactTypeCode() TypeCode { return s }
+
+// Equal reports whether another TypeCode has the same parameters
+func (f *fixedTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(f, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (f *fixedTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(f, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (f *fixedTypeCode) GetCompactTypeCode() TypeCode { return f }
+
+// Equal reports whether another TypeCode has the same parameters
+func (a *aliasTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(a, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (a *aliasTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(a, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (a *aliasTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(a) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (o *objectRefTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(o, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (o *objectRefTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(o, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (o *objectRefTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(o) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (s *structTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(s, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (s *structTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(s, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (s *structTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(s) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (v *valueTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(v, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (v *valueTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(v, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (v *valueTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(v) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (s *sequenceTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(s, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (s *sequenceTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(s, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (s *sequenceTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(s) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (a *arrayTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(a, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (a *arrayTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(a, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (a *arrayTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(a) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (e *enumTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(e, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (e *enumTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(e, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (e *enumTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(e) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (u *unionTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(u, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (u *unionTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(u, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (u *unionTypeCode) GetCompactTypeCode() TypeCode { return getCompactTypeCode(u) }
+
+// Equal reports whether another TypeCode has the same parameters
+func (r *recursiveTypeCode) Equal(other TypeCode) bool { return equalTypeCodes(r, other) }
+
+// Equivalent reports whether another TypeCode describes the same type
+func (r *recursiveTypeCode) Equivalent(other TypeCode) bool { return equivalentTypeCodes(r, other) }
+
+// GetCompactTypeCode returns the TypeCode without names
+func (r *recursiveTypeCode) GetCompactTypeCode() TypeCode { return r }
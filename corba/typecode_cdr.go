@@ -0,0 +1,535 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ifabos/go-corba/giop"
+)
+
+// tcIndirection is written in place of the kind of a TypeCode that occurs
+// within itself, followed by the offset of the kind of its outer occurrence
+const tcIndirection uint32 = 0xffffffff
+
+// typeCodeWriter writes TypeCodes in their CDR representation. TypeCodes
+// with encapsulated parameters are remembered by the position of their kind
+// while their parameters are written, and written as an indirection to that
+// position when they recur within themselves.
+type typeCodeWriter struct {
+	positions map[TypeCode]int
+}
+
+// marshalTypeCode writes a TypeCode
+func marshalTypeCode(m *giop.CDRMarshaller, tc TypeCode) error {
+	w := &typeCodeWriter{positions: make(map[TypeCode]int)}
+	return w.write(m, 0, tc)
+}
+
+// write writes a TypeCode to m, whose first byte is at position base of the
+// outermost stream
+func (w *typeCodeWriter) write(m *giop.CDRMarshaller, base int, tc TypeCode) error {
+	tc = resolveTypeCode(tc)
+	if r, ok := tc.(*recursiveTypeCode); ok {
+		return fmt.Errorf("%w: %s", errUnresolvedRecursion, r.id)
+	}
+	impl, ok := tc.(TypeCodeImpl)
+	if !ok {
+		if tc == nil {
+			return ErrInvalidTypeCode
+		}
+		return fmt.Errorf("%w: %s has no parameter information", ErrInvalidTypeCode, tc.Id())
+	}
+	kind := impl.TCKind()
+
+	if position, ok := w.positions[tc]; ok {
+		m.WriteULong(tcIndirection)
+		m.WriteLong(int32(position - (base + m.Size())))
+		return nil
+	}
+	m.WriteULong(uint32(kind))
+
+	switch kind {
+	case TC_STRING, TC_WSTRING:
+		m.WriteULong(uint32(impl.Length()))
+		return nil
+	case TC_FIXED:
+		fixed, ok := tc.(fixedTypeInfo)
+		if !ok {
+			return fmt.Errorf("%w: fixed type without digits", ErrInvalidTypeCode)
+		}
+		m.WriteUShort(fixed.FixedDigits())
+		m.WriteShort(int16(fixed.FixedScale()))
+		return nil
+	case TC_OBJREF, TC_STRUCT, TC_UNION, TC_ENUM, TC_SEQUENCE, TC_ARRAY, TC_ALIAS, TC_EXCEPT,
+		TC_VALUE, TC_VALUE_BOX, TC_NATIVE, TC_ABSTRACT_INTERFACE, TC_LOCAL_INTERFACE:
+		w.positions[tc] = base + m.Size() - 4
+		defer delete(w.positions, tc)
+		return w.encapsulate(m, base, func(e *giop.CDRMarshaller, base int) error {
+			return w.writeParams(e, base, impl)
+		})
+	}
+	return nil
+}
+
+// encapsulate writes the parameters written by body as an encapsulation
+func (w *typeCodeWriter) encapsulate(m *giop.CDRMarshaller, base int, body func(*giop.CDRMarshaller, int) error) error {
+	// The encapsulation starts after its length, which is aligned
+	start := base + (m.Size()+3)&^3 + 4
+
+	e := giop.NewCDRMarshaller(binary.BigEndian)
+	e.WriteOctet(0) // Big endian
+	if err := body(e, start); err != nil {
+		return err
+	}
+	m.WriteOctetSequence(e.Bytes())
+	return nil
+}
+
+// writeParams writes the encapsulated parameters of a TypeCode
+func (w *typeCodeWriter) writeParams(e *giop.CDRMarshaller, base int, tc TypeCodeImpl) error {
+	kind := tc.TCKind()
+	if kind == TC_SEQUENCE || kind == TC_ARRAY {
+		content, err := tc.ContentType()
+		if err != nil {
+			return err
+		}
+		if err := w.write(e, base, content); err != nil {
+			return err
+		}
+		e.WriteULong(uint32(tc.Length()))
+		return nil
+	}
+
+	e.WriteString(tc.Id())
+	e.WriteString(tc.Name())
+
+	switch kind {
+	case TC_ALIAS, TC_VALUE_BOX:
+		content, err := tc.ContentType()
+		if err != nil {
+			return err
+		}
+		return w.write(e, base, content)
+
+	case TC_ENUM:
+		e.WriteULong(uint32(tc.MemberCount()))
+		for i := 0; i < tc.MemberCount(); i++ {
+			name, _ := tc.MemberName(i)
+			e.WriteString(name)
+		}
+
+	case TC_STRUCT, TC_EXCEPT:
+		e.WriteULong(uint32(tc.MemberCount()))
+		for i := 0; i < tc.MemberCount(); i++ {
+			if err := w.writeMember(e, base, tc, i); err != nil {
+				return err
+			}
+		}
+
+	case TC_UNION:
+		discriminatorType, err := tc.DiscriminatorType()
+		if err != nil {
+			return err
+		}
+		if err := w.write(e, base, discriminatorType); err != nil {
+			return err
+		}
+		e.WriteLong(int32(tc.DefaultIndex()))
+		e.WriteULong(uint32(tc.MemberCount()))
+		for i := 0; i < tc.MemberCount(); i++ {
+			if i == tc.DefaultIndex() {
+				// The label of the default member is the zero octet
+				e.WriteOctet(0)
+			} else {
+				label, _ := tc.MemberLabel(i)
+				if err := marshalLabel(e, discriminatorType, label); err != nil {
+					return err
+				}
+			}
+			if err := w.writeMember(e, base, tc, i); err != nil {
+				return err
+			}
+		}
+
+	case TC_VALUE:
+		value, ok := tc.(valueTypeInfo)
+		if !ok {
+			return fmt.Errorf("%w: value type without modifier", ErrInvalidTypeCode)
+		}
+		e.WriteShort(value.TypeModifier())
+		concreteBase := value.ConcreteBaseType()
+		if concreteBase == nil {
+			concreteBase, _ = GetBasicTypeCode(TC_NULL)
+		}
+		if err := w.write(e, base, concreteBase); err != nil {
+			return err
+		}
+		e.WriteULong(uint32(tc.MemberCount()))
+		for i := 0; i < tc.MemberCount(); i++ {
+			if err := w.writeMember(e, base, tc, i); err != nil {
+				return err
+			}
+			visibility, _ := value.MemberVisibility(i)
+			e.WriteShort(visibility)
+		}
+	}
+	return nil
+}
+
+// writeMember writes the name and type of a member
+func (w *typeCodeWriter) writeMember(e *giop.CDRMarshaller, base int, tc TypeCodeImpl, index int) error {
+	name, err := tc.MemberName(index)
+	if err != nil {
+		return err
+	}
+	memberType, err := tc.MemberType(index)
+	if err != nil {
+		return err
+	}
+	e.WriteString(name)
+	return w.write(e, base, memberType)
+}
+
+// marshalLabel writes a union label as a value of the discriminator type.
+// Labels of enum discriminators may be given by enumerator name.
+func marshalLabel(m *giop.CDRMarshaller, discriminatorType TypeCode, label interface{}) error {
+	if name, ok := label.(string); ok {
+		ordinal, ok := enumOrdinal(discriminatorType, name)
+		if !ok {
+			return fmt.Errorf("%w: %s is not an enumerator of %s", ErrTypeMismatch, name, discriminatorType.Name())
+		}
+		label = ordinal
+	}
+	return marshalValue(m, discriminatorType, label)
+}
+
+// enumOrdinal returns the ordinal of an enumerator
+func enumOrdinal(tc TypeCode, name string) (uint32, bool) {
+	impl, ok := unaliasTypeCode(tc).(TypeCodeImpl)
+	if !ok || impl.TCKind() != TC_ENUM {
+		return 0, false
+	}
+	for i := 0; i < impl.MemberCount(); i++ {
+		if member, _ := impl.MemberName(i); member == name {
+			return uint32(i), true
+		}
+	}
+	return 0, false
+}
+
+// typeCodeReader reads TypeCodes from their CDR representation, resolving
+// indirections to the TypeCodes read, or being read, at the positions they
+// refer to
+type typeCodeReader struct {
+	types map[int]TypeCode
+}
+
+// unmarshalTypeCode reads a TypeCode. Basic TypeCodes are those of the
+// registry; other TypeCodes are new values that are not registered.
+func unmarshalTypeCode(u *giop.CDRUnmarshaller) (TypeCode, error) {
+	r := &typeCodeReader{types: make(map[int]TypeCode)}
+	return r.read(u, 0)
+}
+
+// read reads a TypeCode from u, whose first byte is at position base of the
+// outermost stream
+func (r *typeCodeReader) read(u *giop.CDRUnmarshaller, base int) (TypeCode, error) {
+	value, err := u.ReadULong()
+	if err != nil {
+		return nil, err
+	}
+	position := base + u.Position() - 4
+
+	if value == tcIndirection {
+		offsetPosition := base + u.Position()
+		offset, err := u.ReadLong()
+		if err != nil {
+			return nil, err
+		}
+		tc, ok := r.types[offsetPosition+int(offset)]
+		if !ok {
+			return nil, fmt.Errorf("%w: indirection to offset %d does not refer to a TypeCode", ErrInvalidTypeCode, offset)
+		}
+		return tc, nil
+	}
+
+	kind := TCKind(value)
+	switch kind {
+	case TC_STRING, TC_WSTRING:
+		bound, err := u.ReadULong()
+		if err != nil {
+			return nil, err
+		}
+		return boundedStringTypeCode(kind, int(bound))
+
+	case TC_FIXED:
+		digits, err := u.ReadUShort()
+		if err != nil {
+			return nil, err
+		}
+		scale, err := u.ReadShort()
+		if err != nil {
+			return nil, err
+		}
+		if scale < 0 {
+			return nil, fmt.Errorf("%w: fixed<%d,%d>", ErrInvalidTypeCode, digits, scale)
+		}
+		return CreateFixedTypeCode(digits, uint16(scale))
+
+	case TC_OBJREF, TC_STRUCT, TC_UNION, TC_ENUM, TC_SEQUENCE, TC_ARRAY, TC_ALIAS, TC_EXCEPT,
+		TC_VALUE, TC_VALUE_BOX:
+		e, start, err := r.encapsulation(u, base)
+		if err != nil {
+			return nil, err
+		}
+		tc, err := r.readParams(e, start, position, kind)
+		if err != nil {
+			return nil, fmt.Errorf("%s TypeCode: %w", kind, err)
+		}
+		r.types[position] = tc
+		return tc, nil
+	}
+
+	tc, err := GetBasicTypeCode(kind)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unsupported TypeCode kind %d", ErrInvalidTypeCode, value)
+	}
+	return tc, nil
+}
+
+// encapsulation reads an encapsulation, returning an unmarshaller positioned
+// after its byte order flag and the position of its first byte in the
+// outermost stream
+func (r *typeCodeReader) encapsulation(u *giop.CDRUnmarshaller, base int) (*giop.CDRUnmarshaller, int, error) {
+	length, err := u.ReadULong()
+	if err != nil {
+		return nil, 0, err
+	}
+	if length == 0 || int(length) > u.Len() {
+		return nil, 0, fmt.Errorf("%w: encapsulation length %d", ErrInvalidTypeCode, length)
+	}
+	start := base + u.Position()
+
+	data := make([]byte, length)
+	for i := range data {
+		if data[i], err = u.ReadOctet(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if data[0]&1 == 1 {
+		order = binary.LittleEndian
+	}
+	e := giop.NewCDRUnmarshaller(data, order)
+	e.ReadOctet()
+	return e, start, nil
+}
+
+// readParams reads the encapsulated parameters of a TypeCode whose kind is at
+// position of the outermost stream. The TypeCode is remembered before its
+// member types are read, for indirections within it to refer to it.
+func (r *typeCodeReader) readParams(e *giop.CDRUnmarshaller, base int, position int, kind TCKind) (TypeCode, error) {
+	if kind == TC_SEQUENCE || kind == TC_ARRAY {
+		stc := newSequenceTypeCode("", "", nil, 0)
+		var tc TypeCode = stc
+		if kind == TC_ARRAY {
+			atc := newArrayTypeCode("", "", nil, 0)
+			stc, tc = &atc.sequenceTypeCode, atc
+		}
+		r.types[position] = tc
+
+		content, err := r.read(e, base)
+		if err != nil {
+			return nil, err
+		}
+		bound, err := e.ReadULong()
+		if err != nil {
+			return nil, err
+		}
+		stc.elementType, stc.bound = content, int(bound)
+		stc.name = fmt.Sprintf("%s<%s>", kind, content.Name())
+		return tc, nil
+	}
+
+	id, err := e.ReadString()
+	if err != nil {
+		return nil, err
+	}
+	name, err := e.ReadString()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case TC_OBJREF:
+		return newObjectRefTypeCode(id, name), nil
+
+	case TC_ALIAS, TC_VALUE_BOX:
+		atc := newAliasTypeCode(id, name, nil, kind)
+		r.types[position] = atc
+		if atc.contentType, err = r.read(e, base); err != nil {
+			return nil, err
+		}
+		return atc, nil
+
+	case TC_ENUM:
+		etc := newEnumTypeCode(id, name)
+		count, err := r.count(e)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			member, err := e.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			etc.AddMember(member)
+		}
+		return etc, nil
+
+	case TC_STRUCT, TC_EXCEPT:
+		stc := newStructTypeCode(id, name, kind)
+		r.types[position] = stc
+		count, err := r.count(e)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			member, memberType, err := r.readMember(e, base)
+			if err != nil {
+				return nil, err
+			}
+			stc.AddMember(member, memberType)
+		}
+		return stc, nil
+
+	case TC_UNION:
+		discriminatorType, err := r.read(e, base)
+		if err != nil {
+			return nil, err
+		}
+		utc := newUnionTypeCode(id, name, discriminatorType)
+		r.types[position] = utc
+		defaultIndex, err := e.ReadLong()
+		if err != nil {
+			return nil, err
+		}
+		count, err := r.count(e)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			var label interface{}
+			if i == int(defaultIndex) {
+				label, err = e.ReadOctet()
+			} else {
+				label, err = unmarshalValue(e, discriminatorType)
+			}
+			if err != nil {
+				return nil, err
+			}
+			member, memberType, err := r.readMember(e, base)
+			if err != nil {
+				return nil, err
+			}
+			utc.AddMember(member, label, memberType)
+		}
+		if defaultIndex >= 0 {
+			if err := utc.SetDefaultMember(int(defaultIndex)); err != nil {
+				return nil, err
+			}
+		}
+		return utc, nil
+
+	case TC_VALUE:
+		modifier, err := e.ReadShort()
+		if err != nil {
+			return nil, err
+		}
+		vtc := newValueTypeCode(id, name, modifier, nil)
+		r.types[position] = vtc
+		concreteBase, err := r.read(e, base)
+		if err != nil {
+			return nil, err
+		}
+		if kind, _ := tcKindOf(concreteBase); kind != TC_NULL {
+			vtc.concreteBase = concreteBase
+		}
+		count, err := r.count(e)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			member, memberType, err := r.readMember(e, base)
+			if err != nil {
+				return nil, err
+			}
+			visibility, err := e.ReadShort()
+			if err != nil {
+				return nil, err
+			}
+			vtc.AddMember(member, memberType, visibility)
+		}
+		return vtc, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported TypeCode kind %s", ErrInvalidTypeCode, kind)
+}
+
+// count reads a member count, which cannot exceed the remaining data
+func (r *typeCodeReader) count(e *giop.CDRUnmarshaller) (int, error) {
+	count, err := e.ReadULong()
+	if err != nil {
+		return 0, err
+	}
+	if int(count) > e.Len() {
+		return 0, fmt.Errorf("%w: member count %d exceeds the remaining data", ErrInvalidTypeCode, count)
+	}
+	return int(count), nil
+}
+
+// readMember reads the name and type of a member
+func (r *typeCodeReader) readMember(e *giop.CDRUnmarshaller, base int) (string, TypeCode, error) {
+	name, err := e.ReadString()
+	if err != nil {
+		return "", nil, err
+	}
+	memberType, err := r.read(e, base)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, memberType, nil
+}
+
+// MarshalTypeCode encodes a TypeCode. TypeCodes that occur within themselves
+// are encoded as indirections. The encoding is CDR in big endian byte order.
+func MarshalTypeCode(tc TypeCode) ([]byte, error) {
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	if err := marshalTypeCode(m, tc); err != nil {
+		return nil, err
+	}
+	return m.Bytes(), nil
+}
+
+// UnmarshalTypeCode decodes a TypeCode encoded by MarshalTypeCode
+func UnmarshalTypeCode(data []byte) (TypeCode, error) {
+	return unmarshalTypeCode(giop.NewCDRUnmarshaller(data, binary.BigEndian))
+}
+
+// MarshalAny encodes an Any as its TypeCode followed by its value. The
+// encoding is CDR in big endian byte order.
+func MarshalAny(any *Any) ([]byte, error) {
+	m := giop.NewCDRMarshaller(binary.BigEndian)
+	if err := marshalAny(m, any); err != nil {
+		return nil, err
+	}
+	return m.Bytes(), nil
+}
+
+// UnmarshalAny decodes an Any encoded by MarshalAny. Values are decoded as
+// described for values of their TypeCode: structs as maps of their members,
+// sequences as slices and unions as UnionValue.
+func UnmarshalAny(data []byte) (*Any, error) {
+	return unmarshalAny(giop.NewCDRUnmarshaller(data, binary.BigEndian))
+}
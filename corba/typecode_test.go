@@ -0,0 +1,150 @@
+package corba_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/ifabos/go-corba/corba"
+)
+
+func TestTypeCodeEqualAndEquivalent(t *testing.T) {
+	long := basicTC(t, corba.TC_LONG)
+	counter, err := corba.CreateAliasTypeCode("IDL:Test/Counter:1.0", "Counter", long)
+	if err != nil {
+		t.Fatalf("CreateAliasTypeCode: %v", err)
+	}
+	if counter.Equal(long) || !counter.Equivalent(long) {
+		t.Errorf("alias of long: Equal = %v, Equivalent = %v, want false, true", counter.Equal(long), counter.Equivalent(long))
+	}
+
+	name, _ := corba.CreateStringTypeCode(8)
+	if name.Equal(basicTC(t, corba.TC_STRING)) || name.Length() != 8 {
+		t.Errorf("string<8> equals an unbounded string, or has bound %d", name.Length())
+	}
+
+	pair, _ := corba.CreateStructTypeCode("IDL:Test/Pair:1.0", "Pair")
+	pair.AddMember("first", counter)
+	pair.AddMember("second", name)
+
+	compact := pair.GetCompactTypeCode().(corba.TypeCodeImpl)
+	if compact.Name() != "" || compact.Id() != pair.Id() {
+		t.Errorf("compact TypeCode is named %q with ID %q", compact.Name(), compact.Id())
+	}
+	if member, _ := compact.MemberName(0); member != "" {
+		t.Errorf("compact member name = %q, want none", member)
+	}
+	if pair.Equal(compact) || !pair.Equivalent(compact) {
+		t.Errorf("compact TypeCode: Equal = %v, Equivalent = %v, want false, true", pair.Equal(compact), pair.Equivalent(compact))
+	}
+
+	fixed, _ := corba.CreateFixedTypeCode(5, 2)
+	other, _ := corba.CreateFixedTypeCode(5, 3)
+	if fixed.Equal(other) {
+		t.Error("fixed<5,2> equals fixed<5,3>")
+	}
+}
+
+func TestRecursiveTypeCode(t *testing.T) {
+	// struct Node { long value; sequence<Node> children; };
+	node, _ := corba.CreateStructTypeCode("IDL:Test/Node:1.0", "Node")
+	children, _ := corba.CreateSequenceTypeCode("IDL:Test/NodeSeq:1.0", "NodeSeq",
+		corba.CreateRecursiveTypeCode("IDL:Test/Node:1.0"), 0)
+	node.AddMember("value", basicTC(t, corba.TC_LONG))
+	node.AddMember("children", children)
+
+	data, err := corba.MarshalTypeCode(node)
+	if err != nil {
+		t.Fatalf("MarshalTypeCode: %v", err)
+	}
+	if !bytes.Contains(data, []byte{0xff, 0xff, 0xff, 0xff}) {
+		t.Error("recursive member is not encoded as an indirection")
+	}
+
+	decoded, err := corba.UnmarshalTypeCode(data)
+	if err != nil {
+		t.Fatalf("UnmarshalTypeCode: %v", err)
+	}
+	if !node.Equal(decoded) {
+		t.Errorf("decoded TypeCode %v differs from %v", decoded, node)
+	}
+	seq, _ := decoded.(corba.TypeCodeImpl).MemberType(1)
+	element, _ := seq.(corba.TypeCodeImpl).ContentType()
+	if element != decoded {
+		t.Error("decoded sequence element is not the enclosing struct")
+	}
+
+	tree := map[string]interface{}{
+		"value": int32(1),
+		"children": []interface{}{
+			map[string]interface{}{"value": int32(2), "children": []interface{}{}},
+		},
+	}
+	any, err := corba.NewAnyWithTypeCode(node, tree)
+	if err != nil {
+		t.Fatalf("NewAnyWithTypeCode: %v", err)
+	}
+	data, err = corba.MarshalAny(any)
+	if err != nil {
+		t.Fatalf("MarshalAny: %v", err)
+	}
+	got, err := corba.UnmarshalAny(data)
+	if err != nil {
+		t.Fatalf("UnmarshalAny: %v", err)
+	}
+	if !got.Equal(any) || !reflect.DeepEqual(got.Value(), tree) {
+		t.Errorf("round trip = %v, want %v", got.Value(), tree)
+	}
+}
+
+func TestAnyValuesOnTheWire(t *testing.T) {
+	long := basicTC(t, corba.TC_LONG)
+	shape, _ := corba.CreateUnionTypeCode("IDL:Test/WireShape:1.0", "WireShape", long)
+	shape.AddMember("radius", int32(1), basicTC(t, corba.TC_DOUBLE))
+	shape.AddMember("other", int32(0), basicTC(t, corba.TC_STRING))
+	shape.SetDefaultMember(1)
+	triple, _ := corba.CreateArrayTypeCode("IDL:Test/Triple:1.0", "Triple", long, 3)
+	money, _ := corba.CreateFixedTypeCode(6, 2)
+
+	for _, tc := range []struct {
+		name  string
+		tc    corba.TypeCode
+		value interface{}
+		want  interface{}
+	}{
+		{"union member", shape, corba.UnionValue{Discriminant: int32(1), Value: 2.5}, nil},
+		{"union default", shape, corba.UnionValue{Discriminant: int32(7), Value: "blob"}, nil},
+		{"array", triple, []interface{}{int32(1), int32(2), int32(3)}, nil},
+		{"fixed", money, "-1234.5", "-1234.50"},
+		{"any", basicTC(t, corba.TC_ANY), mustAny(t, long, int32(9)), nil},
+		{"TypeCode", basicTC(t, corba.TC_TYPECODE), corba.TypeCode(triple), nil},
+	} {
+		any, err := corba.NewAnyWithTypeCode(tc.tc, tc.value)
+		if err != nil {
+			t.Fatalf("%s: NewAnyWithTypeCode: %v", tc.name, err)
+		}
+		data, err := corba.MarshalAny(any)
+		if err != nil {
+			t.Fatalf("%s: MarshalAny: %v", tc.name, err)
+		}
+		got, err := corba.UnmarshalAny(data)
+		if err != nil {
+			t.Fatalf("%s: UnmarshalAny: %v", tc.name, err)
+		}
+		if !got.Equal(any) {
+			t.Errorf("%s: round trip = %v, want %v", tc.name, got.Value(), tc.value)
+		}
+		if tc.want != nil && got.Value() != tc.want {
+			t.Errorf("%s: decoded %#v, want %#v", tc.name, got.Value(), tc.want)
+		}
+	}
+}
+
+func mustAny(t *testing.T, tc corba.TypeCode, value interface{}) *corba.Any {
+	t.Helper()
+	any, err := corba.NewAnyWithTypeCode(tc, value)
+	if err != nil {
+		t.Fatalf("NewAnyWithTypeCode: %v", err)
+	}
+	return any
+}
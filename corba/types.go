@@ -2,10 +2,14 @@
 package corba
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"reflect"
 	"sync"
+
+	"github.com/ifabos/go-corba/giop"
 )
 
 // Common type management errors
@@ -171,6 +175,14 @@ type TypeCodeImpl interface {
 
 	// Length returns the bound for strings, sequences, arrays
 	Length() int
+
+	// Equivalent reports whether a TypeCode describes the same type, looking
+	// through aliases and ignoring names
+	Equivalent(TypeCode) bool
+
+	// GetCompactTypeCode returns the TypeCode with names and member names
+	// removed
+	GetCompactTypeCode() TypeCode
 }
 
 // Any represents the CORBA any type, which can hold any CORBA type
@@ -239,6 +251,25 @@ func (a *Any) String() string {
 	return fmt.Sprintf("Any(%s, %v)", a.typeCode.String(), a.value)
 }
 
+// Equal reports whether another Any holds the same value of an equivalent
+// type. Values are compared in their CDR representation, so that a value
+// held as a Go struct equals the same value held as a map of its members.
+func (a *Any) Equal(other *Any) bool {
+	if a == nil || other == nil {
+		return a == other
+	}
+	if !equivalentTypeCodes(a.typeCode, other.typeCode) {
+		return false
+	}
+
+	am := giop.NewCDRMarshaller(binary.BigEndian)
+	om := giop.NewCDRMarshaller(binary.BigEndian)
+	if marshalValue(am, a.typeCode, a.value) != nil || marshalValue(om, other.typeCode, other.value) != nil {
+		return reflect.DeepEqual(a.value, other.value)
+	}
+	return bytes.Equal(am.Bytes(), om.Bytes())
+}
+
 // TypeCodeRegistry manages TypeCode instances
 type TypeCodeRegistry struct {
 	mu             sync.RWMutex
@@ -284,6 +315,11 @@ func (r *TypeCodeRegistry) initializeBasicTypes() {
 		{TC_STRING, DK_STRING, "IDL:omg.org/CORBA/String:1.0", "string", reflect.TypeOf("")},
 		{TC_LONGLONG, DK_PRIMITIVE, "IDL:omg.org/CORBA/LongLong:1.0", "long long", reflect.TypeOf(int64(0))},
 		{TC_ULONGLONG, DK_PRIMITIVE, "IDL:omg.org/CORBA/ULongLong:1.0", "unsigned long long", reflect.TypeOf(uint64(0))},
+		{TC_WCHAR, DK_PRIMITIVE, "IDL:omg.org/CORBA/WChar:1.0", "wchar", reflect.TypeOf(rune(0))},
+		{TC_WSTRING, DK_WSTRING, "IDL:omg.org/CORBA/WString:1.0", "wstring", reflect.TypeOf("")},
+		{TC_NULL, DK_PRIMITIVE, "IDL:omg.org/CORBA/Null:1.0", "null", nil},
+		{TC_VOID, DK_PRIMITIVE, "IDL:omg.org/CORBA/Void:1.0", "void", nil},
+		{TC_TYPECODE, DK_PRIMITIVE, "IDL:omg.org/CORBA/TypeCode:1.0", "TypeCode", reflect.TypeOf((*TypeCode)(nil)).Elem()},
 		// Add TC_ANY with proper reflection of *Any type
 		{TC_ANY, DK_PRIMITIVE, "IDL:omg.org/CORBA/Any:1.0", "any", reflect.TypeOf((*Any)(nil))},
 	}
@@ -302,6 +338,11 @@ func (r *TypeCodeRegistry) initializeBasicTypes() {
 		r.basicTypeCodes[p.kind] = tc
 		r.customTypes[p.id] = tc
 	}
+
+	// References to objects of any interface
+	object := newObjectRefTypeCode("IDL:omg.org/CORBA/Object:1.0", "Object")
+	r.basicTypeCodes[TC_OBJREF] = object
+	r.customTypes[object.id] = object
 }
 
 // GetBasicTypeCode returns a TypeCode for a basic CORBA type
@@ -355,21 +396,31 @@ func (r *TypeCodeRegistry) GetOrCreateStructTypeCode(id string, name string) (*s
 		return nil, fmt.Errorf("TypeCode with ID %s exists but is not a struct", id)
 	}
 
-	stc := &structTypeCode{
-		typeCodeBase: typeCodeBase{
-			id:   id,
-			name: name,
-			kind: DK_STRUCT,
-		},
-		tcKind:      TC_STRUCT,
-		members:     make([]StructMember, 0),
-		memberTypes: make([]TypeCode, 0),
-	}
+	stc := newStructTypeCode(id, name, TC_STRUCT)
 
 	r.customTypes[id] = stc
 	return stc, nil
 }
 
+// GetOrCreateExceptionTypeCode creates a new exception TypeCode if it doesn't
+// exist. Exceptions have members like structs.
+func (r *TypeCodeRegistry) GetOrCreateExceptionTypeCode(id string, name string) (*structTypeCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tc, exists := r.customTypes[id]; exists {
+		if etc, ok := tc.(*structTypeCode); ok && etc.tcKind == TC_EXCEPT {
+			return etc, nil
+		}
+		return nil, fmt.Errorf("TypeCode with ID %s exists but is not an exception", id)
+	}
+
+	etc := newStructTypeCode(id, name, TC_EXCEPT)
+
+	r.customTypes[id] = etc
+	return etc, nil
+}
+
 // GetOrCreateSequenceTypeCode creates a new sequence TypeCode
 func (r *TypeCodeRegistry) GetOrCreateSequenceTypeCode(id string, name string, elementType TypeCode, bound int) (*sequenceTypeCode, error) {
 	r.mu.Lock()
@@ -382,16 +433,7 @@ func (r *TypeCodeRegistry) GetOrCreateSequenceTypeCode(id string, name string, e
 		return nil, fmt.Errorf("TypeCode with ID %s exists but is not a sequence", id)
 	}
 
-	stc := &sequenceTypeCode{
-		typeCodeBase: typeCodeBase{
-			id:   id,
-			name: name,
-			kind: DK_SEQUENCE,
-		},
-		tcKind:      TC_SEQUENCE,
-		elementType: elementType,
-		bound:       bound,
-	}
+	stc := newSequenceTypeCode(id, name, elementType, bound)
 
 	r.customTypes[id] = stc
 	return stc, nil
@@ -409,15 +451,7 @@ func (r *TypeCodeRegistry) GetOrCreateEnumTypeCode(id string, name string) (*enu
 		return nil, fmt.Errorf("TypeCode with ID %s exists but is not an enum", id)
 	}
 
-	etc := &enumTypeCode{
-		typeCodeBase: typeCodeBase{
-			id:   id,
-			name: name,
-			kind: DK_ENUM,
-		},
-		tcKind:  TC_ENUM,
-		members: make([]string, 0),
-	}
+	etc := newEnumTypeCode(id, name)
 
 	r.customTypes[id] = etc
 	return etc, nil
@@ -435,23 +469,98 @@ func (r *TypeCodeRegistry) GetOrCreateUnionTypeCode(id string, name string, disc
 		return nil, fmt.Errorf("TypeCode with ID %s exists but is not a union", id)
 	}
 
-	utc := &unionTypeCode{
-		typeCodeBase: typeCodeBase{
-			id:   id,
-			name: name,
-			kind: DK_UNION,
-		},
-		tcKind:            TC_UNION,
-		discriminatorType: discriminatorType,
-		members:           make([]UnionMember, 0),
-		memberTypes:       make([]TypeCode, 0),
-		defaultIndex:      -1,
-	}
+	utc := newUnionTypeCode(id, name, discriminatorType)
 
 	r.customTypes[id] = utc
 	return utc, nil
 }
 
+// GetOrCreateArrayTypeCode creates a new array TypeCode
+func (r *TypeCodeRegistry) GetOrCreateArrayTypeCode(id string, name string, elementType TypeCode, length int) (*arrayTypeCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tc, exists := r.customTypes[id]; exists {
+		if atc, ok := tc.(*arrayTypeCode); ok {
+			return atc, nil
+		}
+		return nil, fmt.Errorf("TypeCode with ID %s exists but is not an array", id)
+	}
+
+	atc := newArrayTypeCode(id, name, elementType, length)
+
+	r.customTypes[id] = atc
+	return atc, nil
+}
+
+// GetOrCreateAliasTypeCode creates a new alias TypeCode
+func (r *TypeCodeRegistry) GetOrCreateAliasTypeCode(id string, name string, originalType TypeCode) (*aliasTypeCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tc, exists := r.customTypes[id]; exists {
+		if atc, ok := tc.(*aliasTypeCode); ok && atc.tcKind == TC_ALIAS {
+			return atc, nil
+		}
+		return nil, fmt.Errorf("TypeCode with ID %s exists but is not an alias", id)
+	}
+
+	atc := newAliasTypeCode(id, name, originalType, TC_ALIAS)
+	r.customTypes[id] = atc
+	return atc, nil
+}
+
+// GetOrCreateValueBoxTypeCode creates a new value box TypeCode
+func (r *TypeCodeRegistry) GetOrCreateValueBoxTypeCode(id string, name string, boxedType TypeCode) (*aliasTypeCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tc, exists := r.customTypes[id]; exists {
+		if btc, ok := tc.(*aliasTypeCode); ok && btc.tcKind == TC_VALUE_BOX {
+			return btc, nil
+		}
+		return nil, fmt.Errorf("TypeCode with ID %s exists but is not a value box", id)
+	}
+
+	btc := newAliasTypeCode(id, name, boxedType, TC_VALUE_BOX)
+	r.customTypes[id] = btc
+	return btc, nil
+}
+
+// GetOrCreateObjectRefTypeCode creates a new object reference TypeCode
+func (r *TypeCodeRegistry) GetOrCreateObjectRefTypeCode(id string, name string) (*objectRefTypeCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tc, exists := r.customTypes[id]; exists {
+		if otc, ok := tc.(*objectRefTypeCode); ok {
+			return otc, nil
+		}
+		return nil, fmt.Errorf("TypeCode with ID %s exists but is not an interface", id)
+	}
+
+	otc := newObjectRefTypeCode(id, name)
+	r.customTypes[id] = otc
+	return otc, nil
+}
+
+// GetOrCreateValueTypeCode creates a new value type TypeCode
+func (r *TypeCodeRegistry) GetOrCreateValueTypeCode(id string, name string, modifier int16, concreteBase TypeCode) (*valueTypeCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tc, exists := r.customTypes[id]; exists {
+		if vtc, ok := tc.(*valueTypeCode); ok {
+			return vtc, nil
+		}
+		return nil, fmt.Errorf("TypeCode with ID %s exists but is not a value type", id)
+	}
+
+	vtc := newValueTypeCode(id, name, modifier, concreteBase)
+	r.customTypes[id] = vtc
+	return vtc, nil
+}
+
 // basicTypeCode represents a basic CORBA type
 type basicTypeCode struct {
 	typeCodeBase
@@ -523,6 +632,25 @@ type structTypeCode struct {
 	mu          sync.RWMutex
 }
 
+// newStructTypeCode creates a struct or exception TypeCode without members
+func newStructTypeCode(id string, name string, kind TCKind) *structTypeCode {
+	dk := DK_STRUCT
+	if kind == TC_EXCEPT {
+		dk = DK_EXCEPTION
+	}
+
+	return &structTypeCode{
+		typeCodeBase: typeCodeBase{
+			id:   id,
+			name: name,
+			kind: dk,
+		},
+		tcKind:      kind,
+		members:     make([]StructMember, 0),
+		memberTypes: make([]TypeCode, 0),
+	}
+}
+
 // TCKind returns the TCKind of this type
 func (s *structTypeCode) TCKind() TCKind {
 	return s.tcKind
@@ -625,6 +753,21 @@ type sequenceTypeCode struct {
 	bound       int
 }
 
+// newSequenceTypeCode creates a sequence TypeCode. A bound of 0 means the
+// sequence is unbounded.
+func newSequenceTypeCode(id string, name string, elementType TypeCode, bound int) *sequenceTypeCode {
+	return &sequenceTypeCode{
+		typeCodeBase: typeCodeBase{
+			id:   id,
+			name: name,
+			kind: DK_SEQUENCE,
+		},
+		tcKind:      TC_SEQUENCE,
+		elementType: elementType,
+		bound:       bound,
+	}
+}
+
 // TCKind returns the TCKind of this type
 func (s *sequenceTypeCode) TCKind() TCKind {
 	return s.tcKind
@@ -685,6 +828,88 @@ func (s *sequenceTypeCode) Length() int {
 	return s.bound
 }
 
+// arrayTypeCode represents an array type. Its length is the number of
+// elements of every value, where a sequence has a bound.
+type arrayTypeCode struct {
+	sequenceTypeCode
+}
+
+// newArrayTypeCode creates an array TypeCode
+func newArrayTypeCode(id string, name string, elementType TypeCode, length int) *arrayTypeCode {
+	atc := &arrayTypeCode{sequenceTypeCode: *newSequenceTypeCode(id, name, elementType, length)}
+	atc.kind = DK_ARRAY
+	atc.tcKind = TC_ARRAY
+	return atc
+}
+
+// Param gets a parameter value by index
+func (a *arrayTypeCode) Param(index int) (interface{}, error) {
+	if index == 0 {
+		return a.elementType, nil
+	} else if index == 1 {
+		return a.bound, nil
+	}
+	return nil, fmt.Errorf("parameter index %d out of range", index)
+}
+
+// MemberName returns the name of a member
+func (a *arrayTypeCode) MemberName(index int) (string, error) {
+	return "", errors.New("array types have no named members")
+}
+
+// MemberType returns the type of a member
+func (a *arrayTypeCode) MemberType(index int) (TypeCode, error) {
+	return nil, errors.New("array types have no members")
+}
+
+// fixedTypeCode represents a fixed point decimal type with a number of
+// significant digits, of which scale are after the decimal point
+type fixedTypeCode struct {
+	basicTypeCode
+	digits uint16
+	scale  uint16
+}
+
+// newFixedTypeCode creates a fixed point TypeCode. Fixed types are anonymous.
+func newFixedTypeCode(digits, scale uint16) *fixedTypeCode {
+	return &fixedTypeCode{
+		basicTypeCode: basicTypeCode{
+			typeCodeBase: typeCodeBase{
+				name: fmt.Sprintf("fixed<%d,%d>", digits, scale),
+				kind: DK_FIXED,
+			},
+			tcKind: TC_FIXED,
+		},
+		digits: digits,
+		scale:  scale,
+	}
+}
+
+// FixedDigits returns the number of significant digits
+func (f *fixedTypeCode) FixedDigits() uint16 {
+	return f.digits
+}
+
+// FixedScale returns the number of digits after the decimal point
+func (f *fixedTypeCode) FixedScale() uint16 {
+	return f.scale
+}
+
+// Param gets a parameter value by index
+func (f *fixedTypeCode) Param(index int) (interface{}, error) {
+	if index == 0 {
+		return f.digits, nil
+	} else if index == 1 {
+		return f.scale, nil
+	}
+	return nil, fmt.Errorf("parameter index %d out of range", index)
+}
+
+// ParameterCount returns the number of parameters
+func (f *fixedTypeCode) ParameterCount() int {
+	return 2 // digits and scale
+}
+
 // enumTypeCode represents an enum type
 type enumTypeCode struct {
 	typeCodeBase
@@ -693,6 +918,19 @@ type enumTypeCode struct {
 	mu      sync.RWMutex
 }
 
+// newEnumTypeCode creates an enum TypeCode without members
+func newEnumTypeCode(id string, name string) *enumTypeCode {
+	return &enumTypeCode{
+		typeCodeBase: typeCodeBase{
+			id:   id,
+			name: name,
+			kind: DK_ENUM,
+		},
+		tcKind:  TC_ENUM,
+		members: make([]string, 0),
+	}
+}
+
 // TCKind returns the TCKind of this type
 func (e *enumTypeCode) TCKind() TCKind {
 	return e.tcKind
@@ -788,6 +1026,22 @@ type unionTypeCode struct {
 	mu                sync.RWMutex
 }
 
+// newUnionTypeCode creates a union TypeCode without members
+func newUnionTypeCode(id string, name string, discriminatorType TypeCode) *unionTypeCode {
+	return &unionTypeCode{
+		typeCodeBase: typeCodeBase{
+			id:   id,
+			name: name,
+			kind: DK_UNION,
+		},
+		tcKind:            TC_UNION,
+		discriminatorType: discriminatorType,
+		members:           make([]UnionMember, 0),
+		memberTypes:       make([]TypeCode, 0),
+		defaultIndex:      -1,
+	}
+}
+
 // TCKind returns the TCKind of this type
 func (u *unionTypeCode) TCKind() TCKind {
 	return u.tcKind
@@ -994,8 +1248,16 @@ func typeCodeFromReflectValue(v reflect.Value) (TypeCode, error) {
 
 // validateTypeCodeMatch checks if a value matches a TypeCode
 func validateTypeCodeMatch(tc TypeCode, value interface{}) bool {
+	// Values of an alias are values of the original type
+	tc = unaliasTypeCode(tc)
+
 	if value == nil {
-		return tc.Kind() == DK_PRIMITIVE // Any primitive could be nil
+		// Any primitive could be nil, as can references and values
+		switch tc.Kind() {
+		case DK_PRIMITIVE, DK_INTERFACE, DK_VALUE, DK_VALUE_BOX:
+			return true
+		}
+		return false
 	}
 
 	v := reflect.ValueOf(value)
@@ -1003,9 +1265,13 @@ func validateTypeCodeMatch(tc TypeCode, value interface{}) bool {
 	// Handle special cases
 	if tc.Kind() == DK_PRIMITIVE {
 		if tcImpl, ok := tc.(TypeCodeImpl); ok {
-			if tcImpl.TCKind() == TC_ANY {
+			switch tcImpl.TCKind() {
+			case TC_ANY:
 				_, ok := value.(*Any)
 				return ok
+			case TC_TYPECODE:
+				_, ok := value.(TypeCode)
+				return ok
 			}
 		}
 	}
@@ -1022,7 +1288,7 @@ func validateTypeCodeMatch(tc TypeCode, value interface{}) bool {
 		case reflect.Int16:
 			return tc.Name() == "short"
 		case reflect.Int32:
-			return tc.Name() == "long"
+			return tc.Name() == "long" || tc.Name() == "wchar"
 		case reflect.Int64:
 			return tc.Name() == "long long"
 		case reflect.Uint8:
@@ -1038,15 +1304,33 @@ func validateTypeCodeMatch(tc TypeCode, value interface{}) bool {
 		case reflect.Float64:
 			return tc.Name() == "double"
 		}
-	case DK_STRING:
+	case DK_STRING, DK_WSTRING:
+		if v.Kind() != reflect.String {
+			return false
+		}
+		if tcImpl, ok := tc.(TypeCodeImpl); ok && tcImpl.Length() > 0 {
+			return len([]rune(v.String())) <= tcImpl.Length()
+		}
+		return true
+	case DK_FIXED:
 		return v.Kind() == reflect.String
-	case DK_SEQUENCE:
+	case DK_INTERFACE:
+		_, ok := value.(*ObjectRef)
+		return ok
+	case DK_VALUE, DK_VALUE_BOX:
+		// Values are held in Go values of any type
+		return true
+	case DK_SEQUENCE, DK_ARRAY:
 		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
 			return false
 		}
 
 		// Check element type compatibility
 		if tcImpl, ok := tc.(TypeCodeImpl); ok {
+			if bound := tcImpl.Length(); bound > 0 && (v.Len() > bound || tc.Kind() == DK_ARRAY && v.Len() != bound) {
+				return false
+			}
+
 			elemTC, err := tcImpl.ContentType()
 			if err != nil {
 				return false
@@ -1058,11 +1342,12 @@ func validateTypeCodeMatch(tc TypeCode, value interface{}) bool {
 			}
 			return true // Empty slice/array, assume compatible
 		}
-	case DK_STRUCT:
-		return v.Kind() == reflect.Struct
+	case DK_STRUCT, DK_EXCEPTION:
+		// Members are held in Go structs or, when built dynamically, in maps
+		return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
 	case DK_ENUM:
-		// Enums are usually represented as ints in Go
-		return v.Kind() == reflect.Int
+		// Enums are represented by their ordinal
+		return v.CanInt() || v.CanUint()
 	case DK_UNION:
 		// Unions are complex, would need specific validation
 		return true // Assume valid for now
@@ -1091,11 +1376,33 @@ func CreateStructTypeCode(id string, name string) (*structTypeCode, error) {
 	return globalTypeRegistry.GetOrCreateStructTypeCode(id, name)
 }
 
+// CreateExceptionTypeCode creates and registers an exception TypeCode
+func CreateExceptionTypeCode(id string, name string) (*structTypeCode, error) {
+	return globalTypeRegistry.GetOrCreateExceptionTypeCode(id, name)
+}
+
 // CreateSequenceTypeCode creates and registers a sequence TypeCode
 func CreateSequenceTypeCode(id string, name string, elementType TypeCode, bound int) (*sequenceTypeCode, error) {
 	return globalTypeRegistry.GetOrCreateSequenceTypeCode(id, name, elementType, bound)
 }
 
+// CreateArrayTypeCode creates and registers an array TypeCode
+func CreateArrayTypeCode(id string, name string, elementType TypeCode, length int) (*arrayTypeCode, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("%w: array length must be positive", ErrInvalidTypeCode)
+	}
+	return globalTypeRegistry.GetOrCreateArrayTypeCode(id, name, elementType, length)
+}
+
+// CreateFixedTypeCode creates a TypeCode for fixed point decimals of digits
+// significant digits, scale of which are after the decimal point
+func CreateFixedTypeCode(digits, scale uint16) (TypeCodeImpl, error) {
+	if digits == 0 || digits > 31 || scale > digits {
+		return nil, fmt.Errorf("%w: fixed<%d,%d>", ErrInvalidTypeCode, digits, scale)
+	}
+	return newFixedTypeCode(digits, scale), nil
+}
+
 // CreateEnumTypeCode creates and registers an enum TypeCode
 func CreateEnumTypeCode(id string, name string) (*enumTypeCode, error) {
 	return globalTypeRegistry.GetOrCreateEnumTypeCode(id, name)
@@ -1106,6 +1413,51 @@ func CreateUnionTypeCode(id string, name string, discriminatorType TypeCode) (*u
 	return globalTypeRegistry.GetOrCreateUnionTypeCode(id, name, discriminatorType)
 }
 
+// CreateAliasTypeCode creates and registers a TypeCode for a typedef
+func CreateAliasTypeCode(id string, name string, originalType TypeCode) (*aliasTypeCode, error) {
+	return globalTypeRegistry.GetOrCreateAliasTypeCode(id, name, originalType)
+}
+
+// CreateValueBoxTypeCode creates and registers a value box TypeCode
+func CreateValueBoxTypeCode(id string, name string, boxedType TypeCode) (*aliasTypeCode, error) {
+	return globalTypeRegistry.GetOrCreateValueBoxTypeCode(id, name, boxedType)
+}
+
+// CreateObjectRefTypeCode creates and registers a TypeCode for references to
+// objects of an interface
+func CreateObjectRefTypeCode(id string, name string) (*objectRefTypeCode, error) {
+	return globalTypeRegistry.GetOrCreateObjectRefTypeCode(id, name)
+}
+
+// CreateValueTypeCode creates and registers a value type TypeCode. The
+// concrete base type may be nil.
+func CreateValueTypeCode(id string, name string, modifier int16, concreteBase TypeCode) (*valueTypeCode, error) {
+	if modifier < VM_NONE || modifier > VM_TRUNCATABLE {
+		return nil, fmt.Errorf("%w: value modifier %d", ErrInvalidTypeCode, modifier)
+	}
+	return globalTypeRegistry.GetOrCreateValueTypeCode(id, name, modifier, concreteBase)
+}
+
+// CreateStringTypeCode returns a TypeCode for strings of at most bound
+// characters, or for unbounded strings if bound is 0
+func CreateStringTypeCode(bound int) (TypeCodeImpl, error) {
+	return boundedStringTypeCode(TC_STRING, bound)
+}
+
+// CreateWStringTypeCode returns a TypeCode for wide strings of at most bound
+// characters, or for unbounded wide strings if bound is 0
+func CreateWStringTypeCode(bound int) (TypeCodeImpl, error) {
+	return boundedStringTypeCode(TC_WSTRING, bound)
+}
+
+// CreateRecursiveTypeCode returns a placeholder for the struct, union or
+// value type with a repository ID, to be used as a member type within the
+// definition of that type. The placeholder stands for the type once it is
+// registered.
+func CreateRecursiveTypeCode(id string) TypeCodeImpl {
+	return &recursiveTypeCode{id: id}
+}
+
 // CORBA to Go type conversion helpers
 
 // CORBAToGo converts a CORBA value to a Go value
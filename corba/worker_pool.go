@@ -0,0 +1,184 @@
+// Package corba provides a CORBA implementation in Go
+package corba
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultMaxConcurrentRequests is the default number of requests an ORB
+// dispatches concurrently across all of its connections
+const DefaultMaxConcurrentRequests = 64
+
+// workerQueueSize is the number of requests that may wait for a free worker
+// before readers of incoming connections are blocked
+const workerQueueSize = 1024
+
+// workerPool runs server-side request dispatch on a bounded set of goroutines.
+// Workers are started on first use, and stopped when the ORB shuts down.
+type workerPool struct {
+	mu      sync.Mutex
+	jobs    chan func() // Queue of the running workers; nil while they are stopped
+	size    int         // Desired number of workers
+	running int         // Number of workers started and not yet told to stop
+
+	senders sync.WaitGroup // Goroutines sending on jobs
+	workers sync.WaitGroup // Workers started and not yet stopped
+}
+
+// newWorkerPool creates a worker pool with the given number of workers
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{
+		size: size,
+	}
+}
+
+// submit queues a job, blocking while the queue is full
+func (wp *workerPool) submit(job func()) {
+	wp.mu.Lock()
+	if wp.jobs == nil {
+		wp.jobs = make(chan func(), workerQueueSize)
+	}
+	wp.adjust()
+	jobs := wp.jobs
+	wp.senders.Add(1)
+	wp.mu.Unlock()
+
+	jobs <- job
+	wp.senders.Done()
+}
+
+// resize changes the number of workers. Surplus workers stop once they have
+// finished their current job.
+func (wp *workerPool) resize(size int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.size = size
+	if wp.running > 0 {
+		wp.adjust()
+	}
+}
+
+// getSize returns the configured number of workers
+func (wp *workerPool) getSize() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	return wp.size
+}
+
+// adjust starts or stops workers to match the configured size. The caller must hold the lock.
+func (wp *workerPool) adjust() {
+	for wp.running < wp.size {
+		wp.running++
+		wp.workers.Add(1)
+		go wp.worker(wp.jobs)
+	}
+	for ; wp.running > wp.size; wp.running-- {
+		// A nil job tells one worker to exit
+		wp.signalStop()
+	}
+}
+
+// signalStop sends a nil job, which tells one worker to exit. The caller must
+// hold the lock.
+func (wp *workerPool) signalStop() {
+	jobs := wp.jobs
+	wp.senders.Add(1)
+	go func() {
+		jobs <- nil
+		wp.senders.Done()
+	}()
+}
+
+// stop closes the queue once the jobs being submitted are queued, and waits
+// until the workers have run the queued jobs and exited, or ctx is done.
+// Workers are started afresh if jobs are submitted afterwards.
+func (wp *workerPool) stop(ctx context.Context) error {
+	wp.mu.Lock()
+	jobs := wp.jobs
+	wp.jobs = nil
+	wp.running = 0
+	wp.mu.Unlock()
+
+	if jobs == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wp.senders.Wait()
+		close(jobs)
+		wp.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pending returns the number of jobs waiting for a worker
+func (wp *workerPool) pending() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	return len(wp.jobs)
+}
+
+// performOne runs one waiting job on the calling goroutine, if there is one
+func (wp *workerPool) performOne() {
+	wp.mu.Lock()
+	jobs := wp.jobs
+	wp.mu.Unlock()
+
+	select {
+	case job, ok := <-jobs:
+		if !ok {
+			return
+		}
+		if job == nil {
+			// Leave the stop signal for a worker
+			wp.mu.Lock()
+			if wp.jobs == jobs {
+				wp.signalStop()
+			}
+			wp.mu.Unlock()
+			return
+		}
+		job()
+	default:
+	}
+}
+
+// worker runs the jobs of a queue until it receives a nil job or the queue is closed
+func (wp *workerPool) worker(jobs <-chan func()) {
+	defer wp.workers.Done()
+
+	for job := range jobs {
+		if job == nil {
+			return
+		}
+		job()
+	}
+}
+
+// SetMaxConcurrentRequests sets how many requests the ORB dispatches
+// concurrently across all connections. Further requests wait for a free worker.
+func (orb *ORB) SetMaxConcurrentRequests(n int) error {
+	if n < 1 {
+		return fmt.Errorf("invalid number of concurrent requests: %d", n)
+	}
+	orb.workers.resize(n)
+	return nil
+}
+
+// MaxConcurrentRequests returns how many requests the ORB dispatches concurrently
+func (orb *ORB) MaxConcurrentRequests() int {
+	return orb.workers.getSize()
+}
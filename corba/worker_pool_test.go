@@ -0,0 +1,264 @@
+package corba_test
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ifabos/go-corba/corba"
+	"github.com/ifabos/go-corba/giop"
+)
+
+// concurrencyServant records how many calls run at the same time. Calls to
+// "block" wait until the gate is closed.
+type concurrencyServant struct {
+	mu      sync.Mutex
+	current int
+	max     int
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func newConcurrencyServant() *concurrencyServant {
+	return &concurrencyServant{
+		started: make(chan struct{}, 16),
+		gate:    make(chan struct{}),
+	}
+}
+
+func (s *concurrencyServant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	s.mu.Lock()
+	s.current++
+	if s.current > s.max {
+		s.max = s.current
+	}
+	s.mu.Unlock()
+
+	s.started <- struct{}{}
+	if methodName == "block" {
+		<-s.gate
+	}
+
+	s.mu.Lock()
+	s.current--
+	s.mu.Unlock()
+	return nil, nil
+}
+
+func (s *concurrencyServant) maxConcurrent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.max
+}
+
+// sendRawRequest writes a GIOP request directly to a connection
+func sendRawRequest(t *testing.T, conn net.Conn, requestID uint32, objectKey []byte, operation string) {
+	t.Helper()
+
+	data, err := giop.MarshalGIOPMessage(giop.NewRequestMessage(requestID, objectKey, operation, true))
+	if err != nil {
+		t.Fatalf("MarshalGIOPMessage: %v", err)
+	}
+	if _, err := conn.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+// readRawMessage reads one GIOP message from a connection
+func readRawMessage(t *testing.T, conn net.Conn) *giop.Message {
+	t.Helper()
+
+	headerBuf := make([]byte, 12)
+	if _, err := io.ReadFull(conn, headerBuf); err != nil {
+		t.Fatalf("reading message header: %v", err)
+	}
+	header, err := giop.NewCDRUnmarshaller(headerBuf, binary.BigEndian).ReadMessageHeader()
+	if err != nil {
+		t.Fatalf("ReadMessageHeader: %v", err)
+	}
+	body := make([]byte, header.MsgSize)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		t.Fatalf("reading message body: %v", err)
+	}
+	msg, err := giop.UnmarshalGIOPMessage(append(headerBuf, body...))
+	if err != nil {
+		t.Fatalf("UnmarshalGIOPMessage: %v", err)
+	}
+	return msg
+}
+
+// readRawReply reads one GIOP reply from a connection
+func readRawReply(t *testing.T, conn net.Conn) *giop.ReplyHeader {
+	t.Helper()
+
+	msg := readRawMessage(t, conn)
+	reply, ok := msg.Body.(*giop.ReplyHeader)
+	if !ok {
+		t.Fatalf("expected reply, got message type %d", msg.Header.MsgType)
+	}
+	return reply
+}
+
+// threadPolicyPOA serves a concurrencyServant from a POA with the given policies
+// and returns a raw connection to the server and the servant's object key
+func threadPolicyPOA(t *testing.T, name string, policies ...corba.POAPolicy) (*corba.POA, net.Conn, []byte, *concurrencyServant) {
+	t.Helper()
+
+	orb := corba.Init()
+	_, ep := poaTestServer(t, orb, name)
+
+	poa, err := orb.GetRootPOA().CreatePOA("Threads", nil, policies)
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	servant := newConcurrencyServant()
+	oid, err := poa.ActivateObject(servant)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	ref := poa.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0")
+
+	conn, err := corba.NewMemTransport().Connect(ep)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return poa, conn, ref.GetObjectKey(), servant
+}
+
+func TestConcurrentDispatchOnOneConnection(t *testing.T) {
+	_, conn, key, servant := threadPolicyPOA(t, "dispatch-concurrent")
+
+	// A blocked call must not hold up the next request on the same connection
+	sendRawRequest(t, conn, 1, key, "block")
+	<-servant.started
+	sendRawRequest(t, conn, 2, key, "ping")
+
+	if reply := readRawReply(t, conn); reply.RequestID != 2 {
+		t.Fatalf("first reply is for request %d, want 2", reply.RequestID)
+	}
+	close(servant.gate)
+	if reply := readRawReply(t, conn); reply.RequestID != 1 {
+		t.Fatalf("second reply is for request %d, want 1", reply.RequestID)
+	}
+	if servant.maxConcurrent() != 2 {
+		t.Errorf("max concurrent calls = %d, want 2", servant.maxConcurrent())
+	}
+}
+
+func TestSingleThreadModelSerializes(t *testing.T) {
+	_, conn, key, servant := threadPolicyPOA(t, "dispatch-single",
+		corba.NewThreadPolicy(corba.SingleThreadModel))
+
+	sendRawRequest(t, conn, 1, key, "block")
+	<-servant.started
+	sendRawRequest(t, conn, 2, key, "ping")
+
+	// The second call waits for the first one to finish
+	select {
+	case <-servant.started:
+		t.Fatal("second call started while the first was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(servant.gate)
+	readRawReply(t, conn)
+	readRawReply(t, conn)
+	if servant.maxConcurrent() != 1 {
+		t.Errorf("max concurrent calls = %d, want 1", servant.maxConcurrent())
+	}
+}
+
+func TestPOAConcurrencyLimit(t *testing.T) {
+	poa, conn, key, servant := threadPolicyPOA(t, "dispatch-limit")
+	if err := poa.SetMaxConcurrentRequests(2); err != nil {
+		t.Fatalf("SetMaxConcurrentRequests: %v", err)
+	}
+
+	for id := uint32(1); id <= 4; id++ {
+		sendRawRequest(t, conn, id, key, "block")
+	}
+	<-servant.started
+	<-servant.started
+	select {
+	case <-servant.started:
+		t.Fatal("more calls started than the POA limit allows")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(servant.gate)
+	for i := 0; i < 4; i++ {
+		readRawReply(t, conn)
+	}
+	if servant.maxConcurrent() != 2 {
+		t.Errorf("max concurrent calls = %d, want 2", servant.maxConcurrent())
+	}
+}
+
+func TestORBConcurrencyLimit(t *testing.T) {
+	orb := corba.Init()
+	if orb.MaxConcurrentRequests() != corba.DefaultMaxConcurrentRequests {
+		t.Errorf("default limit = %d, want %d", orb.MaxConcurrentRequests(), corba.DefaultMaxConcurrentRequests)
+	}
+	if err := orb.SetMaxConcurrentRequests(0); err == nil {
+		t.Error("SetMaxConcurrentRequests(0) succeeded")
+	}
+	if err := orb.SetMaxConcurrentRequests(3); err != nil || orb.MaxConcurrentRequests() != 3 {
+		t.Errorf("SetMaxConcurrentRequests(3) = %v, limit %d", err, orb.MaxConcurrentRequests())
+	}
+}
+
+func TestBusyPOADoesNotStarveOthers(t *testing.T) {
+	orb := corba.Init()
+	if err := orb.SetMaxConcurrentRequests(2); err != nil {
+		t.Fatalf("SetMaxConcurrentRequests: %v", err)
+	}
+	_, ep := poaTestServer(t, orb, t.Name())
+
+	poa, err := orb.GetRootPOA().CreatePOA("Single", nil, []corba.POAPolicy{
+		corba.NewThreadPolicy(corba.SingleThreadModel),
+	})
+	if err != nil {
+		t.Fatalf("CreatePOA: %v", err)
+	}
+	busy := newConcurrencyServant()
+	oid, err := poa.ActivateObject(busy)
+	if err != nil {
+		t.Fatalf("ActivateObject: %v", err)
+	}
+	busyKey := poa.CreateReferenceWithId(oid, "IDL:Test/Echo:1.0").GetObjectKey()
+
+	other := newConcurrencyServant()
+	otherRef, err := orb.GetRootPOA().ServantToReference(other)
+	if err != nil {
+		t.Fatalf("ServantToReference: %v", err)
+	}
+
+	conn, err := corba.NewMemTransport().Connect(ep)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Requests waiting for the single-threaded POA must not take the workers
+	for id := uint32(1); id <= 3; id++ {
+		sendRawRequest(t, conn, id, busyKey, "block")
+	}
+	<-busy.started
+	sendRawRequest(t, conn, 4, otherRef.GetObjectKey(), "ping")
+	if reply := readRawReply(t, conn); reply.RequestID != 4 {
+		t.Fatalf("first reply is for request %d, want 4", reply.RequestID)
+	}
+
+	close(busy.gate)
+	for i := 0; i < 3; i++ {
+		readRawReply(t, conn)
+	}
+	if busy.maxConcurrent() != 1 {
+		t.Errorf("max concurrent calls = %d, want 1", busy.maxConcurrent())
+	}
+}
@@ -115,7 +115,7 @@ func InvokeDII() {
 	}
 
 	// Send without waiting for response
-	_, err = logRequest.Invoke()
+	err = logRequest.SendOneway()
 	if err != nil {
 		fmt.Printf("One-way invocation failed: %v\n", err)
 		os.Exit(1)
@@ -54,7 +54,7 @@ func NewDynamicCalculator() *DynamicCalculator {
 // It handles all incoming requests dynamically based on the operation name
 func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 	// Validate that the operation exists and has the right number of arguments
-	err := calc.ValidateOperation(request.Operation, request.Arguments)
+	err := calc.ValidateOperation(request.Operation, request.Args)
 	if err != nil {
 		request.SetException(err)
 		return err
@@ -64,14 +64,14 @@ func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 	switch request.Operation {
 	case "add":
 		// Convert arguments to float64
-		if len(request.Arguments) != 2 {
+		if len(request.Args) != 2 {
 			err := fmt.Errorf("add requires 2 arguments")
 			request.SetException(err)
 			return err
 		}
 
-		a, ok1 := request.Arguments[0].(float64)
-		b, ok2 := request.Arguments[1].(float64)
+		a, ok1 := request.Args[0].(float64)
+		b, ok2 := request.Args[1].(float64)
 
 		if !ok1 || !ok2 {
 			err := fmt.Errorf("arguments must be numbers")
@@ -85,14 +85,14 @@ func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 		return nil
 
 	case "subtract":
-		if len(request.Arguments) != 2 {
+		if len(request.Args) != 2 {
 			err := fmt.Errorf("subtract requires 2 arguments")
 			request.SetException(err)
 			return err
 		}
 
-		a, ok1 := request.Arguments[0].(float64)
-		b, ok2 := request.Arguments[1].(float64)
+		a, ok1 := request.Args[0].(float64)
+		b, ok2 := request.Args[1].(float64)
 
 		if !ok1 || !ok2 {
 			err := fmt.Errorf("arguments must be numbers")
@@ -105,14 +105,14 @@ func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 		return nil
 
 	case "multiply":
-		if len(request.Arguments) != 2 {
+		if len(request.Args) != 2 {
 			err := fmt.Errorf("multiply requires 2 arguments")
 			request.SetException(err)
 			return err
 		}
 
-		a, ok1 := request.Arguments[0].(float64)
-		b, ok2 := request.Arguments[1].(float64)
+		a, ok1 := request.Args[0].(float64)
+		b, ok2 := request.Args[1].(float64)
 
 		if !ok1 || !ok2 {
 			err := fmt.Errorf("arguments must be numbers")
@@ -125,14 +125,14 @@ func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 		return nil
 
 	case "divide":
-		if len(request.Arguments) != 2 {
+		if len(request.Args) != 2 {
 			err := fmt.Errorf("divide requires 2 arguments")
 			request.SetException(err)
 			return err
 		}
 
-		a, ok1 := request.Arguments[0].(float64)
-		b, ok2 := request.Arguments[1].(float64)
+		a, ok1 := request.Args[0].(float64)
+		b, ok2 := request.Args[1].(float64)
 
 		if !ok1 || !ok2 {
 			err := fmt.Errorf("arguments must be numbers")
@@ -151,14 +151,14 @@ func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 		return nil
 
 	case "store":
-		if len(request.Arguments) != 2 {
+		if len(request.Args) != 2 {
 			err := fmt.Errorf("store requires 2 arguments")
 			request.SetException(err)
 			return err
 		}
 
-		name, ok1 := request.Arguments[0].(string)
-		value, ok2 := request.Arguments[1].(float64)
+		name, ok1 := request.Args[0].(string)
+		value, ok2 := request.Args[1].(float64)
 
 		if !ok1 || !ok2 {
 			err := fmt.Errorf("invalid argument types")
@@ -171,13 +171,13 @@ func (calc *DynamicCalculator) Invoke(request *corba.ServerRequest) error {
 		return nil
 
 	case "retrieve":
-		if len(request.Arguments) != 1 {
+		if len(request.Args) != 1 {
 			err := fmt.Errorf("retrieve requires 1 argument")
 			request.SetException(err)
 			return err
 		}
 
-		name, ok := request.Arguments[0].(string)
+		name, ok := request.Args[0].(string)
 		if !ok {
 			err := fmt.Errorf("name must be a string")
 			request.SetException(err)
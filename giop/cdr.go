@@ -187,6 +187,12 @@ func (m *CDRMarshaller) WriteOctetSequence(value []byte) {
 	m.position += len(value)
 }
 
+// WriteRaw writes bytes as they are, without a length prefix or alignment
+func (m *CDRMarshaller) WriteRaw(data []byte) {
+	m.buffer.Write(data)
+	m.position += len(data)
+}
+
 // WriteServiceContext writes a service context
 func (m *CDRMarshaller) WriteServiceContext(ctx ServiceContext) {
 	m.WriteULong(ctx.ID)
@@ -525,6 +531,27 @@ func (u *CDRUnmarshaller) ReadOctetSequence() ([]byte, error) {
 	return buf, nil
 }
 
+// Len returns the number of bytes that have not been read yet
+func (u *CDRUnmarshaller) Len() int {
+	return u.reader.Len()
+}
+
+// Position returns the number of bytes read so far, including padding
+func (u *CDRUnmarshaller) Position() int {
+	return u.position
+}
+
+// Remaining returns the bytes that have not been read yet, or nil if there are none
+func (u *CDRUnmarshaller) Remaining() []byte {
+	if u.reader.Len() == 0 {
+		return nil
+	}
+	buf := make([]byte, u.reader.Len())
+	n, _ := io.ReadFull(u.reader, buf)
+	u.position += n
+	return buf
+}
+
 // ReadServiceContext reads a service context
 func (u *CDRUnmarshaller) ReadServiceContext() (ServiceContext, error) {
 	var ctx ServiceContext
@@ -822,14 +849,23 @@ func MarshalGIOPMessage(msg *Message) ([]byte, error) {
 		byteOrder = binary.LittleEndian
 	}
 
-	// Create a marshaller for the body
+	// Create a marshaller for the body. Alignment is relative to the start
+	// of the message, so the body starts after the 12-octet header.
 	bodyMarshaller := NewCDRMarshaller(byteOrder)
+	bodyMarshaller.position = messageHeaderSize
+	writeBody := func(body []byte) {
+		if len(body) > 0 && msg.Header.alignsBody() {
+			bodyMarshaller.align(8)
+		}
+		bodyMarshaller.WriteRaw(body)
+	}
 
 	// Marshal the message body based on the message type
 	switch msg.Header.MsgType {
 	case MsgRequest:
 		if requestHeader, ok := msg.Body.(*RequestHeader); ok {
 			bodyMarshaller.WriteRequestHeader(requestHeader)
+			writeBody(requestHeader.Body)
 		} else {
 			return nil, fmt.Errorf("body is not a RequestHeader")
 		}
@@ -837,6 +873,7 @@ func MarshalGIOPMessage(msg *Message) ([]byte, error) {
 	case MsgReply:
 		if replyHeader, ok := msg.Body.(*ReplyHeader); ok {
 			bodyMarshaller.WriteReplyHeader(replyHeader)
+			writeBody(replyHeader.Body)
 		} else {
 			return nil, fmt.Errorf("body is not a ReplyHeader")
 		}
@@ -860,6 +897,7 @@ func MarshalGIOPMessage(msg *Message) ([]byte, error) {
 		if locateHeader, ok := msg.Body.(*LocateReplyHeader); ok {
 			bodyMarshaller.WriteULong(locateHeader.RequestID)
 			bodyMarshaller.WriteULong(locateHeader.Status)
+			writeBody(locateHeader.Body)
 		} else {
 			return nil, fmt.Errorf("body is not a LocateReplyHeader")
 		}
@@ -908,6 +946,13 @@ func UnmarshalGIOPMessage(data []byte) (*Message, error) {
 
 	// Create the message
 	msg := &Message{Header: header}
+	byteOrder := header.ByteOrder()
+	readBody := func() []byte {
+		if unmarshaller.reader.Len() > 0 && header.alignsBody() {
+			unmarshaller.align(8)
+		}
+		return unmarshaller.Remaining()
+	}
 
 	// Read the message body based on the message type
 	switch header.MsgType {
@@ -916,6 +961,8 @@ func UnmarshalGIOPMessage(data []byte) (*Message, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read request header: %w", err)
 		}
+		requestHeader.Body = readBody()
+		requestHeader.ByteOrder = byteOrder
 		msg.Body = requestHeader
 
 	case MsgReply:
@@ -923,6 +970,8 @@ func UnmarshalGIOPMessage(data []byte) (*Message, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read reply header: %w", err)
 		}
+		replyHeader.Body = readBody()
+		replyHeader.ByteOrder = byteOrder
 		msg.Body = replyHeader
 
 	case MsgCancelRequest:
@@ -955,6 +1004,8 @@ func UnmarshalGIOPMessage(data []byte) (*Message, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read locate reply status: %w", err)
 		}
+		locateHeader.Body = readBody()
+		locateHeader.ByteOrder = byteOrder
 		msg.Body = locateHeader
 
 	case MsgCloseConn:
@@ -9,6 +9,9 @@ import (
 	"time"
 )
 
+// messageHeaderSize is the size of the header that starts every GIOP message
+const messageHeaderSize = 12
+
 // GIOP message types
 const (
 	MsgRequest       = 0
@@ -74,7 +77,9 @@ type RequestHeader struct {
 	ResponseExpected bool
 	ObjectKey        []byte
 	Operation        string
-	Principal        []byte // Deprecated in GIOP 1.2+
+	Principal        []byte           // Deprecated in GIOP 1.2+
+	Body             []byte           // Encoded arguments following the header
+	ByteOrder        binary.ByteOrder // Byte order of Body, set when the message is unmarshalled
 }
 
 // ReplyHeader contains fields specific to a reply message
@@ -82,6 +87,8 @@ type ReplyHeader struct {
 	ServiceContexts ServiceContextList
 	RequestID       uint32
 	ReplyStatus     uint32
+	Body            []byte           // Encoded result, exception or forward IOR following the header
+	ByteOrder       binary.ByteOrder // Byte order of Body, set when the message is unmarshalled
 }
 
 // CancelRequestHeader contains fields specific to a cancel request message
@@ -99,6 +106,8 @@ type LocateRequestHeader struct {
 type LocateReplyHeader struct {
 	RequestID uint32
 	Status    uint32
+	Body      []byte           // Forward IOR for OBJECT_FORWARD replies
+	ByteOrder binary.ByteOrder // Byte order of Body, set when the message is unmarshalled
 }
 
 // Message represents a complete GIOP message with header and body
@@ -165,6 +174,20 @@ func (h *MessageHeader) IsLittleEndian() bool {
 	return (h.Flags & 0x01) == 1
 }
 
+// ByteOrder returns the byte order the message is encoded in
+func (h *MessageHeader) ByteOrder() binary.ByteOrder {
+	if h.IsLittleEndian() {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// alignsBody reports whether the body following a request, reply or locate
+// reply header starts on an 8-octet boundary, as it does from GIOP 1.2 on
+func (h *MessageHeader) alignsBody() bool {
+	return h.Version[0] > 1 || (h.Version[0] == 1 && h.Version[1] >= 2)
+}
+
 // HasMoreFragments returns whether more fragments follow
 func (h *MessageHeader) HasMoreFragments() bool {
 	return (h.Flags & 0x02) == 2
@@ -0,0 +1,76 @@
+package idl_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ifabos/go-corba/idl"
+)
+
+func TestOperationContextClause(t *testing.T) {
+	idlContent := `
+module TestMod {
+    interface Greeter {
+        string greet(in string name) context("USER", "LOCALE*");
+        void reset();
+    };
+};
+`
+	parser := idl.NewParser()
+	if err := parser.Parse(bytes.NewBufferString(idlContent)); err != nil {
+		t.Fatalf("Error parsing IDL: %v", err)
+	}
+	testMod, exists := parser.GetRootModule().GetSubmodule("TestMod")
+	if !exists {
+		t.Fatal("TestMod module not found")
+	}
+	iface, ok := testMod.Types["Greeter"].(*idl.InterfaceType)
+	if !ok {
+		t.Fatal("Greeter is not an interface type")
+	}
+	if len(iface.Operations) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(iface.Operations))
+	}
+	if got, want := iface.Operations[0].Contexts, []string{"USER", "LOCALE*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("greet context clause = %q, want %q", got, want)
+	}
+	if got := iface.Operations[1].Contexts; len(got) != 0 {
+		t.Errorf("reset context clause = %q, want none", got)
+	}
+
+	dir := t.TempDir()
+	gen := idl.NewGenerator(testMod, dir)
+	gen.SetPackageName("testpkg")
+	if err := gen.Generate(); err != nil {
+		t.Fatalf("Generator failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "testmod", "greeter.go"))
+	if err != nil {
+		t.Fatalf("Reading generated file: %v", err)
+	}
+	for _, want := range []string{
+		`greet(name string, _ctx *corba.Context) (string, error)`,
+		`InvokeContext(_ctx, []string{"USER", "LOCALE*"}, "greet", name)`,
+		`corba.IDLContextFromContext(ctx)`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Generated code does not contain %q", want)
+		}
+	}
+}
+
+func TestOperationContextClauseErrors(t *testing.T) {
+	for _, idlContent := range []string{
+		`interface I { void op() context(); };`,
+		`interface I { void op() context(USER); };`,
+		`interface I { void op() context("USER"; };`,
+	} {
+		if err := idl.NewParser().Parse(bytes.NewBufferString(idlContent)); err == nil {
+			t.Errorf("Parsing %q succeeded", idlContent)
+		}
+	}
+}
@@ -79,6 +79,7 @@ func (g *Generator) initTemplates() error {
 		"outParams":    g.outParams,
 		"paramList":    g.paramList,
 		"argList":      g.argList,
+		"contextList":  g.contextList,
 		"hasPrefix":    strings.HasPrefix,
 		"isStructType": func(arg interface{}) (res bool) {
 			// 支持 Type, *StructType, string (type name)，并递归解 typedef
@@ -263,15 +264,29 @@ func (g *Generator) outParams(op Operation) string {
 	return strings.Join(params, ", ")
 }
 
-// paramList returns function parameter list for an operation
+// paramList returns function parameter list for an operation. Operations
+// with a context clause take the caller's Context as a last parameter.
 func (g *Generator) paramList(op Operation) string {
 	var params []string
 	for _, p := range op.Parameters {
 		params = append(params, fmt.Sprintf("%s %s", uncapitalize(p.Name), p.Type.GoTypeName()))
 	}
+	if len(op.Contexts) > 0 {
+		params = append(params, "_ctx *corba.Context")
+	}
 	return strings.Join(params, ", ")
 }
 
+// contextList returns the property names of an operation's context clause as
+// a list of Go string literals
+func (g *Generator) contextList(op Operation) string {
+	var names []string
+	for _, name := range op.Contexts {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	return strings.Join(names, ", ")
+}
+
 // argList returns argument list for method calls
 func (g *Generator) argList(op Operation) string {
 	var args []string
@@ -340,6 +355,19 @@ func (h *{{.Interface.Name}}Helper) Narrow(obj interface{}) ({{.Interface.Name}}
 	if intf, ok := obj.({{.Interface.Name}}); ok {
 		return intf, nil
 	}
+
+	// A reference whose type ID differs, for example one typed as a base
+	// interface, is checked with a remote _is_a
+	if ref, ok := obj.(*corba.ObjectRef); ok {
+		isA, err := ref.IsA(h.ID())
+		if err != nil {
+			return nil, err
+		}
+		if !isA {
+			return nil, fmt.Errorf("object %s does not implement {{.Interface.Name}}", ref.GetTypeID())
+		}
+		return &{{.Interface.Name}}Stub{ObjectRef: ref}, nil
+	}
 	
 	return nil, fmt.Errorf("object does not implement {{.Interface.Name}}")
 }
@@ -353,7 +381,11 @@ type {{.Interface.Name}}Stub struct {
 // {{.Name}} implements the {{.Name}} operation
 func (stub *{{$.Interface.Name}}Stub) {{.Name}}({{paramList .}}) ({{outParams .}}) {
 	// Invoke remote method via CORBA
+	{{if .Contexts}}
+	_result, err := stub.ObjectRef.InvokeContext(_ctx, []string{ {{contextList .}} }, "{{.Name}}", {{argList .}})
+	{{else}}
 	_result, err := stub.ObjectRef.Invoke("{{.Name}}", {{argList .}})
+	{{end}}
 	if err != nil {
 		{{if eq (goType .ReturnType) ""}}
 		return err
@@ -414,10 +446,46 @@ func (stub *{{$.Interface.Name}}Stub) Set{{capitalize .Name}}(value {{goType .Ty
 type {{.Interface.Name}}Servant struct {
 	// Embed the implementation here
 	Impl {{.Interface.Name}}
+
+	// ImplFor, if set, returns the implementation for the object a request
+	// targets. A default servant uses it with corba.POACurrentFromContext to
+	// serve many objects, for example by loading each one from a database.
+	ImplFor func(ctx context.Context) ({{.Interface.Name}}, error)
+}
+
+// PrimaryInterface returns the repository ID the ORB reports for objects
+// incarnated by the servant
+func (servant *{{.Interface.Name}}Servant) PrimaryInterface() string {
+	return (&{{.Interface.Name}}Helper{}).ID()
+}
+
+// ContextClause returns the property names of the context clause of an
+// operation, whose values the ORB passes on with the request
+func (servant *{{.Interface.Name}}Servant) ContextClause(operation string) []string {
+	switch operation {
+	{{range .Interface.Operations}}{{if .Contexts}}
+	case "{{.Name}}":
+		return []string{ {{contextList .}} }
+	{{end}}{{end}}
+	}
+	return nil
 }
 
 // Dispatch handles incoming method calls to the servant
 func (servant *{{.Interface.Name}}Servant) Dispatch(methodName string, args []interface{}) (interface{}, error) {
+	return servant.DispatchContext(context.Background(), methodName, args)
+}
+
+// DispatchContext handles incoming method calls with the context of the request
+func (servant *{{.Interface.Name}}Servant) DispatchContext(ctx context.Context, methodName string, args []interface{}) (interface{}, error) {
+	impl := servant.Impl
+	if servant.ImplFor != nil {
+		var err error
+		if impl, err = servant.ImplFor(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	switch methodName {
 	{{range .Interface.Operations}}
 	case "{{.Name}}":
@@ -431,17 +499,20 @@ func (servant *{{.Interface.Name}}Servant) Dispatch(methodName string, args []in
 			return nil, fmt.Errorf("argument %d for {{$.Name}} has wrong type", {{$i}})
 		}
 		{{- end}}
+		{{- if .Contexts}}
+		_ctx, _ := corba.IDLContextFromContext(ctx)
+		{{- end}}
 		{{if eq (goType .ReturnType) ""}}
-		err := servant.Impl.{{.Name}}({{range $i, $p := .Parameters}}{{if $i}}, {{end}}{{uncapitalize $p.Name}}{{end}})
+		err := impl.{{.Name}}({{range $i, $p := .Parameters}}{{if $i}}, {{end}}{{uncapitalize $p.Name}}{{end}}{{if .Contexts}}{{if .Parameters}}, {{end}}_ctx{{end}})
 		return nil, err
 		{{else}}
-		_result, err := servant.Impl.{{.Name}}({{range $i, $p := .Parameters}}{{if $i}}, {{end}}{{uncapitalize $p.Name}}{{end}})
+		_result, err := impl.{{.Name}}({{range $i, $p := .Parameters}}{{if $i}}, {{end}}{{uncapitalize $p.Name}}{{end}}{{if .Contexts}}{{if .Parameters}}, {{end}}_ctx{{end}})
 		return _result, err
 		{{end}}
 	{{end}}
 	{{range .Interface.Attributes}}
 	case "_get_{{.Name}}":
-		return servant.Impl.Get{{capitalize .Name}}()
+		return impl.Get{{capitalize .Name}}()
 	{{if not .Readonly}}
 	case "_set_{{.Name}}":
 		if len(args) != 1 {
@@ -451,7 +522,7 @@ func (servant *{{.Interface.Name}}Servant) Dispatch(methodName string, args []in
 		if !ok {
 			return nil, fmt.Errorf("wrong argument type for _set_{{.Name}}")
 		}
-		return nil, servant.Impl.Set{{capitalize .Name}}(value)
+		return nil, impl.Set{{capitalize .Name}}(value)
 	{{end}}
 	{{end}}
 	default:
@@ -833,12 +833,69 @@ func (p *Parser) parseInterface() error {
 			}
 		}
 
+		// Check for context clause
+		var contexts []string
+		if p.currentToken.value == "context" {
+			// Skip "context"
+			if err := p.nextToken(); err != nil {
+				return err
+			}
+
+			// Expect opening parenthesis
+			if p.currentToken.typ != tokenOpenParen {
+				return fmt.Errorf("%s:%d:%d: expected '(' after context, got %s",
+					p.currentToken.filename, p.currentToken.line, p.currentToken.column, p.currentToken.value)
+			}
+
+			// Skip opening parenthesis
+			if err := p.nextToken(); err != nil {
+				return err
+			}
+
+			// Parse property name list
+			for {
+				if p.currentToken.typ != tokenString {
+					return fmt.Errorf("%s:%d:%d: expected context property name, got %s",
+						p.currentToken.filename, p.currentToken.line, p.currentToken.column, p.currentToken.value)
+				}
+
+				contexts = append(contexts, p.currentToken.value)
+
+				// Skip property name
+				if err := p.nextToken(); err != nil {
+					return err
+				}
+
+				// Check for more property names
+				if p.currentToken.typ != tokenComma {
+					break
+				}
+
+				// Skip comma
+				if err := p.nextToken(); err != nil {
+					return err
+				}
+			}
+
+			// Expect closing parenthesis
+			if p.currentToken.typ != tokenCloseParen {
+				return fmt.Errorf("%s:%d:%d: expected ')' after context property names, got %s",
+					p.currentToken.filename, p.currentToken.line, p.currentToken.column, p.currentToken.value)
+			}
+
+			// Skip closing parenthesis
+			if err := p.nextToken(); err != nil {
+				return err
+			}
+		}
+
 		// Create operation
 		operation := Operation{
 			Name:       operationName,
 			ReturnType: returnType,
 			Parameters: parameters,
 			Raises:     raises,
+			Contexts:   contexts,
 			Oneway:     oneway,
 		}
 
@@ -238,6 +238,7 @@ type Operation struct {
 	ReturnType Type
 	Parameters []Parameter
 	Raises     []string
+	Contexts   []string // Property names of the context clause, possibly ending in '*'
 	Oneway     bool
 }
 